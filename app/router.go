@@ -4,6 +4,7 @@ import (
 	"digital-book-lending/controller"
 	"digital-book-lending/interfaces"
 	"digital-book-lending/middleware"
+	"digital-book-lending/opds"
 	"digital-book-lending/services"
 	"digital-book-lending/utils"
 	"digital-book-lending/utils/response"
@@ -20,14 +21,23 @@ import (
 )
 
 type Routes struct {
-	App            *gin.Engine
-	BookService    *services.BookService
-	UserService    *services.UserService
-	LendingService *services.LendingService
-	BlacklistRepo  interfaces.Blacklist
+	App                 *gin.Engine
+	BookService         *services.BookService
+	AuthorService       *services.AuthorService
+	CategoryService     *services.CategoryService
+	CopyService         *services.BookCopyService
+	UserService         *services.UserService
+	LendingService      *services.LendingService
+	HoldService         *services.HoldService
+	FineService         *services.FineService
+	EbookService        *services.EbookService
+	PolicyService       *services.LendingPolicyService
+	NotificationService *services.NotificationService
+	WebhookService      *services.WebhookService
+	BlacklistRepo       interfaces.Blacklist
 }
 
-func NewRoutes(bookService *services.BookService, userService *services.UserService, lendingService *services.LendingService, blacklistRepo interfaces.Blacklist) *Routes {
+func NewRoutes(bookService *services.BookService, authorService *services.AuthorService, categoryService *services.CategoryService, copyService *services.BookCopyService, userService *services.UserService, lendingService *services.LendingService, holdService *services.HoldService, fineService *services.FineService, ebookService *services.EbookService, policyService *services.LendingPolicyService, notificationService *services.NotificationService, webhookService *services.WebhookService, blacklistRepo interfaces.Blacklist) *Routes {
 	app := gin.Default()
 
 	app.Use(middleware.CORS())
@@ -45,18 +55,39 @@ func NewRoutes(bookService *services.BookService, userService *services.UserServ
 	app.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	return &Routes{
-		App:            app,
-		BookService:    bookService,
-		UserService:    userService,
-		LendingService: lendingService,
-		BlacklistRepo:  blacklistRepo,
+		App:                 app,
+		BookService:         bookService,
+		AuthorService:       authorService,
+		CategoryService:     categoryService,
+		CopyService:         copyService,
+		UserService:         userService,
+		LendingService:      lendingService,
+		HoldService:         holdService,
+		FineService:         fineService,
+		EbookService:        ebookService,
+		PolicyService:       policyService,
+		NotificationService: notificationService,
+		WebhookService:      webhookService,
+		BlacklistRepo:       blacklistRepo,
 	}
 }
 
 func (r *Routes) BookLending() {
 	ctrlUser := controller.NewUserController(r.UserService)
 	ctrlBook := controller.NewBookController(r.BookService)
+	ctrlAuthor := controller.NewAuthorController(r.AuthorService)
+	ctrlCategory := controller.NewCategoryController(r.CategoryService)
+	ctrlCopy := controller.NewBookCopyController(r.CopyService)
 	ctrlLending := controller.NewLendingController(r.LendingService)
+	ctrlHold := controller.NewHoldController(r.HoldService)
+	ctrlFine := controller.NewFineController(r.FineService)
+	ctrlEbook := controller.NewEbookController(r.EbookService)
+	ctrlPolicy := controller.NewLendingPolicyController(r.PolicyService)
+	ctrlNotification := controller.NewNotificationController(r.NotificationService)
+	ctrlWebhook := controller.NewWebhookController(r.WebhookService)
+	ctrlOpds := controller.NewOpdsController(r.BookService, r.LendingService, opds.Version1)
+	ctrlOpdsV2 := controller.NewOpdsController(r.BookService, r.LendingService, opds.Version2)
+	ctrlOai := controller.NewOaiController(r.BookService)
 
 	apiV1 := r.App.Group("/api/v1")
 	{
@@ -72,20 +103,139 @@ func (r *Routes) BookLending() {
 		book := apiV1.Group("/books")
 		{
 			book.GET("", ctrlBook.List)
+			book.GET("/search", ctrlBook.Search)
+			book.GET("/suggest", ctrlBook.Suggest)
+			book.GET("/:id", ctrlBook.Get)
+			book.GET("/:id/cover", ctrlBook.Cover)
 
 			adminBook := book.Group("").Use(r.AuthMiddleware(), r.RoleMiddleware(utils.RoleAdmin))
 			{
 				adminBook.POST("", ctrlBook.Create)
+				adminBook.POST("/import", ctrlBook.Import)
+				adminBook.POST("/import/marc", ctrlBook.ImportMarc)
+				adminBook.GET("/export/marc", ctrlBook.ExportMarc)
 				adminBook.PUT("/update/:id", ctrlBook.Update)
 				adminBook.DELETE("delete/:id", ctrlBook.Delete)
+				adminBook.PUT("/:id/cover", ctrlBook.UploadCover)
+				adminBook.PUT("/:id/ebook", ctrlBook.UploadEbook)
+				adminBook.PUT("/:id/license", ctrlBook.SetLicense)
+				adminBook.GET("/:id/copies", ctrlCopy.List)
+				adminBook.POST("/:id/copies", ctrlCopy.Create)
 			}
 
 			lendingBook := book.Group("").Use(r.AuthMiddleware(), r.RoleMiddleware(utils.RoleAdmin, utils.RoleMember))
 			{
 				lendingBook.POST("/:id/borrow", ctrlLending.BorrowBook)
 				lendingBook.POST("/:id/return", ctrlLending.ReturnBook)
+				lendingBook.POST("/:id/hold", ctrlHold.PlaceHold)
 			}
 		}
+
+		// author route
+		author := apiV1.Group("/authors")
+		{
+			author.GET("", ctrlAuthor.List)
+			author.GET("/:id", ctrlAuthor.Get)
+
+			adminAuthor := author.Group("").Use(r.AuthMiddleware(), r.RoleMiddleware(utils.RoleAdmin))
+			{
+				adminAuthor.POST("", ctrlAuthor.Create)
+				adminAuthor.PUT("/:id", ctrlAuthor.Update)
+				adminAuthor.DELETE("/:id", ctrlAuthor.Delete)
+				adminAuthor.POST("/:id/merge", ctrlAuthor.Merge)
+			}
+		}
+
+		// category route
+		category := apiV1.Group("/categories")
+		{
+			category.GET("", ctrlCategory.List)
+			category.GET("/:id", ctrlCategory.Get)
+
+			adminCategory := category.Group("").Use(r.AuthMiddleware(), r.RoleMiddleware(utils.RoleAdmin))
+			{
+				adminCategory.POST("", ctrlCategory.Create)
+				adminCategory.PUT("/:id", ctrlCategory.Update)
+				adminCategory.DELETE("/:id", ctrlCategory.Delete)
+				adminCategory.POST("/:id/merge", ctrlCategory.Merge)
+			}
+		}
+
+		// OPDS catalog route for e-reader apps, public with optional sign-in
+		opdsFeed := apiV1.Group("/opds").Use(r.OptionalAuthMiddleware())
+		{
+			opdsFeed.GET("", ctrlOpds.Root)
+			opdsFeed.GET("/books", ctrlOpds.Books)
+			opdsFeed.GET("/categories", ctrlOpds.Categories)
+			opdsFeed.GET("/authors", ctrlOpds.Authors)
+			opdsFeed.GET("/search.xml", ctrlOpds.SearchDescription)
+		}
+		opdsFeedV2 := apiV1.Group("/opds/v2").Use(r.OptionalAuthMiddleware())
+		{
+			opdsFeedV2.GET("", ctrlOpdsV2.Root)
+			opdsFeedV2.GET("/books", ctrlOpdsV2.Books)
+			opdsFeedV2.GET("/categories", ctrlOpdsV2.Categories)
+			opdsFeedV2.GET("/authors", ctrlOpdsV2.Authors)
+		}
+
+		// OAI-PMH harvesting route for union catalogs
+		apiV1.GET("/oai", ctrlOai.Handle)
+		apiV1.POST("/oai", ctrlOai.Handle)
+
+		// hold route
+		hold := apiV1.Group("/holds").Use(r.AuthMiddleware(), r.RoleMiddleware(utils.RoleAdmin, utils.RoleMember))
+		{
+			hold.GET("", ctrlHold.List)
+			hold.DELETE("/:id", ctrlHold.Cancel)
+		}
+
+		// lending route
+		lending := apiV1.Group("/lendings").Use(r.AuthMiddleware(), r.RoleMiddleware(utils.RoleAdmin, utils.RoleMember))
+		{
+			lending.POST("/:id/renew", ctrlLending.RenewBook)
+			lending.GET("/:id/ebook", ctrlEbook.AccessLink)
+		}
+
+		// e-book route, authorized by the signed link instead of a token
+		apiV1.GET("/ebooks/:id", ctrlEbook.Read)
+
+		// logged-in user route
+		me := apiV1.Group("/me").Use(r.AuthMiddleware(), r.RoleMiddleware(utils.RoleAdmin, utils.RoleMember))
+		{
+			me.GET("/lendings", ctrlLending.MyLendings)
+			me.GET("/fines", ctrlFine.MyFines)
+			me.GET("/notification-preferences", ctrlNotification.MyPreferences)
+			me.PUT("/notification-preferences", ctrlNotification.UpdateMyPreferences)
+		}
+
+		// admin route
+		admin := apiV1.Group("/admin").Use(r.AuthMiddleware(), r.RoleMiddleware(utils.RoleAdmin))
+		{
+			admin.GET("/lendings", ctrlLending.List)
+			admin.GET("/lendings/overdue", ctrlLending.ListOverdue)
+			admin.POST("/lendings/checkout", ctrlLending.Checkout)
+			admin.POST("/lendings/:id/checkin", ctrlLending.Checkin)
+
+			admin.PUT("/copies/:id", ctrlCopy.Update)
+			admin.GET("/licenses/expiring", ctrlBook.ExpiringLicenses)
+
+			admin.GET("/users/:id/fines", ctrlFine.UserFines)
+			admin.POST("/users/:id/fines/payments", ctrlFine.RecordPayment)
+			admin.POST("/users/:id/fines/waivers", ctrlFine.WaiveFine)
+
+			admin.GET("/policies", ctrlPolicy.List)
+			admin.POST("/policies", ctrlPolicy.Create)
+			admin.PUT("/policies/:id", ctrlPolicy.Update)
+			admin.DELETE("/policies/:id", ctrlPolicy.Delete)
+
+			admin.GET("/webhooks", ctrlWebhook.List)
+			admin.POST("/webhooks", ctrlWebhook.Create)
+			admin.PUT("/webhooks/:id", ctrlWebhook.Update)
+			admin.DELETE("/webhooks/:id", ctrlWebhook.Delete)
+			admin.GET("/webhooks/:id/deliveries", ctrlWebhook.Deliveries)
+			admin.GET("/webhook-deliveries/:id", ctrlWebhook.Delivery)
+			admin.POST("/webhook-deliveries/:id/replay", ctrlWebhook.Replay)
+		}
 	}
 }
 
@@ -136,6 +286,19 @@ func (r *Routes) AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalAuthMiddleware authenticates a request that carries a token like
+// AuthMiddleware and lets one without a token through anonymously.
+func (r *Routes) OptionalAuthMiddleware() gin.HandlerFunc {
+	auth := r.AuthMiddleware()
+	return func(ctx *gin.Context) {
+		if utils.GetAuthToken(ctx) == "" {
+			ctx.Next()
+			return
+		}
+		auth(ctx)
+	}
+}
+
 func (r *Routes) RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var (
@@ -0,0 +1,355 @@
+package controller
+
+import (
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/request"
+	"digital-book-lending/utils/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuthorCtrl struct {
+	authorService *services.AuthorService
+}
+
+func NewAuthorController(authorService *services.AuthorService) *AuthorCtrl {
+	return &AuthorCtrl{authorService: authorService}
+}
+
+// List godoc
+// @Summary List authors
+// @Description List the authors alphabetically with their number of books
+// @Tags authors
+// @Accept  json
+// @Produce  json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param search query string false "Only authors whose name contains this"
+// @Success 200 {object} response.Pagination
+// @Failure 500 {object} response.Error
+// @Router /authors [get]
+func (c *AuthorCtrl) List(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Author][List]", logId)
+
+	//query parameters
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	authors, totalData, err := c.authorService.ListAuthors(page, limit, ctx.Query("search"))
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; authorService.ListAuthors; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(totalData), page, limit, logId, authors)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; List: %v", logPrefix, utils.JsonEncode(authors)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Get godoc
+// @Summary Get an author
+// @Description Get an author with their number of books
+// @Tags authors
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Author ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /authors/{id} [get]
+func (c *AuthorCtrl) Get(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Author][Get]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s]", id)
+
+	author, err := c.authorService.GetAuthor(id)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; authorService.GetAuthor; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusNotFound, Message: "author not found"}
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, author)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(author)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Create godoc
+// @Summary Create an author
+// @Description Add an author to give books to by ID
+// @Tags authors
+// @Accept  json
+// @Produce  json
+// @Param author body request.Author true "Author details"
+// @Success 201 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /authors [post]
+func (c *AuthorCtrl) Create(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.Author
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Author][Create]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	author, err := c.authorService.CreateAuthor(req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; authorService.CreateAuthor; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			res := response.Response(http.StatusConflict, utils.MsgExists, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusConflict, Message: fmt.Sprintf("an author named '%s' already exists", req.Name)}
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, "Add author successfully", logId, author)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(author)))
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// Update godoc
+// @Summary Rename an author
+// @Description Rename an author; the author names shown on their books change with it
+// @Tags authors
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Author ID"
+// @Param author body request.Author true "Author details"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /authors/{id} [put]
+func (c *AuthorCtrl) Update(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.Author
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Author][Update]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	rows, err := c.authorService.UpdateAuthor(id, req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; authorService.UpdateAuthor; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			res := response.Response(http.StatusConflict, utils.MsgExists, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusConflict, Message: fmt.Sprintf("an author named '%s' already exists, merge them instead", req.Name)}
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	if rows == 0 {
+		res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusNotFound, Message: utils.NotFound}
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Author with ID: '%s' updated successfully", id), logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Author with ID: '%s' updated successfully; Data: %v", logPrefix, id, utils.JsonEncode(req)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Delete godoc
+// @Summary Delete an author
+// @Description Delete an author no book in the catalog is by
+// @Tags authors
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Author ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /authors/{id} [delete]
+func (c *AuthorCtrl) Delete(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Author][Delete]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	rows, err := c.authorService.DeleteAuthor(id)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; authorService.DeleteAuthor; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+	if rows == 0 {
+		res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusNotFound, Message: utils.NotFound}
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Author with ID: '%s' deleted successfully", id), logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Author with ID: '%s' deleted successfully", logPrefix, id))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Merge godoc
+// @Summary Merge authors
+// @Description Merge duplicate authors into this one: their books become this author's and the duplicates are deleted
+// @Tags authors
+// @Accept  json
+// @Produce  json
+// @Param id path string true "ID of the author to keep"
+// @Param merge body request.Merge true "Authors to merge into it"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /authors/{id}/merge [post]
+func (c *AuthorCtrl) Merge(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.Merge
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Author][Merge]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	author, err := c.authorService.MergeAuthors(id, req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; authorService.MergeAuthors; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusNotFound, Message: "author not found"}
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Authors merged into author with ID: '%s' successfully", id), logId, author)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(author)))
+	ctx.JSON(http.StatusOK, res)
+}
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"digital-book-lending/marc"
+	"digital-book-lending/models"
 	"digital-book-lending/services"
 	"digital-book-lending/utils"
 	"digital-book-lending/utils/functions"
@@ -8,9 +10,13 @@ import (
 	"digital-book-lending/utils/response"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -36,6 +42,8 @@ func NewBookController(bookService *services.BookService) *BookCtrl {
 // @Param book body request.AddBook true "Book details"
 // @Success 201 {object} response.Success
 // @Failure 400 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 422 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Security ApiKeyAuth
 // @Router /books [post]
@@ -63,6 +71,12 @@ func (c *BookCtrl) Create(ctx *gin.Context) {
 	book, err := c.bookService.CreateBook(req, username)
 	if err != nil {
 		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.CreateBook; Error: %+v", logPrefix, err))
+		if errors.Is(err, services.ErrUnknownAuthor) || errors.Is(err, services.ErrUnknownCategory) {
+			res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			ctx.JSON(http.StatusUnprocessableEntity, res)
+			return
+		}
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
 			res := response.Response(http.StatusConflict, utils.MsgExists, logId, nil)
 			res.Errors = response.Errors{Code: http.StatusConflict, Message: fmt.Sprintf("book with ISBN: %s already exists", req.ISBN)}
@@ -92,6 +106,7 @@ func (c *BookCtrl) Create(ctx *gin.Context) {
 // @Success 200 {object} response.Success
 // @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
+// @Failure 422 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Security ApiKeyAuth
 // @Router /books/update/{id} [put]
@@ -125,6 +140,12 @@ func (c *BookCtrl) Update(ctx *gin.Context) {
 	rows, err := c.bookService.UpdateBook(id, req, username)
 	if err != nil {
 		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.UpdateBook; Error: %+v", logPrefix, err))
+		if errors.Is(err, services.ErrUnknownAuthor) || errors.Is(err, services.ErrUnknownCategory) {
+			res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			ctx.JSON(http.StatusUnprocessableEntity, res)
+			return
+		}
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
 			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; ISBN: '%s' already exists", logPrefix, req.ISBN))
 			res := response.Response(http.StatusBadRequest, utils.MsgExists, logId, nil)
@@ -193,6 +214,54 @@ func (c *BookCtrl) Delete(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+// Get godoc
+// @Summary Get a book
+// @Description Get a book with its live availability: copies on loan, available and set aside for holds, the hold queue length and the next expected return. For digital titles the copies are licensed seats.
+// @Tags books
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Book ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /books/{id} [get]
+func (c *BookCtrl) Get(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][Get]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s]", id)
+
+	book, err := c.bookService.GetBook(id)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.GetBook; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusNotFound, Message: "book not found"}
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, book)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(book)))
+	ctx.JSON(http.StatusOK, res)
+}
+
 // List godoc
 // @Summary List books
 // @Description List books
@@ -201,10 +270,22 @@ func (c *BookCtrl) Delete(ctx *gin.Context) {
 // @Produce  json
 // @Param page query int false "Page number"
 // @Param limit query int false "Number of items per page"
-// @Param order_by query string false "Order by field"
+// @Param order_by query string false "Order by field, or relevance when searching (default: relevance with a natural or boolean search, updated_at otherwise)"
 // @Param order_direction query string false "Order direction (asc/desc)"
 // @Param search query string false "Search query"
+// @Param search_mode query string false "How search matches: like (default), natural or boolean" Enums(natural, boolean, like)
+// @Param category query []string false "Only books in these categories, repeated or comma separated" collectionFormat(multi)
+// @Param author query []string false "Only books by these authors, repeated or comma separated" collectionFormat(multi)
+// @Param category_id query []string false "Only books in these categories, by ID, repeated or comma separated" collectionFormat(multi)
+// @Param author_id query []string false "Only books by these authors, by ID, repeated or comma separated" collectionFormat(multi)
+// @Param in_stock query bool false "Only books that can be borrowed now"
+// @Param created_from query string false "Only books added on or after this date (YYYY-MM-DD)"
+// @Param created_to query string false "Only books added on or before this date (YYYY-MM-DD)"
+// @Param min_quantity query int false "Minimum quantity"
+// @Param max_quantity query int false "Maximum quantity"
+// @Param cursor query string false "Use cursor pagination instead of page: empty for the first page, then the next_cursor of the previous page (response is response.CursorPagination)"
 // @Success 200 {object} response.Pagination
+// @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /books [get]
@@ -228,11 +309,21 @@ func (c *BookCtrl) List(ctx *gin.Context) {
 	if err != nil || limit < 1 {
 		limit = 10
 	}
-	orderBy := ctx.DefaultQuery("order_by", "updated_at")
-	orderDir := ctx.DefaultQuery("order_direction", "desc")
-	search := ctx.Query("search")
+	filter, err := bookFilter(ctx)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookFilter; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if token, ok := ctx.GetQuery("cursor"); ok {
+		c.listAfter(ctx, logId, logPrefix, limit, filter, token)
+		return
+	}
 
-	books, totalData, err := c.bookService.ListBooks(page, limit, orderBy, orderDir, search)
+	books, totalData, err := c.bookService.ListBooks(page, limit, filter)
 	if err != nil {
 		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Fetch; Error: %+v", logPrefix, err))
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -248,7 +339,749 @@ func (c *BookCtrl) List(ctx *gin.Context) {
 		return
 	}
 
+	facets, err := c.bookService.GetFacets(filter)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.GetFacets; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
 	res := response.PaginationResponse(http.StatusOK, int(totalData), page, limit, logId, books)
+	res.Facets = facets
 	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Success; List: %v", logPrefix, utils.JsonEncode(books)))
 	ctx.JSON(http.StatusOK, res)
 }
+
+// listAfter answers List in cursor pagination: an empty cursor asks for the
+// first page, facets come with the first page only.
+func (c *BookCtrl) listAfter(ctx *gin.Context, logId uuid.UUID, logPrefix string, limit int, filter request.BookFilter, token string) {
+	var after *request.BookCursor
+	if token != "" {
+		cursor, err := request.DecodeBookCursor(token)
+		if err == nil && (cursor.OrderBy != filter.OrderBy || cursor.OrderDir != filter.OrderDir) {
+			err = errors.New("cursor was issued for another order_by or order_direction")
+		}
+		if err != nil {
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; DecodeBookCursor; Error: %+v", logPrefix, err))
+			res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+			res.Error = err.Error()
+			ctx.JSON(http.StatusBadRequest, res)
+			return
+		}
+		after = &cursor
+	}
+
+	books, nextCursor, err := c.bookService.ListBooksAfter(limit, filter, after)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.ListBooksAfter; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.CursorPaginationResponse(http.StatusOK, len(books), limit, nextCursor, logId, books)
+	if after == nil {
+		facets, err := c.bookService.GetFacets(filter)
+		if err != nil {
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.GetFacets; Error: %+v", logPrefix, err))
+			res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+			res.Error = err.Error()
+			ctx.JSON(http.StatusInternalServerError, res)
+			return
+		}
+		res.Facets = facets
+	}
+
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Success; List: %v", logPrefix, utils.JsonEncode(books)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Search godoc
+// @Summary Search books with typo tolerance
+// @Description Search the catalog index by title, author, category or exact ISBN, tolerating typos such as "harry poter". Matches are highlighted in <mark> tags.
+// @Tags books
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Search query"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Success 200 {object} response.Pagination
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /books/search [get]
+func (c *BookCtrl) Search(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][Search]", logId)
+
+	text := strings.TrimSpace(ctx.Query("q"))
+	if text == "" {
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = "q is required"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	page, limit := pageQuery(ctx)
+
+	bookHits, totalData, err := c.bookService.SearchBooks(text, page, limit)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.SearchBooks; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(totalData), page, limit, logId, bookHits)
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Success; Query: %s; Total: %d", logPrefix, text, totalData))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Suggest godoc
+// @Summary Autocomplete book titles and authors
+// @Description Suggest books whose title or author has a word starting with the last word typed, and contains the words before it
+// @Tags books
+// @Accept  json
+// @Produce  json
+// @Param q query string true "What has been typed so far"
+// @Param limit query int false "Number of suggestions (default: 10, at most 50)"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /books/suggest [get]
+func (c *BookCtrl) Suggest(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][Suggest]", logId)
+
+	prefix := strings.TrimSpace(ctx.Query("q"))
+	if prefix == "" {
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = "q is required"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	limit = min(limit, 50)
+
+	suggestions, err := c.bookService.SuggestBooks(prefix, limit)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.SuggestBooks; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, suggestions)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(suggestions)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// bookFilter reads the catalog filters from the query string. The order and
+// search parameters keep their old names and defaults.
+func bookFilter(ctx *gin.Context) (request.BookFilter, error) {
+	filter := request.BookFilter{
+		Search:      strings.TrimSpace(ctx.Query("search")),
+		SearchMode:  ctx.DefaultQuery("search_mode", utils.SearchLike),
+		Categories:  multiQuery(ctx, "category"),
+		Authors:     multiQuery(ctx, "author"),
+		CategoryIds: multiQuery(ctx, "category_id"),
+		AuthorIds:   multiQuery(ctx, "author_id"),
+		OrderBy:     ctx.Query("order_by"),
+		OrderDir:    ctx.DefaultQuery("order_direction", "desc"),
+	}
+
+	switch filter.SearchMode {
+	case utils.SearchNatural, utils.SearchBoolean, utils.SearchLike:
+	default:
+		return filter, fmt.Errorf("search_mode must be natural, boolean or like")
+	}
+
+	// a FULLTEXT search is ranked best match first unless another order is
+	// asked for; a relevance score cannot be used as a cursor, so cursor pages
+	// keep the usual order
+	_, cursorMode := ctx.GetQuery("cursor")
+	switch {
+	case filter.OrderBy == "" && filter.Search != "" && filter.SearchMode != utils.SearchLike && !cursorMode:
+		filter.OrderBy = "relevance"
+	case filter.OrderBy == "":
+		filter.OrderBy = "updated_at"
+	case filter.OrderBy == "relevance" && filter.Search == "":
+		return filter, fmt.Errorf("order_by relevance needs a search")
+	case filter.OrderBy == "relevance" && cursorMode:
+		return filter, fmt.Errorf("order_by relevance cannot be used with cursor")
+	}
+
+	if value := ctx.Query("in_stock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("in_stock must be true or false")
+		}
+		filter.InStock = inStock
+	}
+
+	dates := []struct {
+		key    string
+		target **time.Time
+	}{{"created_from", &filter.CreatedFrom}, {"created_to", &filter.CreatedTo}}
+	for _, date := range dates {
+		key, target := date.key, date.target
+		if value := ctx.Query(key); value != "" {
+			day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a date in YYYY-MM-DD format", key)
+			}
+			*target = &day
+		}
+	}
+
+	quantities := []struct {
+		key    string
+		target **int
+	}{{"min_quantity", &filter.MinQuantity}, {"max_quantity", &filter.MaxQuantity}}
+	for _, quantity := range quantities {
+		key, target := quantity.key, quantity.target
+		if value := ctx.Query(key); value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 0 {
+				return filter, fmt.Errorf("%s must be a whole number of 0 or more", key)
+			}
+			*target = &number
+		}
+	}
+
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedTo.Before(*filter.CreatedFrom) {
+		return filter, fmt.Errorf("created_to must not be before created_from")
+	}
+	if filter.MinQuantity != nil && filter.MaxQuantity != nil && *filter.MaxQuantity < *filter.MinQuantity {
+		return filter, fmt.Errorf("max_quantity must not be less than min_quantity")
+	}
+
+	return filter, nil
+}
+
+// multiQuery collects a query parameter given several times, comma separated,
+// or both.
+func multiQuery(ctx *gin.Context, key string) []string {
+	var values []string
+	for _, param := range ctx.QueryArray(key) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// UploadEbook godoc
+// @Summary Attach an e-book file to a book
+// @Description Upload an EPUB or PDF file that borrowers of the book can read, replacing the previous file
+// @Tags books
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path string true "Book ID"
+// @Param file formData file true "EPUB or PDF file"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /books/{id}/ebook [put]
+func (c *BookCtrl) UploadEbook(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][UploadEbook]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; FormFile ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusBadRequest, Message: "file is required"}
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	book, err := c.bookService.AttachEbook(ctx.Request.Context(), id, file, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.AttachEbook; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "E-book attached successfully", logId, book)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; File: %s (%d bytes)", logPrefix, file.Filename, file.Size))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Import godoc
+// @Summary Import books from CSV
+// @Description Create or update books by ISBN from a CSV file with the columns title, author, isbn, category and quantity. Every row is checked like POST /books and reported as created, updated, skipped or error.
+// @Tags books
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param file formData file true "CSV file with a header row"
+// @Param dry_run query bool false "Check and report without saving anything"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /books/import [post]
+func (c *BookCtrl) Import(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][Import][%s]", logId, username)
+
+	dryRun, err := strconv.ParseBool(ctx.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = "dry_run must be true or false"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; FormFile ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusBadRequest, Message: "file is required"}
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	maxSize := int64(utils.GetEnv("IMPORT_MAX_SIZE_MB", 10).(int)) << 20
+	if file.Size > maxSize {
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: fmt.Sprintf("import file is larger than %d MB", maxSize>>20)}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; file.Open; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	defer src.Close()
+
+	report, err := c.bookService.ImportBooks(src, dryRun, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.ImportBooks; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, report)
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Success; File: %s; DryRun: %t; Created: %d; Updated: %d; Skipped: %d; Failed: %d", logPrefix, file.Filename, dryRun, report.Created, report.Updated, report.Skipped, report.Failed))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ImportMarc godoc
+// @Summary Import books from MARC
+// @Description Create or update books by ISBN from MARC21 binary or MARCXML records, reading 020 $a as the ISBN, 100 $a as the author, 245 $a and $b as the title and 650 $a as the category. Every record is reported as created, updated, skipped or error.
+// @Tags books
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param file formData file true "MARC21 (.mrc) or MARCXML (.xml) file"
+// @Param format query string false "marc or marcxml (default: from the file extension)" Enums(marc, marcxml)
+// @Param copies query int false "Copies of each new book (default: 1); existing books keep theirs"
+// @Param dry_run query bool false "Check and report without saving anything"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /books/import/marc [post]
+func (c *BookCtrl) ImportMarc(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][ImportMarc][%s]", logId, username)
+
+	dryRun, err := strconv.ParseBool(ctx.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = "dry_run must be true or false"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	copies, err := strconv.Atoi(ctx.DefaultQuery("copies", "1"))
+	if err != nil || copies < 1 {
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = "copies must be a whole number of 1 or more"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; FormFile ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusBadRequest, Message: "file is required"}
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	format := ctx.Query("format")
+	if format == "" {
+		switch strings.ToLower(path.Ext(file.Filename)) {
+		case ".mrc", ".marc":
+			format = marc.FormatBinary
+		case ".xml":
+			format = marc.FormatXML
+		}
+	}
+	if format != marc.FormatBinary && format != marc.FormatXML {
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = "format must be marc or marcxml"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	maxSize := int64(utils.GetEnv("IMPORT_MAX_SIZE_MB", 10).(int)) << 20
+	if file.Size > maxSize {
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: fmt.Sprintf("import file is larger than %d MB", maxSize>>20)}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; file.Open; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	defer src.Close()
+
+	report, err := c.bookService.ImportMarc(src, format, copies, dryRun, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.ImportMarc; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, report)
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Success; File: %s; Format: %s; DryRun: %t; Created: %d; Updated: %d; Skipped: %d; Failed: %d", logPrefix, file.Filename, format, dryRun, report.Created, report.Updated, report.Skipped, report.Failed))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ExportMarc godoc
+// @Summary Export books as MARC
+// @Description Download the books matching the list filters as MARC21 binary or MARCXML records with 001 id, 020 ISBN, 100 first author, 700 other authors, 245 title and 650 categories
+// @Tags books
+// @Produce  application/marc
+// @Produce  application/marcxml+xml
+// @Param format query string false "marc or marcxml (default: marcxml)" Enums(marc, marcxml)
+// @Param search query string false "Search query"
+// @Param category query []string false "Only books in these categories" collectionFormat(multi)
+// @Param author query []string false "Only books by these authors" collectionFormat(multi)
+// @Param category_id query []string false "Only books in these categories, by ID" collectionFormat(multi)
+// @Param author_id query []string false "Only books by these authors, by ID" collectionFormat(multi)
+// @Param in_stock query bool false "Only books that can be borrowed now"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /books/export/marc [get]
+func (c *BookCtrl) ExportMarc(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][ExportMarc][%s]", logId, username)
+
+	filter, err := bookFilter(ctx)
+	if err != nil {
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	var contentType, filename string
+	format := ctx.DefaultQuery("format", marc.FormatXML)
+	switch format {
+	case marc.FormatBinary:
+		contentType, filename = "application/marc", "books.mrc"
+	case marc.FormatXML:
+		contentType, filename = "application/marcxml+xml", "books.xml"
+	default:
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = "format must be marc or marcxml"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	// the records are streamed, so a failure part way can only be logged
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Status(http.StatusOK)
+
+	written, err := c.bookService.ExportMarc(ctx.Writer, format, filter)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.ExportMarc; after %d records; Error: %+v", logPrefix, written, err))
+		return
+	}
+
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Success; Format: %s; Records: %d", logPrefix, format, written))
+}
+
+// UploadCover godoc
+// @Summary Upload the cover image of a book
+// @Description Upload a JPEG, PNG or WebP cover image, replacing the previous cover
+// @Tags books
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path string true "Book ID"
+// @Param file formData file true "Cover image"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /books/{id}/cover [put]
+func (c *BookCtrl) UploadCover(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][UploadCover]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; FormFile ERROR: %s;", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusBadRequest, Message: "file is required"}
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	book, err := c.bookService.AttachCover(ctx.Request.Context(), id, file, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.AttachCover; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Cover uploaded successfully", logId, book)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; File: %s (%d bytes)", logPrefix, file.Filename, file.Size))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Cover godoc
+// @Summary Get the cover image of a book
+// @Description Serve the cover image of a book from the configured storage
+// @Tags books
+// @Produce  image/jpeg
+// @Produce  image/png
+// @Produce  image/webp
+// @Param id path string true "Book ID"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Router /books/{id}/cover [get]
+func (c *BookCtrl) Cover(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][Cover]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+
+	book, obj, err := c.bookService.OpenCover(ctx.Request.Context(), id)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.OpenCover; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusNotFound, Message: err.Error()}
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+	defer obj.Close()
+
+	serveAsset(ctx, "inline", fmt.Sprintf("%s%s", book.ID, path.Ext(*book.Cover.Key)), book, book.Cover, obj)
+}
+
+// serveAsset streams a book asset, answering range and conditional requests.
+// The checksum doubles as the ETag so clients can check what they downloaded.
+func serveAsset(ctx *gin.Context, disposition, filename string, book models.Book, asset models.Asset, obj io.ReadSeeker) {
+	if asset.ContentType != nil {
+		ctx.Header("Content-Type", *asset.ContentType)
+	}
+	if asset.Checksum != nil {
+		ctx.Header("ETag", fmt.Sprintf("%q", *asset.Checksum))
+		ctx.Header("X-Checksum-Sha256", *asset.Checksum)
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, filename))
+
+	var modTime time.Time
+	if book.UpdatedAt != nil {
+		modTime = *book.UpdatedAt
+	}
+	http.ServeContent(ctx.Writer, ctx.Request, filename, modTime, obj)
+}
+
+// SetLicense godoc
+// @Summary Set the publisher license of a digital title
+// @Description Put a book under a one-copy-one-user, metered, time-limited or simultaneous-use license; licensed books are lent as e-books
+// @Tags books
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Book ID"
+// @Param license body request.BookLicense true "License terms"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /books/{id}/license [put]
+func (c *BookCtrl) SetLicense(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.BookLicense
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][SetLicense]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	book, err := c.bookService.SetLicense(id, req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.SetLicense; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "License set successfully", logId, book)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; License: %v", logPrefix, utils.JsonEncode(req)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ExpiringLicenses godoc
+// @Summary List licenses close to running out
+// @Description List digital titles whose license expires within the given days or has at most the given checkouts left, including licenses that already ran out
+// @Tags books
+// @Accept  json
+// @Produce  json
+// @Param days query int false "Expiring within this many days (default LICENSE_EXPIRY_WARN_DAYS)"
+// @Param checkouts query int false "At most this many checkouts left (default LICENSE_CHECKOUTS_WARN)"
+// @Success 200 {object} response.Success
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/licenses/expiring [get]
+func (c *BookCtrl) ExpiringLicenses(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Book][ExpiringLicenses]", logId)
+
+	days, err := strconv.Atoi(ctx.Query("days"))
+	if err != nil || days < 0 {
+		days = utils.GetEnv("LICENSE_EXPIRY_WARN_DAYS", 30).(int)
+	}
+	checkouts, err := strconv.Atoi(ctx.Query("checkouts"))
+	if err != nil || checkouts < 0 {
+		checkouts = utils.GetEnv("LICENSE_CHECKOUTS_WARN", 5).(int)
+	}
+
+	reports, err := c.bookService.ExpiringLicenses(days, checkouts)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.ExpiringLicenses; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, reports)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; Days: %d; Checkouts: %d; Found: %d", logPrefix, days, checkouts, len(reports)))
+	ctx.JSON(http.StatusOK, res)
+}
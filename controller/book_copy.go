@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/request"
+	"digital-book-lending/utils/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BookCopyCtrl struct {
+	copyService *services.BookCopyService
+}
+
+func NewBookCopyController(copyService *services.BookCopyService) *BookCopyCtrl {
+	return &BookCopyCtrl{copyService: copyService}
+}
+
+// List godoc
+// @Summary List the copies of a book
+// @Description List every copy of a book with its barcode, condition, location and status
+// @Tags copies
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Book ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /books/{id}/copies [get]
+func (c *BookCopyCtrl) List(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][BookCopy][List]", logId)
+
+	bookId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+
+	copies, err := c.copyService.ListCopies(bookId)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; copyService.ListCopies; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, copies)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; List: %v", logPrefix, utils.JsonEncode(copies)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Create godoc
+// @Summary Add a copy to a book
+// @Description Add a copy to a book; it is set aside for the first member in the hold queue, if any
+// @Tags copies
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Book ID"
+// @Param copy body request.AddBookCopy true "Copy details"
+// @Success 201 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /books/{id}/copies [post]
+func (c *BookCopyCtrl) Create(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.AddBookCopy
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][BookCopy][Create]", logId)
+
+	bookId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	bookCopy, err := c.copyService.AddCopy(bookId, req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; copyService.AddCopy; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			res := response.Response(http.StatusConflict, utils.MsgExists, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusConflict, Message: fmt.Sprintf("copy with barcode: %s already exists", req.Barcode)}
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, "Add copy successfully", logId, bookCopy)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(bookCopy)))
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// Update godoc
+// @Summary Update a copy
+// @Description Update the condition, location or status of a copy; copies on loan or on hold keep their status
+// @Tags copies
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Copy ID"
+// @Param copy body request.UpdateBookCopy true "Copy details"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/copies/{id} [put]
+func (c *BookCopyCtrl) Update(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.UpdateBookCopy
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][BookCopy][Update]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	bookCopy, err := c.copyService.UpdateCopy(id, req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; copyService.UpdateCopy; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Copy with ID: '%s' updated successfully", id), logId, bookCopy)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(bookCopy)))
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"digital-book-lending/utils"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBookFilterSearchDefaults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query   string
+		mode    string
+		orderBy string
+	}{
+		{query: "", mode: utils.SearchLike, orderBy: "updated_at"},
+		{query: "search=978-0-306", mode: utils.SearchLike, orderBy: "updated_at"},
+		{query: "search=go&order_by=relevance", mode: utils.SearchLike, orderBy: "relevance"},
+		{query: "search=go&search_mode=natural", mode: utils.SearchNatural, orderBy: "relevance"},
+		{query: "search=%2Bgo&search_mode=boolean", mode: utils.SearchBoolean, orderBy: "relevance"},
+		{query: "search=go&search_mode=natural&order_by=title", mode: utils.SearchNatural, orderBy: "title"},
+		{query: "search=go&search_mode=natural&cursor=", mode: utils.SearchNatural, orderBy: "updated_at"},
+	}
+
+	for _, tt := range tests {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", "/api/v1/books?"+tt.query, nil)
+
+		filter, err := bookFilter(ctx)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		if filter.SearchMode != tt.mode || filter.OrderBy != tt.orderBy || filter.OrderDir != "desc" {
+			t.Errorf("%q: search_mode %s, order %s %s, want %s, %s desc", tt.query, filter.SearchMode, filter.OrderBy, filter.OrderDir, tt.mode, tt.orderBy)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package controller
+
+import (
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/request"
+	"digital-book-lending/utils/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CategoryCtrl struct {
+	categoryService *services.CategoryService
+}
+
+func NewCategoryController(categoryService *services.CategoryService) *CategoryCtrl {
+	return &CategoryCtrl{categoryService: categoryService}
+}
+
+// List godoc
+// @Summary List categories
+// @Description List the categories alphabetically with their number of books
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param search query string false "Only categories whose name contains this"
+// @Success 200 {object} response.Pagination
+// @Failure 500 {object} response.Error
+// @Router /categories [get]
+func (c *CategoryCtrl) List(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Category][List]", logId)
+
+	//query parameters
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	categories, totalData, err := c.categoryService.ListCategories(page, limit, ctx.Query("search"))
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; categoryService.ListCategories; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(totalData), page, limit, logId, categories)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; List: %v", logPrefix, utils.JsonEncode(categories)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Get godoc
+// @Summary Get a category
+// @Description Get a category with its number of books
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Category ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /categories/{id} [get]
+func (c *CategoryCtrl) Get(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Category][Get]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s]", id)
+
+	category, err := c.categoryService.GetCategory(id)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; categoryService.GetCategory; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusNotFound, Message: "category not found"}
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, category)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(category)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Create godoc
+// @Summary Create a category
+// @Description Add a category to put books in by ID
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param category body request.Category true "Category details"
+// @Success 201 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /categories [post]
+func (c *CategoryCtrl) Create(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.Category
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Category][Create]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	category, err := c.categoryService.CreateCategory(req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; categoryService.CreateCategory; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			res := response.Response(http.StatusConflict, utils.MsgExists, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusConflict, Message: fmt.Sprintf("a category named '%s' already exists", req.Name)}
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, "Add category successfully", logId, category)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(category)))
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// Update godoc
+// @Summary Rename a category
+// @Description Rename a category; the category names shown on its books and the lending policies for it change with it
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Category ID"
+// @Param category body request.Category true "Category details"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /categories/{id} [put]
+func (c *CategoryCtrl) Update(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.Category
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Category][Update]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	rows, err := c.categoryService.UpdateCategory(id, req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; categoryService.UpdateCategory; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			res := response.Response(http.StatusConflict, utils.MsgExists, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusConflict, Message: fmt.Sprintf("a category named '%s' already exists, merge them instead", req.Name)}
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	if rows == 0 {
+		res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusNotFound, Message: utils.NotFound}
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Category with ID: '%s' updated successfully", id), logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Category with ID: '%s' updated successfully; Data: %v", logPrefix, id, utils.JsonEncode(req)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Delete godoc
+// @Summary Delete a category
+// @Description Delete a category no book in the catalog is in
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Category ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /categories/{id} [delete]
+func (c *CategoryCtrl) Delete(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Category][Delete]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	rows, err := c.categoryService.DeleteCategory(id)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; categoryService.DeleteCategory; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+	if rows == 0 {
+		res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusNotFound, Message: utils.NotFound}
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Category with ID: '%s' deleted successfully", id), logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Category with ID: '%s' deleted successfully", logPrefix, id))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Merge godoc
+// @Summary Merge categories
+// @Description Merge duplicate categories into this one: their books and lending policies move to it and the duplicates are deleted
+// @Tags categories
+// @Accept  json
+// @Produce  json
+// @Param id path string true "ID of the category to keep"
+// @Param merge body request.Merge true "Categories to merge into it"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /categories/{id}/merge [post]
+func (c *CategoryCtrl) Merge(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.Merge
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Category][Merge]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	category, err := c.categoryService.MergeCategories(id, req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; categoryService.MergeCategories; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusNotFound, Message: "category not found"}
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Categories merged into category with ID: '%s' successfully", id), logId, category)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(category)))
+	ctx.JSON(http.StatusOK, res)
+}
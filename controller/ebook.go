@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/response"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type EbookCtrl struct {
+	ebookService *services.EbookService
+}
+
+func NewEbookController(ebookService *services.EbookService) *EbookCtrl {
+	return &EbookCtrl{ebookService: ebookService}
+}
+
+// AccessLink godoc
+// @Summary Get a link to read a borrowed e-book
+// @Description Sign an expiring download/stream link to the e-book of an active loan
+// @Tags ebooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Lending ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /lendings/{id}/ebook [get]
+func (c *EbookCtrl) AccessLink(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Ebook][AccessLink]", logId)
+
+	lendingId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+
+	link, err := c.ebookService.GetAccessLink(lendingId, userId)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err.Error()))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, link)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; Lending: %s; Expires: %s", logPrefix, lendingId, link.ExpiresAt))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Read godoc
+// @Summary Download or stream a borrowed e-book
+// @Description Serve the e-book file of an active loan through a signed link; range requests are supported for streaming
+// @Tags ebooks
+// @Produce  application/epub+zip
+// @Produce  application/pdf
+// @Param id path string true "Lending ID"
+// @Param expires query int true "Link expiry (unix time)"
+// @Param signature query string true "Link signature"
+// @Param download query bool false "Send the file as an attachment"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Router /ebooks/{id} [get]
+func (c *EbookCtrl) Read(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Ebook][Read]", logId)
+
+	lendingId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+
+	expires, err := strconv.ParseInt(ctx.Query("expires"), 10, 64)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Invalid expires: '%s'", logPrefix, ctx.Query("expires")))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusBadRequest, Message: "expires must be a unix time"}
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	book, obj, err := c.ebookService.OpenEbook(ctx.Request.Context(), lendingId, expires, ctx.Query("signature"))
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err.Error()))
+		// the book is only known once the link and the loan have been accepted,
+		// so an error past that point comes from the storage
+		if book.ID != "" {
+			res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+			res.Error = err.Error()
+			ctx.JSON(http.StatusInternalServerError, res)
+			return
+		}
+
+		res := response.Response(http.StatusForbidden, utils.MsgDenied, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusForbidden, Message: err.Error()}
+		ctx.JSON(http.StatusForbidden, res)
+		return
+	}
+	defer obj.Close()
+
+	disposition := "inline"
+	if download, _ := strconv.ParseBool(ctx.Query("download")); download {
+		disposition = "attachment"
+	}
+	ctx.Header("Cache-Control", "private, no-store")
+
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Serving e-book of book: %s", logPrefix, book.ID))
+	serveAsset(ctx, disposition, fmt.Sprintf("%s.%s", book.Title, *book.EbookFormat), book, book.Ebook, obj)
+}
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/request"
+	"digital-book-lending/utils/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FineCtrl struct {
+	fineService *services.FineService
+}
+
+func NewFineController(fineService *services.FineService) *FineCtrl {
+	return &FineCtrl{fineService: fineService}
+}
+
+// MyFines godoc
+// @Summary Get my fines
+// @Description Get the outstanding fine balance and fines ledger of the logged-in user
+// @Tags fines
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.Success
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /me/fines [get]
+func (c *FineCtrl) MyFines(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Fine][MyFines][%s]", logId, userId)
+
+	c.userFines(ctx, logId, logPrefix, userId)
+}
+
+// UserFines godoc
+// @Summary Get a member's fines
+// @Description Get the outstanding fine balance and fines ledger of a member
+// @Tags fines
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/users/{id}/fines [get]
+func (c *FineCtrl) UserFines(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Fine][UserFines]", logId)
+
+	userId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s]", userId)
+
+	c.userFines(ctx, logId, logPrefix, userId)
+}
+
+func (c *FineCtrl) userFines(ctx *gin.Context, logId uuid.UUID, logPrefix, userId string) {
+	summary, err := c.fineService.GetUserFines(userId)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; fineService.GetUserFines; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusNotFound, Message: "user not found"}
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, summary)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(summary)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// RecordPayment godoc
+// @Summary Record a fine payment
+// @Description Record a payment against a member's outstanding fines
+// @Tags fines
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param payment body request.FinePayment true "Payment details"
+// @Success 201 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/users/{id}/fines/payments [post]
+func (c *FineCtrl) RecordPayment(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.FinePayment
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Fine][RecordPayment]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", userId, username)
+
+	fine, err := c.fineService.RecordPayment(userId, req, username)
+	c.settled(ctx, logId, logPrefix, fine, err, "Payment recorded successfully")
+}
+
+// WaiveFine godoc
+// @Summary Waive fines
+// @Description Waive part or all of a member's outstanding fines with a reason
+// @Tags fines
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param waiver body request.FineWaiver true "Waiver details"
+// @Success 201 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/users/{id}/fines/waivers [post]
+func (c *FineCtrl) WaiveFine(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.FineWaiver
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Fine][WaiveFine]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", userId, username)
+
+	fine, err := c.fineService.WaiveFine(userId, req, username)
+	c.settled(ctx, logId, logPrefix, fine, err, "Fine waived successfully")
+}
+
+func (c *FineCtrl) settled(ctx *gin.Context, logId uuid.UUID, logPrefix string, fine interface{}, err error, msg string) {
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusNotFound, Message: "user not found"}
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, msg, logId, fine)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(fine)))
+	ctx.JSON(http.StatusCreated, res)
+}
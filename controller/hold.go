@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/response"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type HoldCtrl struct {
+	holdService *services.HoldService
+}
+
+func NewHoldController(holdService *services.HoldService) *HoldCtrl {
+	return &HoldCtrl{holdService: holdService}
+}
+
+// PlaceHold godoc
+// @Summary Place a hold on a book
+// @Description Join the FIFO hold queue of an out-of-stock book
+// @Tags holds
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Book ID"
+// @Success 201 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /books/{id}/hold [post]
+func (c *HoldCtrl) PlaceHold(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Hold][PlaceHold]", logId)
+
+	bookId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+
+	hold, err := c.holdService.PlaceHold(bookId, userId)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err.Error()))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, "Hold placed successfully", logId, hold)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(hold)))
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// List godoc
+// @Summary List my holds
+// @Description List the holds of the logged-in user, with the queue position of waiting holds
+// @Tags holds
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.Success
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /holds [get]
+func (c *HoldCtrl) List(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Hold][List][%s]", logId, userId)
+
+	holds, err := c.holdService.ListHolds(userId)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; holdService.ListHolds; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, holds)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; List: %v", logPrefix, utils.JsonEncode(holds)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Cancel godoc
+// @Summary Cancel a hold
+// @Description Cancel a waiting or ready hold; a copy set aside for it goes to the next member in the queue
+// @Tags holds
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Hold ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /holds/{id} [delete]
+func (c *HoldCtrl) Cancel(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Hold][Cancel]", logId)
+
+	holdId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+
+	if err := c.holdService.CancelHold(holdId, userId); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err.Error()))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Hold cancelled successfully", logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Hold with ID: '%s' cancelled", logPrefix, holdId))
+	ctx.JSON(http.StatusOK, res)
+}
@@ -4,9 +4,13 @@ import (
 	"digital-book-lending/services"
 	"digital-book-lending/utils"
 	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/request"
 	"digital-book-lending/utils/response"
 	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -103,3 +107,339 @@ func (c *LendingCtrl) ReturnBook(ctx *gin.Context) {
 	res := response.Response(http.StatusOK, "Book returned successfully", logId, nil)
 	ctx.JSON(http.StatusOK, res)
 }
+
+// ListOverdue godoc
+// @Summary List overdue lendings
+// @Description List every lending record past its due date, oldest due date first
+// @Tags lendings
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.Success
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/lendings/overdue [get]
+func (c *LendingCtrl) ListOverdue(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingBook][ListOverdue]", logId)
+
+	records, err := c.lendingService.ListOverdue()
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; lendingService.ListOverdue; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, records)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; Total: %d", logPrefix, len(records)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// RenewBook godoc
+// @Summary Renew a lending
+// @Description Push the due date of an active lending out by another loan period
+// @Tags lendings
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Lending ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /lendings/{id}/renew [post]
+func (c *LendingCtrl) RenewBook(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingBook][RenewBook]", logId)
+
+	lendingId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+
+	record, err := c.lendingService.RenewBook(lendingId, userId)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err.Error()))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Book renewed successfully", logId, record)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(record)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// MyLendings godoc
+// @Summary List my lendings
+// @Description List the borrowing history and active loans of the logged-in user, with book details
+// @Tags lendings
+// @Accept  json
+// @Produce  json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param status query string false "Lending status (active/borrowed/overdue/returned)"
+// @Param from_date query string false "Borrowed on or after this date (YYYY-MM-DD)"
+// @Param to_date query string false "Borrowed on or before this date (YYYY-MM-DD)"
+// @Param overdue query bool false "Only overdue (true) or not overdue (false) lendings"
+// @Param order_by query string false "Order by field"
+// @Param order_direction query string false "Order direction (asc/desc)"
+// @Success 200 {object} response.Pagination
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /me/lendings [get]
+func (c *LendingCtrl) MyLendings(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingBook][MyLendings][%s]", logId, userId)
+
+	page, limit := pageQuery(ctx)
+	filter, err := lendingFilterQuery(ctx)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Invalid query; Error: %s", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	filter.UserId = userId
+
+	records, totalData, err := c.lendingService.ListLendings(page, limit, filter)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; lendingService.ListLendings; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(totalData), page, limit, logId, records)
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Success; Total: %d", logPrefix, totalData))
+	ctx.JSON(http.StatusOK, res)
+}
+
+func pageQuery(ctx *gin.Context) (page, limit int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err = strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	return page, limit
+}
+
+func lendingFilterQuery(ctx *gin.Context) (request.LendingFilter, error) {
+	var filter request.LendingFilter
+
+	filter.Status = ctx.Query("status")
+	switch filter.Status {
+	case "", utils.LendingActive, utils.Borrowed, utils.Overdue, utils.Returned:
+	default:
+		return filter, fmt.Errorf("invalid status: %s", filter.Status)
+	}
+
+	if value := ctx.Query("overdue"); value != "" {
+		overdue, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid overdue: %s, expected true or false", value)
+		}
+		filter.Overdue = &overdue
+	}
+
+	filter.OrderBy = ctx.DefaultQuery("order_by", "borrow_date")
+	filter.OrderDir = ctx.DefaultQuery("order_direction", "desc")
+
+	for param, target := range map[string]**time.Time{"from_date": &filter.FromDate, "to_date": &filter.ToDate} {
+		value := ctx.Query(param)
+		if value == "" {
+			continue
+		}
+		date, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s: %s, expected format YYYY-MM-DD", param, value)
+		}
+		*target = &date
+	}
+
+	return filter, nil
+}
+
+// List godoc
+// @Summary List all lendings
+// @Description List and filter the lending records of every member, with book details
+// @Tags lendings
+// @Accept  json
+// @Produce  json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param user_id query string false "User ID"
+// @Param book_id query string false "Book ID"
+// @Param status query string false "Lending status (active/borrowed/overdue/returned)"
+// @Param from_date query string false "Borrowed on or after this date (YYYY-MM-DD)"
+// @Param to_date query string false "Borrowed on or before this date (YYYY-MM-DD)"
+// @Param overdue query bool false "Only overdue (true) or not overdue (false) lendings"
+// @Param order_by query string false "Order by field"
+// @Param order_direction query string false "Order direction (asc/desc)"
+// @Success 200 {object} response.Pagination
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/lendings [get]
+func (c *LendingCtrl) List(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingBook][List]", logId)
+
+	page, limit := pageQuery(ctx)
+	filter, err := lendingFilterQuery(ctx)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Invalid query; Error: %s", logPrefix, err.Error()))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	filter.UserId = ctx.Query("user_id")
+	filter.BookId = ctx.Query("book_id")
+
+	records, totalData, err := c.lendingService.ListLendings(page, limit, filter)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; lendingService.ListLendings; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(totalData), page, limit, logId, records)
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Success; Total: %d", logPrefix, totalData))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Checkout godoc
+// @Summary Check out a book for a member
+// @Description Borrow a book on behalf of a member at the front desk
+// @Tags lendings
+// @Accept  json
+// @Produce  json
+// @Param checkout body request.Checkout true "Book and member"
+// @Success 201 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/lendings/checkout [post]
+func (c *LendingCtrl) Checkout(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.Checkout
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingBook][Checkout][%s]", logId, username)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	newLendingRecord, err := c.lendingService.Checkout(req.BookId, req.UserId)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err.Error()))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, "Book checked out successfully", logId, newLendingRecord)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(newLendingRecord)))
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// Checkin godoc
+// @Summary Check in a book for a member
+// @Description Return a book on behalf of the member who borrowed it
+// @Tags lendings
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Lending ID"
+// @Param checkin body request.Checkin true "Member"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/lendings/{id}/checkin [post]
+func (c *LendingCtrl) Checkin(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.Checkin
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingBook][Checkin][%s]", logId, username)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	lendingId, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+
+	if err := c.lendingService.Checkin(lendingId, req.UserId); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err.Error()))
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Book checked in successfully", logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Lending with ID: '%s' checked in", logPrefix, lendingId))
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/request"
+	"digital-book-lending/utils/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LendingPolicyCtrl struct {
+	policyService *services.LendingPolicyService
+}
+
+func NewLendingPolicyController(policyService *services.LendingPolicyService) *LendingPolicyCtrl {
+	return &LendingPolicyCtrl{policyService: policyService}
+}
+
+// List godoc
+// @Summary List lending policies
+// @Description List the lending rules applied per role and per category
+// @Tags policies
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.Success
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/policies [get]
+func (c *LendingPolicyCtrl) List(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingPolicy][List]", logId)
+
+	policies, err := c.policyService.ListPolicies()
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; policyService.ListPolicies; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, policies)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; List: %v", logPrefix, utils.JsonEncode(policies)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Create godoc
+// @Summary Create a lending policy
+// @Description Create a lending rule for a role and/or category; leave role or category empty to apply it to all
+// @Tags policies
+// @Accept  json
+// @Produce  json
+// @Param policy body request.LendingPolicy true "Policy details"
+// @Success 201 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/policies [post]
+func (c *LendingPolicyCtrl) Create(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.LendingPolicy
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingPolicy][Create]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	policy, err := c.policyService.CreatePolicy(req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; policyService.CreatePolicy; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			res := response.Response(http.StatusConflict, utils.MsgExists, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusConflict, Message: fmt.Sprintf("a policy for role: '%s' and category: '%s' already exists", req.Role, req.Category)}
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, "Add lending policy successfully", logId, policy)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(policy)))
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// Update godoc
+// @Summary Update a lending policy
+// @Description Replace a lending rule; changes apply to the next borrow or renewal
+// @Tags policies
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Policy ID"
+// @Param policy body request.LendingPolicy true "Policy details"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/policies/{id} [put]
+func (c *LendingPolicyCtrl) Update(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.LendingPolicy
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingPolicy][Update]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	rows, err := c.policyService.UpdatePolicy(id, req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; policyService.UpdatePolicy; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			res := response.Response(http.StatusConflict, utils.MsgExists, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusConflict, Message: fmt.Sprintf("a policy for role: '%s' and category: '%s' already exists", req.Role, req.Category)}
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	if rows == 0 {
+		res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusNotFound, Message: utils.NotFound}
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Lending policy with ID: '%s' updated successfully", id), logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Lending policy with ID: '%s' updated successfully; Data: %v", logPrefix, id, utils.JsonEncode(req)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Delete godoc
+// @Summary Delete a lending policy
+// @Description Delete a lending rule
+// @Tags policies
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Policy ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/policies/{id} [delete]
+func (c *LendingPolicyCtrl) Delete(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][LendingPolicy][Delete]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	rows, err := c.policyService.DeletePolicy(id)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; policyService.DeletePolicy; Error: %+v", logPrefix, err))
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	if rows == 0 {
+		res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusNotFound, Message: utils.NotFound}
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Lending policy with ID: '%s' deleted successfully", id), logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Lending policy with ID: '%s' deleted successfully", logPrefix, id))
+	ctx.JSON(http.StatusOK, res)
+}
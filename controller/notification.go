@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/request"
+	"digital-book-lending/utils/response"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type NotificationCtrl struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationController(notificationService *services.NotificationService) *NotificationCtrl {
+	return &NotificationCtrl{notificationService: notificationService}
+}
+
+// MyPreferences godoc
+// @Summary Get my notification preferences
+// @Description Get the language and the e-mail notifications the logged-in user gets; every notification is on until changed
+// @Tags notifications
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.Success
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /me/notification-preferences [get]
+func (c *NotificationCtrl) MyPreferences(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Notification][MyPreferences][%s]", logId, userId)
+
+	preference, err := c.notificationService.GetPreferences(userId)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; notificationService.GetPreferences; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, preference)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(preference)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// UpdateMyPreferences godoc
+// @Summary Update my notification preferences
+// @Description Choose the language (en or id) and which reminders the logged-in user gets by e-mail
+// @Tags notifications
+// @Accept  json
+// @Produce  json
+// @Param preferences body request.NotificationPreference true "Notification preferences"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /me/notification-preferences [put]
+func (c *NotificationCtrl) UpdateMyPreferences(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.NotificationPreference
+	)
+
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Notification][UpdateMyPreferences][%s]", logId, userId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	preference, err := c.notificationService.UpdatePreferences(userId, req)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; notificationService.UpdatePreferences; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, "Notification preferences updated successfully", logId, preference)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(preference)))
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,402 @@
+package controller
+
+import (
+	"digital-book-lending/oai"
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"digital-book-lending/utils/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OaiCtrl lets union catalogs harvest the books over OAI-PMH 2.0.
+type OaiCtrl struct {
+	bookService *services.BookService
+}
+
+func NewOaiController(bookService *services.BookService) *OaiCtrl {
+	return &OaiCtrl{bookService: bookService}
+}
+
+// oaiError is an OAI-PMH error, answered inside the response rather than with
+// an HTTP status.
+type oaiError oai.Error
+
+func (e oaiError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Handle godoc
+// @Summary OAI-PMH harvesting
+// @Description OAI-PMH 2.0 repository of the books in Dublin Core (oai_dc). Supports the Identify, ListMetadataFormats, ListSets, ListIdentifiers, ListRecords and GetRecord verbs, selective harvesting by datestamp with from and until, and resumption tokens. Deleted books are listed as deleted records. Arguments may also be posted as a form.
+// @Tags oai
+// @Accept  x-www-form-urlencoded
+// @Produce  xml
+// @Param verb query string true "OAI-PMH verb" Enums(Identify, ListMetadataFormats, ListSets, ListIdentifiers, ListRecords, GetRecord)
+// @Param identifier query string false "Record identifier, oai:{repository}:{book-id}"
+// @Param metadataPrefix query string false "Metadata format" Enums(oai_dc)
+// @Param from query string false "Records changed on or after this datestamp (YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ)"
+// @Param until query string false "Records changed on or before this datestamp (YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ)"
+// @Param resumptionToken query string false "Token of the next part of an incomplete list"
+// @Success 200 {string} string "OAI-PMH response"
+// @Router /oai [get]
+// @Router /oai [post]
+func (c *OaiCtrl) Handle(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Oai][Handle]", logId)
+
+	res := oai.NewResponse(oaiBaseURL(ctx))
+	args, err := oaiArgs(ctx)
+	if err == nil {
+		verb := args.Get("verb")
+		logPrefix += fmt.Sprintf("[%s]", verb)
+
+		switch verb {
+		case oai.VerbIdentify:
+			err = c.identify(&res, args)
+		case oai.VerbListMetadataFormats:
+			err = c.listMetadataFormats(&res, args)
+		case oai.VerbListSets:
+			err = c.listSets(args)
+		case oai.VerbListIdentifiers, oai.VerbListRecords:
+			err = c.list(&res, verb, args)
+		case oai.VerbGetRecord:
+			err = c.getRecord(&res, args)
+		default:
+			err = oaiError{Code: oai.ErrBadVerb, Message: "Unknown or missing verb"}
+		}
+	}
+
+	var oaiErr oaiError
+	switch {
+	case errors.As(err, &oaiErr):
+		// the request is only echoed when it was understood
+		if oaiErr.Code != oai.ErrBadVerb && oaiErr.Code != oai.ErrBadArgument {
+			res.Request = oaiRequest(res.Request.BaseURL, args)
+		}
+		res.Errors = append(res.Errors, oai.Error(oaiErr))
+		utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; OAI error: %s", logPrefix, oaiErr.Error()))
+	case err != nil:
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	default:
+		res.Request = oaiRequest(res.Request.BaseURL, args)
+		utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success", logPrefix))
+	}
+
+	body, err := res.Marshal()
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; res.Marshal; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/xml; charset=utf-8", body)
+}
+
+func (c *OaiCtrl) identify(res *oai.Response, args url.Values) error {
+	if err := oaiCheckArgs(args, nil, nil); err != nil {
+		return err
+	}
+
+	earliest, err := c.bookService.EarliestDatestamp()
+	if err != nil {
+		return err
+	}
+	if earliest.IsZero() {
+		earliest = time.Now()
+	}
+
+	res.Identify = oai.NewIdentify(
+		utils.GetEnv("OAI_REPOSITORY_NAME", "Digital Book Lending").(string),
+		res.Request.BaseURL,
+		utils.GetEnv("OAI_ADMIN_EMAIL", "admin@example.com").(string),
+		oaiRepositoryId(),
+		earliest,
+	)
+	return nil
+}
+
+// listMetadataFormats lists the formats of the repository, or of one record,
+// which are the same for every record.
+func (c *OaiCtrl) listMetadataFormats(res *oai.Response, args url.Values) error {
+	if err := oaiCheckArgs(args, nil, []string{"identifier"}); err != nil {
+		return err
+	}
+
+	if identifier := args.Get("identifier"); identifier != "" {
+		if _, err := c.findRecord(identifier); err != nil {
+			return err
+		}
+	}
+
+	res.ListMetadataFormats = &oai.ListMetadataFormats{Formats: oai.Formats}
+	return nil
+}
+
+func (c *OaiCtrl) listSets(args url.Values) error {
+	if err := oaiCheckArgs(args, nil, []string{"resumptionToken"}); err != nil {
+		return err
+	}
+
+	return oaiError{Code: oai.ErrNoSetHierarchy, Message: "The repository does not support sets"}
+}
+
+func (c *OaiCtrl) getRecord(res *oai.Response, args url.Values) error {
+	if err := oaiCheckArgs(args, []string{"identifier", "metadataPrefix"}, nil); err != nil {
+		return err
+	}
+	if err := oaiCheckFormat(args.Get("metadataPrefix")); err != nil {
+		return err
+	}
+
+	record, err := c.findRecord(args.Get("identifier"))
+	if err != nil {
+		return err
+	}
+
+	res.GetRecord = &oai.GetRecord{Record: record}
+	return nil
+}
+
+// list answers ListIdentifiers and ListRecords, which page through the same
+// records, with or without their metadata.
+func (c *OaiCtrl) list(res *oai.Response, verb string, args url.Values) error {
+	harvest, err := oaiHarvest(args)
+	if err != nil {
+		return err
+	}
+
+	books, totalData, next, err := c.bookService.HarvestBooks(harvest, utils.GetEnv("OAI_PAGE_SIZE", 100).(int))
+	if err != nil {
+		return err
+	}
+	if len(books) == 0 {
+		if harvest.Cursor > 0 {
+			return oaiError{Code: oai.ErrBadResumptionToken, Message: "The resumption token is no longer valid"}
+		}
+		return oaiError{Code: oai.ErrNoRecordsMatch, Message: "No records match the request"}
+	}
+
+	// the last part of a resumed list still has a token, an empty one
+	var token *oai.ResumptionToken
+	switch {
+	case next != nil:
+		token = &oai.ResumptionToken{CompleteListSize: totalData, Cursor: harvest.Cursor, Value: next.Token()}
+	case harvest.Cursor > 0:
+		token = &oai.ResumptionToken{CompleteListSize: totalData, Cursor: harvest.Cursor}
+	}
+
+	repository := oaiRepositoryId()
+	if verb == oai.VerbListIdentifiers {
+		res.ListIdentifiers = &oai.ListIdentifiers{ResumptionToken: token}
+		for _, book := range books {
+			res.ListIdentifiers.Headers = append(res.ListIdentifiers.Headers, oai.HeaderOf(repository, book))
+		}
+		return nil
+	}
+
+	res.ListRecords = &oai.ListRecords{ResumptionToken: token}
+	for _, book := range books {
+		res.ListRecords.Records = append(res.ListRecords.Records, oai.FromBook(repository, book))
+	}
+	return nil
+}
+
+// findRecord looks up the book an identifier of this repository names.
+func (c *OaiCtrl) findRecord(identifier string) (oai.Record, error) {
+	notFound := oaiError{Code: oai.ErrIdDoesNotExist, Message: fmt.Sprintf("No record has the identifier %s", identifier)}
+
+	repository := oaiRepositoryId()
+	id, ok := strings.CutPrefix(identifier, oai.Identifier(repository, ""))
+	if !ok {
+		return oai.Record{}, notFound
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return oai.Record{}, notFound
+	}
+
+	book, err := c.bookService.GetBookRecord(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return oai.Record{}, notFound
+	}
+	if err != nil {
+		return oai.Record{}, err
+	}
+
+	return oai.FromBook(repository, book), nil
+}
+
+// oaiArgs reads the arguments from the query string or a posted form. An
+// argument may not be repeated.
+func oaiArgs(ctx *gin.Context) (url.Values, error) {
+	if err := ctx.Request.ParseForm(); err != nil {
+		return nil, oaiError{Code: oai.ErrBadArgument, Message: "The arguments cannot be read"}
+	}
+
+	for key, values := range ctx.Request.Form {
+		if len(values) > 1 {
+			return nil, oaiError{Code: oai.ErrBadArgument, Message: fmt.Sprintf("The argument %s is repeated", key)}
+		}
+	}
+
+	return ctx.Request.Form, nil
+}
+
+// oaiCheckArgs fails unless the request has all the required arguments and
+// nothing but them and the optional ones.
+func oaiCheckArgs(args url.Values, required, optional []string) error {
+	allowed := map[string]bool{"verb": true}
+	for _, key := range append(required, optional...) {
+		allowed[key] = true
+	}
+
+	for key := range args {
+		if !allowed[key] {
+			return oaiError{Code: oai.ErrBadArgument, Message: fmt.Sprintf("The argument %s is not allowed", key)}
+		}
+	}
+	for _, key := range required {
+		if args.Get(key) == "" {
+			return oaiError{Code: oai.ErrBadArgument, Message: fmt.Sprintf("The argument %s is required", key)}
+		}
+	}
+
+	return nil
+}
+
+func oaiCheckFormat(prefix string) error {
+	for _, format := range oai.Formats {
+		if format.MetadataPrefix == prefix {
+			return nil
+		}
+	}
+
+	return oaiError{Code: oai.ErrCannotDisseminateFormat, Message: fmt.Sprintf("The metadata format %s is not supported", prefix)}
+}
+
+// oaiHarvest reads the harvest of a list request, from its resumption token,
+// which must be its only argument, or from its metadata prefix and dates.
+func oaiHarvest(args url.Values) (request.Harvest, error) {
+	if token := args.Get("resumptionToken"); token != "" {
+		if err := oaiCheckArgs(args, []string{"resumptionToken"}, nil); err != nil {
+			return request.Harvest{}, err
+		}
+
+		harvest, err := request.DecodeHarvest(token)
+		if err != nil {
+			return request.Harvest{}, oaiError{Code: oai.ErrBadResumptionToken, Message: "The resumption token is invalid"}
+		}
+		return harvest, nil
+	}
+
+	if err := oaiCheckArgs(args, []string{"metadataPrefix"}, []string{"from", "until", "set"}); err != nil {
+		return request.Harvest{}, err
+	}
+
+	harvest := request.Harvest{MetadataPrefix: args.Get("metadataPrefix")}
+	from, fromDay, err := oaiDatestamp(args.Get("from"), false)
+	if err != nil {
+		return harvest, err
+	}
+	until, untilDay, err := oaiDatestamp(args.Get("until"), true)
+	if err != nil {
+		return harvest, err
+	}
+	if from != nil && until != nil {
+		if fromDay != untilDay {
+			return harvest, oaiError{Code: oai.ErrBadArgument, Message: "from and until must have the same granularity"}
+		}
+		if until.Before(*from) {
+			return harvest, oaiError{Code: oai.ErrBadArgument, Message: "until must not be before from"}
+		}
+	}
+	harvest.From, harvest.Until = from, until
+
+	if err := oaiCheckFormat(harvest.MetadataPrefix); err != nil {
+		return harvest, err
+	}
+	if args.Get("set") != "" {
+		return harvest, oaiError{Code: oai.ErrNoSetHierarchy, Message: "The repository does not support sets"}
+	}
+
+	return harvest, nil
+}
+
+// oaiDatestamp parses a from or until argument in either granularity. An
+// until day runs to its last second.
+func oaiDatestamp(value string, until bool) (*time.Time, bool, error) {
+	if value == "" {
+		return nil, false, nil
+	}
+
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		if until {
+			day = day.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		return &day, true, nil
+	}
+	if instant, err := time.Parse("2006-01-02T15:04:05Z", value); err == nil {
+		return &instant, false, nil
+	}
+
+	return nil, false, oaiError{Code: oai.ErrBadArgument, Message: fmt.Sprintf("%s is not a YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ datestamp", value)}
+}
+
+func oaiRequest(baseURL string, args url.Values) oai.Request {
+	return oai.Request{
+		Verb:            args.Get("verb"),
+		Identifier:      args.Get("identifier"),
+		MetadataPrefix:  args.Get("metadataPrefix"),
+		From:            args.Get("from"),
+		Until:           args.Get("until"),
+		Set:             args.Get("set"),
+		ResumptionToken: args.Get("resumptionToken"),
+		BaseURL:         baseURL,
+	}
+}
+
+// oaiBaseURL is the address harvesters reach the endpoint at, under APP_URL
+// when it is set.
+func oaiBaseURL(ctx *gin.Context) string {
+	if appURL := utils.GetEnv("APP_URL", "").(string); appURL != "" {
+		return strings.TrimSuffix(appURL, "/") + apiPath + "/oai"
+	}
+
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + ctx.Request.Host + apiPath + "/oai"
+}
+
+// oaiRepositoryId names the repository in record identifiers: the host of
+// APP_URL unless OAI_REPOSITORY_ID is set.
+func oaiRepositoryId() string {
+	if id := utils.GetEnv("OAI_REPOSITORY_ID", "").(string); id != "" {
+		return id
+	}
+	if appURL, err := url.Parse(utils.GetEnv("APP_URL", "").(string)); err == nil && appURL.Hostname() != "" {
+		return appURL.Hostname()
+	}
+	return "localhost"
+}
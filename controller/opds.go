@@ -0,0 +1,418 @@
+package controller
+
+import (
+	"digital-book-lending/models"
+	"digital-book-lending/opds"
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/request"
+	"digital-book-lending/utils/response"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// the OPDS links point at the rest of the API
+const apiPath = "/api/v1"
+
+// OpdsCtrl serves the catalog to e-reader apps, as OPDS 1.2 or 2.0 depending
+// on its version. The feeds are public; a signed-in user also gets borrow and
+// return links.
+type OpdsCtrl struct {
+	bookService    *services.BookService
+	lendingService *services.LendingService
+	version        string
+	base           string
+}
+
+func NewOpdsController(bookService *services.BookService, lendingService *services.LendingService, version string) *OpdsCtrl {
+	base := apiPath + "/opds"
+	if version == opds.Version2 {
+		base += "/v2"
+	}
+
+	return &OpdsCtrl{
+		bookService:    bookService,
+		lendingService: lendingService,
+		version:        version,
+		base:           base,
+	}
+}
+
+// Root godoc
+// @Summary OPDS catalog root
+// @Description Navigation feed of the catalog for e-reader apps, leading to all books, books by category and books by author. /opds is OPDS 1.2 (Atom), /opds/v2 is OPDS 2.0 (JSON).
+// @Tags opds
+// @Produce  application/atom+xml
+// @Produce  application/opds+json
+// @Success 200 {string} string "OPDS feed"
+// @Router /opds [get]
+// @Router /opds/v2 [get]
+func (c *OpdsCtrl) Root(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Opds][Root][%s]", logId, c.version)
+
+	feed := opds.Feed{
+		ID:      c.base,
+		Title:   utils.GetEnv("OPDS_TITLE", "Digital Book Lending").(string),
+		Kind:    opds.KindNavigation,
+		Updated: time.Now(),
+		Links:   c.feedLinks(c.base, opds.KindNavigation),
+		Navigation: []opds.Navigation{
+			{Title: "All books", Href: c.base + "/books", Kind: opds.KindAcquisition},
+			{Title: "By category", Href: c.base + "/categories", Kind: opds.KindNavigation},
+			{Title: "By author", Href: c.base + "/authors", Kind: opds.KindNavigation},
+		},
+	}
+
+	c.write(ctx, logId, logPrefix, feed)
+}
+
+// Categories godoc
+// @Summary OPDS categories
+// @Description Navigation feed of every category with its number of books, each leading to its books
+// @Tags opds
+// @Produce  application/atom+xml
+// @Produce  application/opds+json
+// @Param page query int false "Page number"
+// @Success 200 {string} string "OPDS feed"
+// @Router /opds/categories [get]
+// @Router /opds/v2/categories [get]
+func (c *OpdsCtrl) Categories(ctx *gin.Context) {
+	c.facetFeed(ctx, "category", "Categories", "/categories")
+}
+
+// Authors godoc
+// @Summary OPDS authors
+// @Description Navigation feed of every author with their number of books, each leading to their books
+// @Tags opds
+// @Produce  application/atom+xml
+// @Produce  application/opds+json
+// @Param page query int false "Page number"
+// @Success 200 {string} string "OPDS feed"
+// @Router /opds/authors [get]
+// @Router /opds/v2/authors [get]
+func (c *OpdsCtrl) Authors(ctx *gin.Context) {
+	c.facetFeed(ctx, "author", "Authors", "/authors")
+}
+
+// facetFeed lists the values of a facet, each linking to the books feed
+// filtered by it.
+func (c *OpdsCtrl) facetFeed(ctx *gin.Context, facet, title, segment string) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Opds][%s][%s]", logId, title, c.version)
+
+	page, perPage := opdsPage(ctx)
+	values, totalData, err := c.bookService.ListFacetValues(facet, page, perPage)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.ListFacetValues; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	path := c.base + segment
+	feed := opds.Feed{
+		ID:      path,
+		Title:   title,
+		Kind:    opds.KindNavigation,
+		Updated: time.Now(),
+		Links:   c.feedLinks(pageHref(path, ctx.Request.URL.Query(), page), opds.KindNavigation),
+		Total:   totalData,
+		Page:    page,
+		PerPage: perPage,
+	}
+	feed.Links = append(feed.Links, opds.PageLinks(page, perPage, totalData, opds.KindNavigation, func(page int) string {
+		return pageHref(path, ctx.Request.URL.Query(), page)
+	})...)
+
+	for _, value := range values {
+		feed.Navigation = append(feed.Navigation, opds.Navigation{
+			Title: value.Value,
+			Href:  c.base + "/books?" + url.Values{facet: {value.Value}}.Encode(),
+			Kind:  opds.KindAcquisition,
+			Count: value.Count,
+		})
+	}
+
+	c.write(ctx, logId, logPrefix, feed)
+}
+
+// Books godoc
+// @Summary OPDS books
+// @Description Acquisition feed of the books matching the book list filters, newest first, with cover links. A signed-in user also gets a borrow link, or return and read links for a book they have on loan.
+// @Tags opds
+// @Produce  application/atom+xml
+// @Produce  application/opds+json
+// @Param page query int false "Page number"
+// @Param search query string false "Search query"
+// @Param category query []string false "Only books in these categories" collectionFormat(multi)
+// @Param author query []string false "Only books by these authors" collectionFormat(multi)
+// @Param category_id query []string false "Only books in these categories, by ID" collectionFormat(multi)
+// @Param author_id query []string false "Only books by these authors, by ID" collectionFormat(multi)
+// @Param in_stock query bool false "Only books that can be borrowed now"
+// @Success 200 {string} string "OPDS feed"
+// @Failure 400 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /opds/books [get]
+// @Router /opds/v2/books [get]
+func (c *OpdsCtrl) Books(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	userId := utils.InterfaceString(authData["user_id"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Opds][Books][%s][%s]", logId, c.version, userId)
+
+	filter, err := bookFilter(ctx)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookFilter; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	page, perPage := opdsPage(ctx)
+	books, totalData, err := c.bookService.ListBooks(page, perPage, filter)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; bookService.ListBooks; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	loans, err := c.activeLoans(userId, books)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; lendingService.ListLendings; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	path := c.base + "/books"
+	self := pageHref(path, ctx.Request.URL.Query(), page)
+	feed := opds.Feed{
+		ID:      self,
+		Title:   booksTitle(filter),
+		Kind:    opds.KindAcquisition,
+		Updated: time.Now(),
+		Links:   c.feedLinks(self, opds.KindAcquisition),
+		Total:   totalData,
+		Page:    page,
+		PerPage: perPage,
+	}
+	feed.Links = append(feed.Links, opds.PageLinks(page, perPage, totalData, opds.KindAcquisition, func(page int) string {
+		return pageHref(path, ctx.Request.URL.Query(), page)
+	})...)
+
+	for _, book := range books {
+		publication := opds.FromBook(book)
+		publication.Links = bookLinks(book, loans, userId != "")
+		feed.Publications = append(feed.Publications, publication)
+	}
+
+	c.write(ctx, logId, logPrefix, feed)
+}
+
+// SearchDescription godoc
+// @Summary OPDS search description
+// @Description OpenSearch description telling OPDS 1.2 clients how to search the books feed
+// @Tags opds
+// @Produce  application/opensearchdescription+xml
+// @Success 200 {string} string "OpenSearch description"
+// @Router /opds/search.xml [get]
+func (c *OpdsCtrl) SearchDescription(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Opds][SearchDescription][%s]", logId, c.version)
+
+	title := utils.GetEnv("OPDS_TITLE", "Digital Book Lending").(string)
+	body, err := opds.OpenSearch(title, "Search the books by title, author or ISBN", c.base+"/books?search={searchTerms}")
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; opds.OpenSearch; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	ctx.Data(http.StatusOK, opds.TypeOpenSearch, body)
+}
+
+// write sends the feed in the format of the controller's OPDS version.
+func (c *OpdsCtrl) write(ctx *gin.Context, logId uuid.UUID, logPrefix string, feed opds.Feed) {
+	var (
+		body        []byte
+		contentType string
+		err         error
+	)
+	if c.version == opds.Version2 {
+		body, err = feed.JSON()
+		contentType = opds.TypeJSON
+	} else {
+		body, err = feed.Atom()
+		contentType = opds.AtomType(feed.Kind)
+	}
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; write; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; Entries: %d", logPrefix, len(feed.Navigation)+len(feed.Publications)))
+	ctx.Data(http.StatusOK, contentType, body)
+}
+
+// feedLinks are the links every feed has: itself, the root and the search.
+// OPDS 1.2 clients find the search through an OpenSearch description, OPDS
+// 2.0 clients through a URI template.
+func (c *OpdsCtrl) feedLinks(self, kind string) []opds.Link {
+	links := []opds.Link{
+		{Rel: "self", Href: self, Kind: kind},
+		{Rel: "start", Href: c.base, Kind: opds.KindNavigation},
+	}
+	if c.version == opds.Version2 {
+		return append(links, opds.Link{Rel: "search", Href: c.base + "/books{?search}", Kind: opds.KindAcquisition, Templated: true})
+	}
+	return append(links, opds.Link{Rel: "search", Href: c.base + "/search.xml", Type: opds.TypeOpenSearch})
+}
+
+// activeLoans are the loans the user has of the books, by book id.
+func (c *OpdsCtrl) activeLoans(userId string, books []models.Book) (map[string]models.LendingRecord, error) {
+	if userId == "" || len(books) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(books))
+	for _, book := range books {
+		ids = append(ids, book.ID)
+	}
+	records, _, err := c.lendingService.ListLendings(1, len(ids), request.LendingFilter{UserId: userId, Status: utils.LendingActive, BookIds: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	loans := make(map[string]models.LendingRecord, len(records))
+	for _, record := range records {
+		loans[record.BookId] = record
+	}
+	return loans, nil
+}
+
+// bookLinks link a book to its details and cover and, for a signed-in user,
+// to borrowing it or, while they have it, to reading and returning it.
+func bookLinks(book models.Book, loans map[string]models.LendingRecord, signedIn bool) []opds.Link {
+	bookPath := apiPath + "/books/" + book.ID
+	links := []opds.Link{{Rel: "alternate", Href: bookPath, Type: "application/json", Title: "Details"}}
+
+	if book.Cover.Key != nil {
+		var coverType string
+		if book.Cover.ContentType != nil {
+			coverType = *book.Cover.ContentType
+		}
+		links = append(links,
+			opds.Link{Rel: opds.RelImage, Href: bookPath + "/cover", Type: coverType},
+			opds.Link{Rel: opds.RelThumbnail, Href: bookPath + "/cover", Type: coverType},
+		)
+	}
+
+	if !signedIn {
+		return links
+	}
+
+	if loan, ok := loans[book.ID]; ok {
+		if loan.IsDigital {
+			links = append(links, opds.Link{
+				Rel:          opds.RelAcquisition,
+				Href:         apiPath + "/lendings/" + loan.Id + "/ebook",
+				Type:         "application/json",
+				Title:        "Read",
+				Availability: &opds.Availability{Status: opds.AvailabilityReady, Until: &loan.DueDate},
+			})
+		}
+		return append(links, opds.Link{Rel: opds.RelRevoke, Href: apiPath + "/books/" + loan.Id + "/return", Type: "application/json", Title: "Return"})
+	}
+
+	status := opds.AvailabilityUnavailable
+	if inStock(book) {
+		status = opds.AvailabilityAvailable
+	}
+	return append(links, opds.Link{
+		Rel:          opds.RelBorrow,
+		Href:         bookPath + "/borrow",
+		Type:         "application/json",
+		Title:        "Borrow",
+		Availability: &opds.Availability{Status: status},
+	})
+}
+
+// inStock follows the in_stock filter of the book list: a copy is on the
+// shelf or the digital license still allows a loan.
+func inStock(book models.Book) bool {
+	if book.Quantity > 0 {
+		return true
+	}
+
+	license := book.License
+	return license.Type != nil &&
+		(license.ExpiresAt == nil || license.ExpiresAt.After(time.Now())) &&
+		(license.MaxCheckouts == nil || license.Checkouts < *license.MaxCheckouts)
+}
+
+func booksTitle(filter request.BookFilter) string {
+	switch {
+	case filter.Search != "":
+		return fmt.Sprintf("Search results for %q", filter.Search)
+	case len(filter.Categories) == 1 && len(filter.Authors) == 0:
+		return filter.Categories[0]
+	case len(filter.Authors) == 1 && len(filter.Categories) == 0:
+		return filter.Authors[0]
+	default:
+		return "All books"
+	}
+}
+
+// opdsPage reads the page; the page size is fixed by OPDS_PAGE_SIZE, as
+// e-reader apps only follow the links they are given.
+func opdsPage(ctx *gin.Context) (page, perPage int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	return page, utils.GetEnv("OPDS_PAGE_SIZE", 50).(int)
+}
+
+func pageHref(path string, query url.Values, page int) string {
+	query.Set("page", strconv.Itoa(page))
+	return path + "?" + query.Encode()
+}
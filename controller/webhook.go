@@ -0,0 +1,367 @@
+package controller
+
+import (
+	"digital-book-lending/services"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/functions"
+	"digital-book-lending/utils/request"
+	"digital-book-lending/utils/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookCtrl struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController(webhookService *services.WebhookService) *WebhookCtrl {
+	return &WebhookCtrl{webhookService: webhookService}
+}
+
+// List godoc
+// @Summary List webhook subscriptions
+// @Description List the partner endpoints that get lending and catalog events
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.Success
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/webhooks [get]
+func (c *WebhookCtrl) List(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Webhook][List]", logId)
+
+	subscriptions, err := c.webhookService.ListSubscriptions()
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; webhookService.ListSubscriptions; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, utils.MsgSuccess, logId, subscriptions)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; List: %v", logPrefix, utils.JsonEncode(subscriptions)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Create godoc
+// @Summary Create a webhook subscription
+// @Description Subscribe an endpoint to events: book.created, book.updated, book.deleted, loan.borrowed, loan.returned. The signing secret is only returned here.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param subscription body request.WebhookSubscription true "Subscription details"
+// @Success 201 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/webhooks [post]
+func (c *WebhookCtrl) Create(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.WebhookSubscription
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Webhook][Create][%s]", logId, username)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	subscription, err := c.webhookService.CreateSubscription(req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; webhookService.CreateSubscription; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.Response(http.StatusCreated, "Add webhook subscription successfully", logId, subscription)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success; Id: %s; Url: %s;", logPrefix, subscription.Id, subscription.Url))
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// Update godoc
+// @Summary Update a webhook subscription
+// @Description Replace the endpoint and events of a subscription, or pause it with is_active: false
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Subscription ID"
+// @Param subscription body request.WebhookSubscription true "Subscription details"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/webhooks/{id} [put]
+func (c *WebhookCtrl) Update(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+		req       request.WebhookSubscription
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Webhook][Update]", logId)
+
+	if err := ctx.BindJSON(&req); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; BindJSON ERROR: %s;", logPrefix, err.Error()))
+
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = utils.ValidateError(err, reflect.TypeOf(req), "json")
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	rows, err := c.webhookService.UpdateSubscription(id, req, username)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; webhookService.UpdateSubscription; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	if rows == 0 {
+		res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusNotFound, Message: utils.NotFound}
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Webhook subscription with ID: '%s' updated successfully", id), logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Webhook subscription with ID: '%s' updated successfully; Data: %v", logPrefix, id, utils.JsonEncode(req)))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Delete godoc
+// @Summary Delete a webhook subscription
+// @Description Delete a subscription together with its delivery log
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/webhooks/{id} [delete]
+func (c *WebhookCtrl) Delete(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Webhook][Delete]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	rows, err := c.webhookService.DeleteSubscription(id)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; webhookService.DeleteSubscription; Error: %+v", logPrefix, err))
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	if rows == 0 {
+		res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusNotFound, Message: utils.NotFound}
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, fmt.Sprintf("Webhook subscription with ID: '%s' deleted successfully", id), logId, nil)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Webhook subscription with ID: '%s' deleted successfully", logPrefix, id))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Deliveries godoc
+// @Summary List webhook deliveries
+// @Description List the deliveries of a subscription, newest first
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Subscription ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param status query string false "pending, delivered or failed"
+// @Success 200 {object} response.Pagination
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (c *WebhookCtrl) Deliveries(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Webhook][Deliveries]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s]", id)
+
+	status := ctx.Query("status")
+	switch status {
+	case "", utils.WebhookPending, utils.WebhookDelivered, utils.WebhookFailed:
+	default:
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Invalid query; status: %s", logPrefix, status))
+		res := response.Response(http.StatusBadRequest, utils.InvalidRequest, logId, nil)
+		res.Error = fmt.Sprintf("invalid status: %s", status)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	page, limit := pageQuery(ctx)
+	deliveries, totalData, err := c.webhookService.ListDeliveries(id, page, limit, status)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; webhookService.ListDeliveries; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusNotFound, Message: "webhook subscription not found"}
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+
+		res := response.Response(http.StatusInternalServerError, utils.MsgFail, logId, nil)
+		res.Error = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := response.PaginationResponse(http.StatusOK, int(totalData), page, limit, logId, deliveries)
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Success; Total: %d", logPrefix, totalData))
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Delivery godoc
+// @Summary Get a webhook delivery
+// @Description Get a delivery with every attempt made: status code, response body and error
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/webhook-deliveries/{id} [get]
+func (c *WebhookCtrl) Delivery(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Webhook][Delivery]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s]", id)
+
+	delivery, err := c.webhookService.GetDelivery(id)
+	c.delivery(ctx, logId, logPrefix, delivery, err, utils.MsgSuccess)
+}
+
+// Replay godoc
+// @Summary Replay a failed webhook delivery
+// @Description Send a failed delivery again with the original body, starting a new round of retries
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Security ApiKeyAuth
+// @Router /admin/webhook-deliveries/{id}/replay [post]
+func (c *WebhookCtrl) Replay(ctx *gin.Context) {
+	var (
+		logId     uuid.UUID
+		logPrefix string
+	)
+	authData := functions.GetAuthData(ctx)
+	username := utils.InterfaceString(authData["username"])
+
+	logId = utils.GenerateLogId(ctx)
+	logPrefix = fmt.Sprintf("[%s][Webhook][Replay]", logId)
+
+	id, err := functions.ValidateUUID(ctx, logPrefix, logId)
+	if err != nil {
+		return
+	}
+	logPrefix += fmt.Sprintf("[%s][%s]", id, username)
+
+	delivery, err := c.webhookService.ReplayDelivery(id)
+	c.delivery(ctx, logId, logPrefix, delivery, err, "Webhook delivery queued for replay")
+}
+
+func (c *WebhookCtrl) delivery(ctx *gin.Context, logId uuid.UUID, logPrefix string, delivery interface{}, err error, msg string) {
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error: %+v", logPrefix, err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			res := response.Response(http.StatusNotFound, utils.MsgNotFound, logId, nil)
+			res.Errors = response.Errors{Code: http.StatusNotFound, Message: "webhook delivery not found"}
+			ctx.JSON(http.StatusNotFound, res)
+			return
+		}
+
+		res := response.Response(http.StatusUnprocessableEntity, utils.MsgFail, logId, nil)
+		res.Errors = response.Errors{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+
+	res := response.Response(http.StatusOK, msg, logId, delivery)
+	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Success: %+v;", logPrefix, utils.JsonEncode(delivery)))
+	ctx.JSON(http.StatusOK, res)
+}
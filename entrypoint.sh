@@ -1,3 +1,3 @@
 #!/bin/sh
 
-/app/${SERVICE_NAME}
\ No newline at end of file
+exec /app/${SERVICE_NAME} "$@"
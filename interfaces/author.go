@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"digital-book-lending/models"
+
+	"gorm.io/gorm"
+)
+
+type Author interface {
+	Store(tx *gorm.DB, m models.Author) error
+	Update(tx *gorm.DB, m models.Author, data interface{}) (int64, error)
+	Delete(tx *gorm.DB, m models.Author) (int64, error)
+	Fetch(page, limit int, search string) ([]models.Author, int64, error)
+	GetById(id string) (models.Author, error)
+	GetByIdForUpdate(tx *gorm.DB, id string) (models.Author, error)
+	GetByName(tx *gorm.DB, name string) (models.Author, error)
+	FetchByIds(tx *gorm.DB, ids []string) ([]models.Author, error)
+	FetchByBooks(tx *gorm.DB, bookIds []string) (map[string][]models.Author, error)
+	FetchBookIds(tx *gorm.DB, ids []string) ([]string, error)
+	CountBooks(tx *gorm.DB, id string) (int64, error)
+	LinkBook(tx *gorm.DB, bookId string, ids []string) error
+	Merge(tx *gorm.DB, id string, sourceIds []string) error
+}
@@ -1,8 +1,12 @@
 package interfaces
 
-import "digital-book-lending/models"
+import (
+	"digital-book-lending/models"
+	"time"
+)
 
 type Blacklist interface {
 	Store(m models.Blacklist) error
 	GetByToken(token string) (models.Blacklist, error)
+	DeleteCreatedBefore(before time.Time) (int64, error)
 }
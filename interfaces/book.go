@@ -2,16 +2,31 @@ package interfaces
 
 import (
 	"digital-book-lending/models"
+	"digital-book-lending/utils/request"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type Book interface {
-	Store(m models.Book) error
+	Store(tx *gorm.DB, m models.Book) error
 	Update(tx *gorm.DB, m models.Book, data interface{}) (int64, error)
 	Delete(m models.Book) (int64, error)
-	SoftDelete(m models.Book, data interface{}) (int64, error)
+	SoftDelete(tx *gorm.DB, m models.Book, data interface{}) (int64, error)
 	GetByIsbn(isbn string) (models.Book, error)
-	Fetch(page, limit int, orderBy, orderDir, search string) ([]models.Book, int64, error)
+	GetByIsbnForUpdate(tx *gorm.DB, isbn string) (models.Book, error)
+	Fetch(page, limit int, filter request.BookFilter) ([]models.Book, int64, error)
+	FetchAfter(limit int, filter request.BookFilter, after *request.BookCursor) ([]models.Book, *request.BookCursor, error)
+	FetchFacets(filter request.BookFilter, limit int) (models.BookFacets, error)
+	FetchFacetValues(facet string, page, limit int) ([]models.FacetCount, int64, error)
+	FetchChanged(limit int, harvest request.Harvest) ([]models.Book, error)
+	CountChanged(harvest request.Harvest) (int64, error)
+	GetById(id string) (models.Book, error)
+	GetByIdWithDeleted(id string) (models.Book, error)
+	EarliestCreatedAt() (*time.Time, error)
+	FetchByIds(ids []string) ([]models.Book, error)
 	GetByIdForUpdate(tx *gorm.DB, id string) (models.Book, error)
+	SyncQuantity(tx *gorm.DB, id string) error
+	SyncNames(tx *gorm.DB, ids []string) error
+	FetchExpiringLicenses(until time.Time, checkoutsLeft int) ([]models.Book, error)
 }
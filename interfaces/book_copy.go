@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"digital-book-lending/models"
+
+	"gorm.io/gorm"
+)
+
+type BookCopy interface {
+	Store(tx *gorm.DB, m models.BookCopy) (models.BookCopy, error)
+	Update(tx *gorm.DB, m models.BookCopy, data interface{}) error
+	GetById(id string) (models.BookCopy, error)
+	GetByIdForUpdate(tx *gorm.DB, id string) (models.BookCopy, error)
+	GetAvailableForUpdate(tx *gorm.DB, bookId string) (models.BookCopy, error)
+	FetchAvailableForUpdate(tx *gorm.DB, bookId string, limit int) ([]models.BookCopy, error)
+	FetchByBook(bookId string) ([]models.BookCopy, error)
+	CountByBook(tx *gorm.DB, bookId string) (int64, error)
+	CountAvailable(tx *gorm.DB, bookId string) (int64, error)
+	CountByStatus(bookId string) (map[string]int64, error)
+}
@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"digital-book-lending/models"
+
+	"gorm.io/gorm"
+)
+
+type Category interface {
+	Store(tx *gorm.DB, m models.Category) error
+	Update(tx *gorm.DB, m models.Category, data interface{}) (int64, error)
+	Delete(tx *gorm.DB, m models.Category) (int64, error)
+	Fetch(page, limit int, search string) ([]models.Category, int64, error)
+	GetById(id string) (models.Category, error)
+	GetByIdForUpdate(tx *gorm.DB, id string) (models.Category, error)
+	GetByName(tx *gorm.DB, name string) (models.Category, error)
+	FetchByIds(tx *gorm.DB, ids []string) ([]models.Category, error)
+	FetchByBooks(tx *gorm.DB, bookIds []string) (map[string][]models.Category, error)
+	FetchBookIds(tx *gorm.DB, ids []string) ([]string, error)
+	CountBooks(tx *gorm.DB, id string) (int64, error)
+	LinkBook(tx *gorm.DB, bookId string, ids []string) error
+	Merge(tx *gorm.DB, id string, sourceIds []string) error
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"digital-book-lending/models"
+
+	"gorm.io/gorm"
+)
+
+type Fine interface {
+	Store(tx *gorm.DB, m models.Fine) (models.Fine, error)
+	FetchByUser(userId string) ([]models.Fine, error)
+	GetBalance(tx *gorm.DB, userId string) (models.Money, error)
+	GetBalanceForUpdate(tx *gorm.DB, userId string) (models.Money, error)
+}
@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"digital-book-lending/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Hold interface {
+	Store(tx *gorm.DB, m models.Hold) (models.Hold, error)
+	Update(tx *gorm.DB, m models.Hold, data interface{}) error
+	GetById(id string) (models.Hold, error)
+	GetByIdForUpdate(tx *gorm.DB, id string) (models.Hold, error)
+	GetActiveByUserAndBook(tx *gorm.DB, userId, bookId string) (models.Hold, error)
+	GetNextWaiting(tx *gorm.DB, bookId string) (models.Hold, error)
+	CountWaiting(tx *gorm.DB, bookId string) (int64, error)
+	CountReady(tx *gorm.DB, bookId string) (int64, error)
+	GetQueuePosition(tx *gorm.DB, m models.Hold) (int64, error)
+	FetchByUser(userId string) ([]models.Hold, error)
+	FetchLapsed(tx *gorm.DB, now time.Time) ([]models.Hold, error)
+	FetchLapsedByBook(tx *gorm.DB, bookId string, now time.Time) ([]models.Hold, error)
+}
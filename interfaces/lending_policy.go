@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"digital-book-lending/models"
+
+	"gorm.io/gorm"
+)
+
+type LendingPolicy interface {
+	Store(m models.LendingPolicy) error
+	Update(m models.LendingPolicy, data interface{}) (int64, error)
+	Delete(m models.LendingPolicy) (int64, error)
+	Fetch() ([]models.LendingPolicy, error)
+	FetchApplicable(tx *gorm.DB, role, category string) ([]models.LendingPolicy, error)
+	RenameCategory(tx *gorm.DB, from []string, to string) error
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"digital-book-lending/models"
+	"digital-book-lending/utils/request"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,6 +12,15 @@ type Lending interface {
 	Store(tx *gorm.DB, m models.LendingRecord) (models.LendingRecord, error)
 	Update(tx *gorm.DB, m models.LendingRecord, data interface{}) error
 	GetActiveByUserAndBook(tx *gorm.DB, userId, bookId string) (models.LendingRecord, error)
-	CountBorrowsByUser(tx *gorm.DB, userId string, since time.Time) (int64, error)
+	CountBorrowsByUser(tx *gorm.DB, userId, category string, since time.Time) (int64, error)
+	CountActiveByUser(tx *gorm.DB, userId, category string) (int64, error)
+	CountActiveDigitalByBook(tx *gorm.DB, bookId string) (int64, error)
+	GetNextDueDate(bookId string) (*time.Time, error)
 	GetBorrowedById(tx *gorm.DB, id string) (models.LendingRecord, error)
+	GetById(id string) (models.LendingRecord, error)
+	MarkOverdue(tx *gorm.DB, now time.Time) (int64, error)
+	FetchExpiredDigital(now time.Time) ([]models.LendingRecord, error)
+	FetchOverdue() ([]models.LendingRecord, error)
+	FetchDueBetween(from, to time.Time) ([]models.LendingRecord, error)
+	Fetch(page, limit int, filter request.LendingFilter) ([]models.LendingRecord, int64, error)
 }
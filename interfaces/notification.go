@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"digital-book-lending/models"
+
+	"gorm.io/gorm"
+)
+
+type Notification interface {
+	Enqueue(tx *gorm.DB, m models.Notification) (int64, error)
+	Update(m models.Notification, data interface{}) error
+	FetchPending(limit, maxAttempts int) ([]models.Notification, error)
+	GetPreference(userId string) (models.NotificationPreference, error)
+	SavePreference(m models.NotificationPreference) error
+}
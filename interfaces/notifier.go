@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"digital-book-lending/models"
+)
+
+// Notifier delivers rendered notifications, by e-mail or any other channel.
+type Notifier interface {
+	Send(ctx context.Context, msg models.Message) error
+}
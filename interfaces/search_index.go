@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"digital-book-lending/models"
+	"time"
+)
+
+// SearchIndex is a full-text index of the catalog kept beside the books table.
+// The books table stays the source of truth; the index can always be rebuilt.
+// Every replica keeps an index of its own.
+type SearchIndex interface {
+	Index(book models.Book) error
+	Delete(id string) error
+	Search(query string, page, limit int) ([]models.SearchHit, int64, error)
+	Suggest(prefix string, limit int) ([]models.SearchHit, error)
+	// Rebuild replaces the index with the books returned by next, which
+	// returns an empty batch once every book has been read.
+	Rebuild(next func() ([]models.Book, error)) (int, error)
+	// SyncedAt is how far the index has caught up with the books table, kept
+	// with the index; the zero time for an index never synced.
+	SyncedAt() (time.Time, error)
+	SetSyncedAt(at time.Time) error
+	Close() error
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+)
+
+// Storage keeps the files of book assets, such as covers and e-books, under a
+// key such as "covers/<book-id>/<upload-id>.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -5,4 +5,5 @@ import "digital-book-lending/models"
 type Users interface {
 	Store(m models.Users) error
 	GetByEmail(email string) (models.Users, error)
+	GetById(id string) (models.Users, error)
 }
@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"digital-book-lending/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Webhook interface {
+	StoreSubscription(m models.WebhookSubscription) error
+	UpdateSubscription(m models.WebhookSubscription, data interface{}) (int64, error)
+	DeleteSubscription(m models.WebhookSubscription) (int64, error)
+	GetSubscriptionById(id string) (models.WebhookSubscription, error)
+	FetchSubscriptions() ([]models.WebhookSubscription, error)
+	FetchActiveSubscriptions(tx *gorm.DB) ([]models.WebhookSubscription, error)
+	StoreEvent(tx *gorm.DB, m models.WebhookEvent) error
+	FetchUndispatchedEvents(tx *gorm.DB, limit int) ([]models.WebhookEvent, error)
+	MarkEventsDispatched(tx *gorm.DB, ids []string, now time.Time) error
+	StoreDeliveries(tx *gorm.DB, m []models.WebhookDelivery) error
+	UpdateDelivery(m models.WebhookDelivery, data interface{}) (int64, error)
+	GetDeliveryById(id string) (models.WebhookDelivery, error)
+	FetchDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	FetchDeliveries(page, limit int, subscriptionId, status string) ([]models.WebhookDelivery, int64, error)
+	StoreAttempt(m models.WebhookDeliveryAttempt) error
+}
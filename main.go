@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"digital-book-lending/app"
 	"digital-book-lending/database"
 	_ "digital-book-lending/docs"
+	"digital-book-lending/notifier"
 	"digital-book-lending/repository"
+	"digital-book-lending/scheduler"
+	"digital-book-lending/search"
 	"digital-book-lending/services"
+	"digital-book-lending/storage"
 	"digital-book-lending/utils"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -89,28 +95,158 @@ func main() {
 	utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("ConfigID: %s", confID))
 
 	runMigration()
+	utils.RegisterValidations()
 
 	db, sqlBookLend := database.ConnDb()
 	defer sqlBookLend.Close()
 
+	fileStorage, err := storage.NewStorage()
+	FailOnError(err, "Failed init storage")
+
+	mailer, err := notifier.NewNotifier()
+	FailOnError(err, "Failed init notifier")
+
+	searchIndex, err := search.NewSearchIndex()
+	FailOnError(err, "Failed open search index")
+	defer searchIndex.Close()
+
 	// Repositories
 	bookRepo := repository.NewBookRepo(db)
+	authorRepo := repository.NewAuthorRepo(db)
+	categoryRepo := repository.NewCategoryRepo(db)
+	copyRepo := repository.NewBookCopyRepo(db)
 	userRepo := repository.NewUserRepo(db)
 	blacklistRepo := repository.NewBlacklistRepo(db)
 	lendingRepo := repository.NewLendingRepo(db)
+	holdRepo := repository.NewHoldRepo(db)
+	fineRepo := repository.NewFineRepo(db)
+	policyRepo := repository.NewLendingPolicyRepo(db)
+	notificationRepo := repository.NewNotificationRepo(db)
+	webhookRepo := repository.NewWebhookRepo(db)
 
 	// Services
-	bookService := services.NewBookService(bookRepo, db)
+	bookService := services.NewBookService(bookRepo, authorRepo, categoryRepo, copyRepo, holdRepo, lendingRepo, notificationRepo, webhookRepo, fileStorage, searchIndex, db)
+	authorService := services.NewAuthorService(authorRepo, categoryRepo, bookRepo, webhookRepo, searchIndex, db)
+	categoryService := services.NewCategoryService(categoryRepo, authorRepo, bookRepo, policyRepo, webhookRepo, searchIndex, db)
+	copyService := services.NewBookCopyService(bookRepo, copyRepo, holdRepo, notificationRepo, db)
 	userService := services.NewUserService(userRepo, blacklistRepo)
-	lendingService := services.NewLendingService(lendingRepo, bookRepo, db)
+	lendingService := services.NewLendingService(lendingRepo, bookRepo, copyRepo, holdRepo, fineRepo, userRepo, policyRepo, notificationRepo, webhookRepo, db)
+	holdService := services.NewHoldService(holdRepo, bookRepo, copyRepo, lendingRepo, notificationRepo, db)
+	fineService := services.NewFineService(fineRepo, userRepo, db)
+	ebookService := services.NewEbookService(lendingRepo, fileStorage)
+	policyService := services.NewLendingPolicyService(policyRepo)
+	webhookService := services.NewWebhookService(webhookRepo, db)
+	notificationService := services.NewNotificationService(notificationRepo, lendingRepo, holdRepo, bookRepo, userRepo, mailer, db)
 
-	routes := app.NewRoutes(bookService, userService, lendingService, blacklistRepo)
+	// subcommands run once and exit. Run them while the API is stopped, as
+	// only one process can open the search index
+	switch flag.Arg(0) {
+	case "reindex":
+		indexed, err := bookService.ReindexBooks()
+		FailOnError(err, "Failed rebuild search index")
+		utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("Search index rebuilt with %d books", indexed))
+		return
+	case "import-books":
+		importBooks(bookService, flag.Args()[1:])
+		return
+	}
+
+	// every replica keeps a search index of its own, so every replica keeps
+	// it in step with the books, whether or not it runs the other jobs
+	jobs := scheduler.NewScheduler(sqlBookLend)
+	jobs.Register(scheduler.Job{
+		Name:       "sync-search-index",
+		Interval:   jobInterval("JOB_SYNC_SEARCH_MINUTES", 1),
+		PerReplica: true,
+		Run:        bookService.SyncSearchIndex,
+	})
+	if utils.GetEnv("SCHEDULER_ENABLED", true).(bool) {
+		jobs.Register(scheduler.Job{
+			Name:     "return-digital-loans",
+			Interval: jobInterval("JOB_RETURN_DIGITAL_MINUTES", 5),
+			Run: func() (int64, error) {
+				returned, err := lendingService.ReturnExpiredDigitalLoans()
+				return int64(returned), err
+			},
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "flag-overdue-loans",
+			Interval: jobInterval("JOB_FLAG_OVERDUE_MINUTES", 60),
+			Run:      lendingService.FlagOverdue,
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "expire-hold-pickups",
+			Interval: jobInterval("JOB_EXPIRE_HOLDS_MINUTES", 15),
+			Run: func() (int64, error) {
+				expired, err := holdService.ExpireLapsedHolds()
+				return int64(expired), err
+			},
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "queue-loan-reminders",
+			Interval: jobInterval("JOB_LOAN_REMINDERS_MINUTES", 60),
+			Run:      notificationService.QueueLoanReminders,
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "send-notifications",
+			Interval: jobInterval("JOB_SEND_NOTIFICATIONS_MINUTES", 1),
+			Run:      notificationService.SendPending,
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "deliver-webhooks",
+			Interval: jobInterval("JOB_DELIVER_WEBHOOKS_MINUTES", 1),
+			Run:      webhookService.DeliverEvents,
+		})
+		jobs.Register(scheduler.Job{
+			Name:     "purge-blacklist",
+			Interval: jobInterval("JOB_PURGE_BLACKLIST_MINUTES", 1440),
+			Run:      userService.PurgeBlacklist,
+		})
+	}
+	jobs.Start(context.Background())
+
+	routes := app.NewRoutes(bookService, authorService, categoryService, copyService, userService, lendingService, holdService, fineService, ebookService, policyService, notificationService, webhookService, blacklistRepo)
 
 	routes.BookLending()
 	err = routes.App.Run(fmt.Sprintf(":%s", port))
 	FailOnError(err, "Failed run service")
 }
 
+// importBooks imports a CSV file into the catalog and prints the report. It
+// exits with status 1 when any row failed.
+func importBooks(bookService *services.BookService, args []string) {
+	cmd := flag.NewFlagSet("import-books", flag.ExitOnError)
+	dryRun := cmd.Bool("dry-run", false, "check and report without saving anything")
+	username := cmd.String("user", "import", "name recorded as the creator of the books")
+	cmd.Usage = func() {
+		fmt.Fprintln(cmd.Output(), "Usage: import-books [-dry-run] [-user name] <file.csv>")
+		cmd.PrintDefaults()
+	}
+	_ = cmd.Parse(args)
+	if cmd.NArg() != 1 {
+		cmd.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(cmd.Arg(0))
+	FailOnError(err, "Failed open import file")
+	defer file.Close()
+
+	report, err := bookService.ImportBooks(file, *dryRun, *username)
+	FailOnError(err, "Failed import books")
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func jobInterval(key string, defMinutes int) time.Duration {
+	return time.Duration(utils.GetEnv(key, defMinutes).(int)) * time.Minute
+}
+
 func runMigration() {
 	m, err := migrate.New(
 		utils.GetEnv("PATH_MIGRATE", "file://migrations").(string),
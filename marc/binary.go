@@ -0,0 +1,192 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// delimiters of MARC21 binary (ISO 2709)
+const (
+	subfieldDelimiter = 0x1f
+	fieldTerminator   = 0x1e
+	recordTerminator  = 0x1d
+
+	leaderLength = 24
+	entryLength  = 12
+)
+
+type binaryReader struct {
+	r     *bufio.Reader
+	index int
+}
+
+func newBinaryReader(r io.Reader) *binaryReader {
+	return &binaryReader{r: bufio.NewReader(r)}
+}
+
+func (b *binaryReader) Read() (Record, error) {
+	data, err := b.r.ReadBytes(recordTerminator)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Record{}, err
+	}
+
+	// some files put a line break between records
+	data = bytes.TrimLeft(data, "\r\n")
+	if len(data) == 0 {
+		return Record{}, io.EOF
+	}
+
+	b.index++
+	if err != nil {
+		return Record{}, &RecordError{Index: b.index, Err: errors.New("record is cut short")}
+	}
+
+	record, err := parseBinary(data)
+	if err != nil {
+		return Record{}, &RecordError{Index: b.index, Err: err}
+	}
+
+	return record, nil
+}
+
+// parseBinary reads one record: a 24 byte leader, a directory of 12 byte
+// entries (tag, length and offset of each field), then the fields.
+func parseBinary(data []byte) (Record, error) {
+	if len(data) < leaderLength+2 {
+		return Record{}, errors.New("record is shorter than its leader")
+	}
+
+	leader := string(data[:leaderLength])
+	base, err := number(leader[12:17])
+	if err != nil || base <= leaderLength || base > len(data) || data[base-1] != fieldTerminator {
+		return Record{}, errors.New("leader has an invalid base address")
+	}
+	if leader[9] != 'a' && !utf8.Valid(data) {
+		return Record{}, errors.New("record is not in UTF-8, MARC-8 records are not supported")
+	}
+
+	record := Record{Leader: leader}
+	directory := data[leaderLength : base-1]
+	if len(directory)%entryLength != 0 {
+		return Record{}, errors.New("directory has an invalid length")
+	}
+
+	for i := 0; i < len(directory); i += entryLength {
+		tag := string(directory[i : i+3])
+		length, err1 := number(string(directory[i+3 : i+7]))
+		start, err2 := number(string(directory[i+7 : i+12]))
+		if err1 != nil || err2 != nil || length < 1 || start < 0 || base+start+length > len(data) {
+			return Record{}, fmt.Errorf("directory entry of field %s is invalid", tag)
+		}
+		field := bytes.TrimSuffix(data[base+start:base+start+length], []byte{fieldTerminator})
+
+		if tag < "010" {
+			record.ControlFields = append(record.ControlFields, ControlField{Tag: tag, Value: string(field)})
+			continue
+		}
+
+		if len(field) < 2 {
+			return Record{}, fmt.Errorf("field %s has no indicators", tag)
+		}
+		dataField := DataField{Tag: tag, Ind1: string(field[0]), Ind2: string(field[1])}
+		for _, part := range bytes.Split(field[2:], []byte{subfieldDelimiter})[1:] {
+			if len(part) == 0 {
+				continue
+			}
+			dataField.Subfields = append(dataField.Subfields, Subfield{Code: string(part[0]), Value: string(part[1:])})
+		}
+		record.DataFields = append(record.DataFields, dataField)
+	}
+
+	return record, nil
+}
+
+// number reads a number of the leader or the directory, which are always
+// written as unsigned digits; strconv.Atoi alone would take "-9999" or "+12".
+func number(s string) (int, error) {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("%q is not a number", s)
+		}
+	}
+
+	return strconv.Atoi(s)
+}
+
+type binaryWriter struct {
+	w io.Writer
+}
+
+func newBinaryWriter(w io.Writer) *binaryWriter {
+	return &binaryWriter{w: w}
+}
+
+func (b *binaryWriter) Write(record Record) error {
+	var directory, fields bytes.Buffer
+
+	addField := func(tag string, content []byte) error {
+		content = append(content, fieldTerminator)
+		if len(content) > 9999 {
+			return fmt.Errorf("field %s is longer than 9999 bytes", tag)
+		}
+		fmt.Fprintf(&directory, "%-3.3s%04d%05d", tag, len(content), fields.Len())
+		fields.Write(content)
+		return nil
+	}
+
+	for _, field := range record.ControlFields {
+		if err := addField(field.Tag, []byte(field.Value)); err != nil {
+			return err
+		}
+	}
+	for _, field := range record.DataFields {
+		content := []byte(indicator(field.Ind1) + indicator(field.Ind2))
+		for _, subfield := range field.Subfields {
+			content = append(content, subfieldDelimiter)
+			content = append(content, subfield.Code...)
+			content = append(content, subfield.Value...)
+		}
+		if err := addField(field.Tag, content); err != nil {
+			return err
+		}
+	}
+	directory.WriteByte(fieldTerminator)
+
+	base := leaderLength + directory.Len()
+	length := base + fields.Len() + 1
+	if length > 99999 {
+		return errors.New("record is longer than 99999 bytes")
+	}
+
+	leader := []byte(fmt.Sprintf("%-24.24s", record.Leader))
+	copy(leader[0:5], fmt.Sprintf("%05d", length))
+	leader[9] = 'a'
+	copy(leader[10:12], "22")
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+	copy(leader[20:24], "4500")
+
+	out := make([]byte, 0, length)
+	out = append(out, leader...)
+	out = append(out, directory.Bytes()...)
+	out = append(out, fields.Bytes()...)
+	out = append(out, recordTerminator)
+
+	_, err := b.w.Write(out)
+	return err
+}
+
+func (b *binaryWriter) Close() error {
+	return nil
+}
+
+func indicator(value string) string {
+	if len(value) != 1 {
+		return " "
+	}
+	return value
+}
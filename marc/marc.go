@@ -0,0 +1,42 @@
+package marc
+
+import (
+	"fmt"
+	"io"
+)
+
+// Reader reads records one at a time and returns io.EOF after the last one.
+// A *RecordError is returned for a record that cannot be read; any other
+// error means the rest of the input cannot be read either.
+type Reader interface {
+	Read() (Record, error)
+}
+
+// Writer writes records; Close finishes the output but leaves the underlying
+// writer open.
+type Writer interface {
+	Write(record Record) error
+	Close() error
+}
+
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatBinary:
+		return newBinaryReader(r), nil
+	case FormatXML:
+		return newXMLReader(r), nil
+	default:
+		return nil, fmt.Errorf("unknown MARC format: %s", format)
+	}
+}
+
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatBinary:
+		return newBinaryWriter(w), nil
+	case FormatXML:
+		return newXMLWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown MARC format: %s", format)
+	}
+}
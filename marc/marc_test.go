@@ -0,0 +1,296 @@
+package marc
+
+import (
+	"bytes"
+	"digital-book-lending/models"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testRecords() []Record {
+	return []Record{
+		FromBook(models.Book{
+			ID:      "5f0c6a4e-8f5e-4f43-9a39-1f2a3e4d5c6b",
+			Title:   "The Go Programming Language",
+			ISBN:    "9780134190440",
+			Authors: []models.Author{{Name: "Alan A. A. Donovan"}, {Name: "Brian W. Kernighan"}},
+			Categories: []models.Category{
+				{Name: "Programming"},
+				{Name: "Go (Computer program language)"},
+			},
+		}),
+		FromBook(models.Book{
+			ID:       "0d1c2b3a-4e5f-4a6b-8c7d-9e0f1a2b3c4d",
+			Title:    "Café Society: À la carte",
+			ISBN:     "9780306406157",
+			Author:   "Zoë Ångström",
+			Category: "Cookery",
+		}),
+	}
+}
+
+func writeRecords(t *testing.T, format string, records []Record) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// readAll reads every record, collecting the *RecordError ones apart.
+func readAll(t *testing.T, format string, data []byte) ([]Record, []*RecordError) {
+	t.Helper()
+
+	r, err := NewReader(bytes.NewReader(data), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		records []Record
+		errs    []*RecordError
+	)
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return records, errs
+		}
+		var recordErr *RecordError
+		if errors.As(err, &recordErr) {
+			errs = append(errs, recordErr)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatBinary, FormatXML} {
+		t.Run(format, func(t *testing.T) {
+			want := testRecords()
+			got, errs := readAll(t, format, writeRecords(t, format, want))
+			if len(errs) > 0 {
+				t.Fatalf("unexpected record errors: %v", errs)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("read %d records, want %d", len(got), len(want))
+			}
+
+			for i := range want {
+				if !reflect.DeepEqual(got[i].ControlFields, want[i].ControlFields) {
+					t.Errorf("record %d control fields = %+v, want %+v", i, got[i].ControlFields, want[i].ControlFields)
+				}
+				if !reflect.DeepEqual(got[i].DataFields, want[i].DataFields) {
+					t.Errorf("record %d data fields = %+v, want %+v", i, got[i].DataFields, want[i].DataFields)
+				}
+				if got[i].Leader[5:10] != want[i].Leader[5:10] {
+					t.Errorf("record %d leader = %q, want type of %q", i, got[i].Leader, want[i].Leader)
+				}
+			}
+		})
+	}
+}
+
+func TestBinaryLeader(t *testing.T) {
+	data := writeRecords(t, FormatBinary, testRecords()[:1])
+	leader := string(data[:leaderLength])
+
+	if length, _ := number(leader[0:5]); length != len(data) {
+		t.Errorf("record length = %d, want %d", length, len(data))
+	}
+	if leader[9] != 'a' {
+		t.Errorf("character coding = %q, want a (UTF-8)", leader[9])
+	}
+	base, _ := number(leader[12:17])
+	if data[base-1] != fieldTerminator {
+		t.Errorf("base address %d does not follow the directory", base)
+	}
+	if data[len(data)-1] != recordTerminator {
+		t.Error("record does not end with the record terminator")
+	}
+}
+
+func TestBook(t *testing.T) {
+	records := testRecords()
+
+	book := records[0].Book()
+	if book.ISBN != "9780134190440" || book.Title != "The Go Programming Language" || book.Author != "Alan A. A. Donovan" || book.Category != "Programming" {
+		t.Errorf("Book() = %+v", book)
+	}
+
+	var added []string
+	for _, field := range records[0].DataFields {
+		if field.Tag == "700" {
+			added = append(added, field.Subfields[0].Value)
+		}
+	}
+	if !reflect.DeepEqual(added, []string{"Brian W. Kernighan"}) {
+		t.Errorf("700 fields = %v, want the second author", added)
+	}
+
+	book = records[1].Book()
+	if book.Title != "Café Society: À la carte" || book.Author != "Zoë Ångström" || book.Category != "Cookery" {
+		t.Errorf("Book() = %+v", book)
+	}
+}
+
+func TestBookTrimsPunctuation(t *testing.T) {
+	record := Record{DataFields: []DataField{
+		{Tag: "020", Subfields: []Subfield{{Code: "a", Value: "0306406152 (pbk.)"}}},
+		{Tag: "100", Subfields: []Subfield{{Code: "a", Value: "Smith, J. ,"}}},
+		{Tag: "245", Subfields: []Subfield{{Code: "a", Value: "Title /"}, {Code: "b", Value: "a subtitle."}}},
+		{Tag: "650", Subfields: []Subfield{{Code: "a", Value: "History."}}},
+	}}
+
+	book := record.Book()
+	if book.ISBN != "0306406152" || book.Author != "Smith, J." || book.Title != "Title: a subtitle" || book.Category != "History" {
+		t.Errorf("Book() = %+v", book)
+	}
+}
+
+// directoryOffset is where the first directory entry of a binary record
+// starts; its length is at +3 and its start at +7.
+const directoryOffset = leaderLength
+
+func TestBinaryMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		mangle func(record []byte) []byte
+	}{
+		{name: "negative start", mangle: func(r []byte) []byte {
+			copy(r[directoryOffset+7:], "-9999")
+			return r
+		}},
+		{name: "signed length", mangle: func(r []byte) []byte {
+			copy(r[directoryOffset+3:], "+036")
+			return r
+		}},
+		{name: "negative length", mangle: func(r []byte) []byte {
+			copy(r[directoryOffset+3:], "-001")
+			return r
+		}},
+		{name: "start past the end", mangle: func(r []byte) []byte {
+			copy(r[directoryOffset+7:], "99999")
+			return r
+		}},
+		{name: "zero length", mangle: func(r []byte) []byte {
+			copy(r[directoryOffset+3:], "0000")
+			return r
+		}},
+		{name: "negative base address", mangle: func(r []byte) []byte {
+			copy(r[12:17], "-0024")
+			return r
+		}},
+		{name: "base address past the end", mangle: func(r []byte) []byte {
+			copy(r[12:17], "99999")
+			return r
+		}},
+		{name: "base address inside the directory", mangle: func(r []byte) []byte {
+			copy(r[12:17], "00030")
+			return r
+		}},
+		{name: "shorter than the leader", mangle: func(r []byte) []byte {
+			return append(r[:10:10], recordTerminator)
+		}},
+		{name: "MARC-8", mangle: func(r []byte) []byte {
+			r[9] = ' '
+			return bytes.Replace(r, []byte("Café"), []byte("Caf\xe9 "), 1)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := testRecords()
+			first := writeRecords(t, FormatBinary, records[1:])
+			second := writeRecords(t, FormatBinary, records[:1])
+
+			data := append(tt.mangle(first), second...)
+			got, errs := readAll(t, FormatBinary, data)
+			if len(errs) != 1 || errs[0].Index != 1 {
+				t.Fatalf("record errors = %v, want one for record 1", errs)
+			}
+			if len(got) != 1 || got[0].ControlFields[0].Value != records[0].ControlFields[0].Value {
+				t.Errorf("records after the malformed one = %+v, want the next record", got)
+			}
+		})
+	}
+}
+
+func TestBinaryCutShort(t *testing.T) {
+	data := writeRecords(t, FormatBinary, testRecords())
+	data = data[:len(data)-10]
+
+	got, errs := readAll(t, FormatBinary, data)
+	if len(got) != 1 || len(errs) != 1 || errs[0].Index != 2 {
+		t.Errorf("records = %d, errors = %v, want the first record and an error for the second", len(got), errs)
+	}
+}
+
+func TestBinaryLineBreaks(t *testing.T) {
+	records := testRecords()
+	data := writeRecords(t, FormatBinary, records[:1])
+	data = append(data, "\r\n"...)
+	data = append(data, writeRecords(t, FormatBinary, records[1:])...)
+	data = append(data, '\n')
+
+	got, errs := readAll(t, FormatBinary, data)
+	if len(got) != 2 || len(errs) != 0 {
+		t.Errorf("records = %d, errors = %v, want 2 records", len(got), errs)
+	}
+}
+
+func TestXMLSingleRecord(t *testing.T) {
+	data := `<record xmlns="http://www.loc.gov/MARC21/slim">
+  <leader>00000nam a2200000 i 4500</leader>
+  <datafield tag="020" ind1=" " ind2=" "><subfield code="a">9780306406157</subfield></datafield>
+</record>`
+
+	got, _ := readAll(t, FormatXML, []byte(data))
+	if len(got) != 1 || got[0].Book().ISBN != "9780306406157" {
+		t.Errorf("records = %+v", got)
+	}
+}
+
+func TestXMLBroken(t *testing.T) {
+	data := writeRecords(t, FormatXML, testRecords())
+	data = data[:bytes.LastIndex(data, []byte("<datafield"))+5]
+
+	r, err := NewReader(bytes.NewReader(data), FormatXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(); err != nil {
+		t.Fatalf("first record: %v", err)
+	}
+	_, err = r.Read()
+	if err == nil || errors.Is(err, io.EOF) || !strings.Contains(err.Error(), "invalid MARCXML") {
+		t.Errorf("Read of the broken record = %v, want invalid MARCXML", err)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewReader(nil, "json"); err == nil {
+		t.Error("NewReader accepted an unknown format")
+	}
+	if _, err := NewWriter(nil, "json"); err == nil {
+		t.Error("NewWriter accepted an unknown format")
+	}
+}
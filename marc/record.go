@@ -0,0 +1,135 @@
+package marc
+
+import (
+	"digital-book-lending/models"
+	"encoding/xml"
+	"strings"
+)
+
+const (
+	FormatBinary = "marc"
+	FormatXML    = "marcxml"
+
+	namespace = "http://www.loc.gov/MARC21/slim"
+)
+
+// Record is a bibliographic record, kept as tagged fields so it can be written
+// as MARC21 binary or as MARCXML.
+type Record struct {
+	XMLName       xml.Name       `xml:"record"`
+	Leader        string         `xml:"leader"`
+	ControlFields []ControlField `xml:"controlfield"`
+	DataFields    []DataField    `xml:"datafield"`
+}
+
+type ControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type DataField struct {
+	Tag       string     `xml:"tag,attr"`
+	Ind1      string     `xml:"ind1,attr"`
+	Ind2      string     `xml:"ind2,attr"`
+	Subfields []Subfield `xml:"subfield"`
+}
+
+type Subfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// RecordError is a record that could not be read. The records after it can
+// still be read.
+type RecordError struct {
+	Index int
+	Err   error
+}
+
+func (e *RecordError) Error() string {
+	return e.Err.Error()
+}
+
+// FromBook describes a book in MARC21: 001 its id, 020 $a the ISBN, 100 $a
+// the first author, 700 $a each other author, 245 $a the title and 650 $a
+// each category as a subject.
+func FromBook(book models.Book) Record {
+	authors := []string{book.Author}
+	if len(book.Authors) > 0 {
+		authors = authors[:0]
+		for _, author := range book.Authors {
+			authors = append(authors, author.Name)
+		}
+	}
+	categories := []string{book.Category}
+	if len(book.Categories) > 0 {
+		categories = categories[:0]
+		for _, category := range book.Categories {
+			categories = append(categories, category.Name)
+		}
+	}
+
+	fields := []DataField{
+		{Tag: "020", Ind1: " ", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: book.ISBN}}},
+		{Tag: "100", Ind1: "1", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: authors[0]}}},
+		{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []Subfield{{Code: "a", Value: book.Title}}},
+	}
+	for _, category := range categories {
+		fields = append(fields, DataField{Tag: "650", Ind1: " ", Ind2: "4", Subfields: []Subfield{{Code: "a", Value: category}}})
+	}
+	for _, author := range authors[1:] {
+		fields = append(fields, DataField{Tag: "700", Ind1: "1", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: author}}})
+	}
+
+	return Record{
+		Leader:        newLeader(),
+		ControlFields: []ControlField{{Tag: "001", Value: book.ID}},
+		DataFields:    fields,
+	}
+}
+
+// Book reads the fields FromBook writes. The ISBN is the first word of the
+// first 020 $a, as catalogers often add a qualifier such as "(pbk.)"; the
+// title joins 245 $a and $b. The punctuation that MARC puts at the end of
+// fields is dropped, except a full stop after an author, which usually ends
+// an initial. A field the record does not have is left empty.
+func (r Record) Book() models.Book {
+	var book models.Book
+
+	if isbn := strings.Fields(r.subfield("020", "a")); len(isbn) > 0 {
+		book.ISBN = isbn[0]
+	}
+	book.Author = trimPunctuation(r.subfield("100", "a"), " /:;,=")
+	book.Title = trimPunctuation(r.subfield("245", "a"), " /:;,=.")
+	if subtitle := trimPunctuation(r.subfield("245", "b"), " /:;,=."); subtitle != "" {
+		book.Title += ": " + subtitle
+	}
+	book.Category = trimPunctuation(r.subfield("650", "a"), " /:;,=.")
+
+	return book
+}
+
+// subfield is the first non-empty value of the code in a field with the tag.
+func (r Record) subfield(tag, code string) string {
+	for _, field := range r.DataFields {
+		if field.Tag != tag {
+			continue
+		}
+		for _, subfield := range field.Subfields {
+			if value := strings.TrimSpace(subfield.Value); subfield.Code == code && value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// newLeader is the leader of a new, complete, UTF-8 record of a printed book.
+// The record length and base address are filled in when it is written.
+func newLeader() string {
+	return "00000nam a2200000 i 4500"
+}
+
+func trimPunctuation(value, cutset string) string {
+	return strings.TrimRight(strings.TrimSpace(value), cutset)
+}
@@ -0,0 +1,94 @@
+package marc
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type xmlReader struct {
+	d     *xml.Decoder
+	index int
+}
+
+func newXMLReader(r io.Reader) *xmlReader {
+	return &xmlReader{d: xml.NewDecoder(r)}
+}
+
+// Read returns the next <record>, whether it sits in a <collection> or is the
+// root element. Broken XML ends the input.
+func (x *xmlReader) Read() (Record, error) {
+	for {
+		token, err := x.d.Token()
+		if errors.Is(err, io.EOF) {
+			return Record{}, io.EOF
+		}
+		if err != nil {
+			return Record{}, fmt.Errorf("invalid MARCXML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		x.index++
+		var record Record
+		if err := x.d.DecodeElement(&record, &start); err != nil {
+			return Record{}, fmt.Errorf("invalid MARCXML: %w", err)
+		}
+
+		return record, nil
+	}
+}
+
+type xmlWriter struct {
+	w       io.Writer
+	e       *xml.Encoder
+	started bool
+}
+
+func newXMLWriter(w io.Writer) *xmlWriter {
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	return &xmlWriter{w: w, e: e}
+}
+
+var collection = xml.StartElement{
+	Name: xml.Name{Local: "collection"},
+	Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: namespace}},
+}
+
+func (x *xmlWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+
+	if _, err := io.WriteString(x.w, xml.Header); err != nil {
+		return err
+	}
+	return x.e.EncodeToken(collection)
+}
+
+func (x *xmlWriter) Write(record Record) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	return x.e.Encode(record)
+}
+
+func (x *xmlWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if err := x.e.EncodeToken(collection.End()); err != nil {
+		return err
+	}
+	if err := x.e.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(x.w, "\n")
+	return err
+}
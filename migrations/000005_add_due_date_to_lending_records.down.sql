@@ -0,0 +1,6 @@
+UPDATE `lending_records` SET `status` = 'borrowed' WHERE `status` = 'overdue';
+
+ALTER TABLE `lending_records`
+    DROP INDEX `idx_lending_records_status_due_date`,
+    DROP COLUMN `due_date`,
+    MODIFY COLUMN `status` ENUM('borrowed', 'returned') NOT NULL DEFAULT 'borrowed';
@@ -0,0 +1,9 @@
+ALTER TABLE `lending_records`
+    ADD COLUMN `due_date` TIMESTAMP NULL DEFAULT NULL AFTER `borrow_date`,
+    MODIFY COLUMN `status` ENUM('borrowed', 'returned', 'overdue') NOT NULL DEFAULT 'borrowed';
+
+UPDATE `lending_records` SET `due_date` = DATE_ADD(`borrow_date`, INTERVAL 14 DAY) WHERE `due_date` IS NULL;
+
+ALTER TABLE `lending_records`
+    MODIFY COLUMN `due_date` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD INDEX `idx_lending_records_status_due_date` (`status`, `due_date`);
@@ -0,0 +1,2 @@
+ALTER TABLE `lending_records`
+    DROP COLUMN `renewal_count`;
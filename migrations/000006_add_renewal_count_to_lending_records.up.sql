@@ -0,0 +1,2 @@
+ALTER TABLE `lending_records`
+    ADD COLUMN `renewal_count` INT NOT NULL DEFAULT 0 AFTER `status`;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `holds` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `user_id` CHAR(36) NOT NULL,
+    `book_id` CHAR(36) NOT NULL,
+    `status` ENUM('waiting', 'ready', 'fulfilled', 'cancelled', 'expired') NOT NULL DEFAULT 'waiting',
+    `ready_at` TIMESTAMP NULL,
+    `expires_at` TIMESTAMP NULL,
+
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX `idx_holds_book_status` (`book_id`, `status`, `created_at`),
+    INDEX `idx_holds_status_expires_at` (`status`, `expires_at`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`book_id`) REFERENCES `books`(`id`) ON DELETE RESTRICT
+);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `fines` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `user_id` CHAR(36) NOT NULL,
+    `lending_id` CHAR(36) NULL,
+    `type` ENUM('charge', 'payment', 'waiver') NOT NULL,
+    `amount` DECIMAL(12, 2) NOT NULL,
+    `days_overdue` INT NOT NULL DEFAULT 0,
+    `reason` VARCHAR(255) NULL,
+
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by` VARCHAR(100) NOT NULL,
+
+    INDEX `idx_fines_user_created_at` (`user_id`, `created_at`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`lending_id`) REFERENCES `lending_records`(`id`) ON DELETE SET NULL
+);
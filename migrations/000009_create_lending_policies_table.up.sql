@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS `lending_policies` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `name` VARCHAR(100) NOT NULL,
+    `role` VARCHAR(50) NOT NULL DEFAULT '',
+    `category` VARCHAR(100) NOT NULL DEFAULT '',
+    `max_concurrent_loans` INT NULL,
+    `window_days` INT NULL,
+    `window_max_borrows` INT NULL,
+    `loan_period_days` INT NOT NULL,
+    `max_renewals` INT NOT NULL DEFAULT 0,
+
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by` VARCHAR(100) NOT NULL,
+    `updated_at` DATETIME NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    `updated_by` VARCHAR(100) NULL DEFAULT NULL,
+
+    UNIQUE KEY `idx_lending_policies_role_category` (`role`, `category`)
+);
+
+-- the rule BorrowBook used to hard-code: 5 borrows in the last 7 days
+INSERT INTO `lending_policies` (`id`, `name`, `window_days`, `window_max_borrows`, `loan_period_days`, `max_renewals`, `created_by`)
+VALUES (UUID(), 'Default', 7, 5, 14, 2, 'system');
@@ -0,0 +1,9 @@
+ALTER TABLE `holds`
+    DROP FOREIGN KEY `fk_holds_copy`,
+    DROP COLUMN `copy_id`;
+
+ALTER TABLE `lending_records`
+    DROP FOREIGN KEY `fk_lending_records_copy`,
+    DROP COLUMN `copy_id`;
+
+DROP TABLE IF EXISTS book_copies;
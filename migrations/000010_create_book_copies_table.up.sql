@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS `book_copies` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `book_id` CHAR(36) NOT NULL,
+    `barcode` VARCHAR(150) NOT NULL UNIQUE,
+    `condition` ENUM('new', 'good', 'fair', 'poor', 'damaged') NOT NULL DEFAULT 'good',
+    `location` VARCHAR(100) NULL DEFAULT NULL,
+    `status` ENUM('available', 'on_loan', 'on_hold', 'maintenance', 'lost', 'withdrawn') NOT NULL DEFAULT 'available',
+
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by` VARCHAR(100) NOT NULL,
+    `updated_at` DATETIME NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    `updated_by` VARCHAR(100) NULL DEFAULT NULL,
+
+    INDEX `idx_book_copies_book_status` (`book_id`, `status`),
+    FOREIGN KEY (`book_id`) REFERENCES `books`(`id`) ON DELETE RESTRICT
+);
+
+ALTER TABLE `lending_records`
+    ADD COLUMN `copy_id` CHAR(36) NULL DEFAULT NULL AFTER `book_id`,
+    ADD CONSTRAINT `fk_lending_records_copy` FOREIGN KEY (`copy_id`) REFERENCES `book_copies`(`id`) ON DELETE RESTRICT;
+
+ALTER TABLE `holds`
+    ADD COLUMN `copy_id` CHAR(36) NULL DEFAULT NULL AFTER `book_id`,
+    ADD CONSTRAINT `fk_holds_copy` FOREIGN KEY (`copy_id`) REFERENCES `book_copies`(`id`) ON DELETE SET NULL;
+
+SET SESSION cte_max_recursion_depth = 1000000;
+
+-- books.quantity counts the copies on the shelf: each one becomes an available copy
+INSERT INTO `book_copies` (`id`, `book_id`, `barcode`, `status`, `created_by`)
+WITH RECURSIVE `seq` (`n`) AS (
+    SELECT 1
+    UNION ALL
+    SELECT `n` + 1 FROM `seq` WHERE `n` < (SELECT COALESCE(MAX(`quantity`), 0) FROM `books`)
+)
+SELECT UUID(), `b`.`id`, CONCAT(REPLACE(REPLACE(`b`.`isbn`, '-', ''), ' ', ''), '-', LPAD(`seq`.`n`, 3, '0')), 'available', 'migration'
+FROM `books` `b`
+JOIN `seq` ON `seq`.`n` <= `b`.`quantity`;
+
+-- copies out on loan or set aside for a hold were not counted in books.quantity;
+-- they reuse the id of the lending record or hold they belong to
+INSERT INTO `book_copies` (`id`, `book_id`, `barcode`, `status`, `created_by`)
+SELECT `lr`.`id`, `lr`.`book_id`, CONCAT(REPLACE(REPLACE(`b`.`isbn`, '-', ''), ' ', ''), '-L', REPLACE(`lr`.`id`, '-', '')), 'on_loan', 'migration'
+FROM `lending_records` `lr`
+JOIN `books` `b` ON `b`.`id` = `lr`.`book_id`
+WHERE `lr`.`status` IN ('borrowed', 'overdue');
+
+UPDATE `lending_records` SET `copy_id` = `id` WHERE `status` IN ('borrowed', 'overdue');
+
+INSERT INTO `book_copies` (`id`, `book_id`, `barcode`, `status`, `created_by`)
+SELECT `h`.`id`, `h`.`book_id`, CONCAT(REPLACE(REPLACE(`b`.`isbn`, '-', ''), ' ', ''), '-H', REPLACE(`h`.`id`, '-', '')), 'on_hold', 'migration'
+FROM `holds` `h`
+JOIN `books` `b` ON `b`.`id` = `h`.`book_id`
+WHERE `h`.`status` = 'ready';
+
+UPDATE `holds` SET `copy_id` = `id` WHERE `status` = 'ready';
@@ -0,0 +1,3 @@
+ALTER TABLE `books`
+    DROP COLUMN `ebook_format`,
+    DROP COLUMN `ebook_file`;
@@ -0,0 +1,3 @@
+ALTER TABLE `books`
+    ADD COLUMN `ebook_file` VARCHAR(255) NULL DEFAULT NULL AFTER `quantity`,
+    ADD COLUMN `ebook_format` ENUM('epub', 'pdf') NULL DEFAULT NULL AFTER `ebook_file`;
@@ -0,0 +1,13 @@
+UPDATE `books`
+SET `ebook_key` = SUBSTRING(`ebook_key`, LENGTH('ebooks/') + 1)
+WHERE `ebook_key` LIKE 'ebooks/%';
+
+ALTER TABLE `books`
+    DROP COLUMN `ebook_checksum`,
+    DROP COLUMN `ebook_size`,
+    DROP COLUMN `ebook_content_type`,
+    CHANGE COLUMN `ebook_key` `ebook_file` VARCHAR(255) NULL DEFAULT NULL,
+    DROP COLUMN `cover_checksum`,
+    DROP COLUMN `cover_size`,
+    DROP COLUMN `cover_content_type`,
+    DROP COLUMN `cover_key`;
@@ -0,0 +1,17 @@
+ALTER TABLE `books`
+    ADD COLUMN `cover_key` VARCHAR(255) NULL DEFAULT NULL AFTER `quantity`,
+    ADD COLUMN `cover_content_type` VARCHAR(100) NULL DEFAULT NULL AFTER `cover_key`,
+    ADD COLUMN `cover_size` BIGINT NULL DEFAULT NULL AFTER `cover_content_type`,
+    ADD COLUMN `cover_checksum` CHAR(64) NULL DEFAULT NULL AFTER `cover_size`,
+    CHANGE COLUMN `ebook_file` `ebook_key` VARCHAR(255) NULL DEFAULT NULL,
+    ADD COLUMN `ebook_content_type` VARCHAR(100) NULL DEFAULT NULL AFTER `ebook_format`,
+    ADD COLUMN `ebook_size` BIGINT NULL DEFAULT NULL AFTER `ebook_content_type`,
+    ADD COLUMN `ebook_checksum` CHAR(64) NULL DEFAULT NULL AFTER `ebook_size`;
+
+-- e-books were stored by file name under EBOOK_DIR (storage/ebooks by default)
+-- and are now the "ebooks/" prefix of the storage; the files themselves have to
+-- be moved there by hand, into data/storage/ebooks for the default local storage
+UPDATE `books`
+SET `ebook_key` = CONCAT('ebooks/', `ebook_key`),
+    `ebook_content_type` = IF(`ebook_format` = 'epub', 'application/epub+zip', 'application/pdf')
+WHERE `ebook_key` IS NOT NULL;
@@ -0,0 +1,10 @@
+ALTER TABLE `lending_records`
+    DROP COLUMN `is_digital`;
+
+ALTER TABLE `books`
+    DROP INDEX `idx_books_license_expires_at`,
+    DROP COLUMN `license_expires_at`,
+    DROP COLUMN `license_checkouts`,
+    DROP COLUMN `license_max_checkouts`,
+    DROP COLUMN `license_seats`,
+    DROP COLUMN `license_type`;
@@ -0,0 +1,10 @@
+ALTER TABLE `books`
+    ADD COLUMN `license_type` ENUM('one_copy_one_user', 'metered', 'time_limited', 'simultaneous_use') NULL DEFAULT NULL AFTER `ebook_checksum`,
+    ADD COLUMN `license_seats` INT NULL DEFAULT NULL AFTER `license_type`,
+    ADD COLUMN `license_max_checkouts` INT NULL DEFAULT NULL AFTER `license_seats`,
+    ADD COLUMN `license_checkouts` INT NOT NULL DEFAULT 0 AFTER `license_max_checkouts`,
+    ADD COLUMN `license_expires_at` DATETIME NULL DEFAULT NULL AFTER `license_checkouts`,
+    ADD INDEX `idx_books_license_expires_at` (`license_expires_at`);
+
+ALTER TABLE `lending_records`
+    ADD COLUMN `is_digital` TINYINT(1) NOT NULL DEFAULT 0 AFTER `copy_id`;
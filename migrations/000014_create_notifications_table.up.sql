@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS `notifications` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `user_id` CHAR(36) NOT NULL,
+    `book_id` CHAR(36) NOT NULL,
+    `type` ENUM('due_soon', 'overdue', 'hold_ready') NOT NULL,
+    `reference_id` CHAR(36) NOT NULL,
+    `due_at` DATETIME NOT NULL,
+    `dedup_key` VARCHAR(100) NOT NULL,
+    `status` ENUM('pending', 'sent', 'skipped', 'failed') NOT NULL DEFAULT 'pending',
+    `attempts` INT NOT NULL DEFAULT 0,
+    `last_error` VARCHAR(255) NULL DEFAULT NULL,
+    `sent_at` DATETIME NULL DEFAULT NULL,
+
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` DATETIME NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY `idx_notifications_dedup_key` (`dedup_key`),
+    INDEX `idx_notifications_status_created_at` (`status`, `created_at`),
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `notification_preferences` (
+    `user_id` CHAR(36) NOT NULL PRIMARY KEY,
+    `locale` ENUM('en', 'id') NOT NULL DEFAULT 'en',
+    `due_soon` TINYINT(1) NOT NULL DEFAULT 1,
+    `overdue` TINYINT(1) NOT NULL DEFAULT 1,
+    `hold_ready` TINYINT(1) NOT NULL DEFAULT 1,
+
+    `updated_at` DATETIME NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS `webhook_subscriptions` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `url` VARCHAR(2048) NOT NULL,
+    `description` VARCHAR(255) NULL DEFAULT NULL,
+    `events` VARCHAR(255) NOT NULL,
+    `secret` VARCHAR(128) NOT NULL,
+    `is_active` TINYINT(1) NOT NULL DEFAULT 1,
+
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by` VARCHAR(100) NOT NULL,
+    `updated_at` DATETIME NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    `updated_by` VARCHAR(100) NULL DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `webhook_events` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `type` VARCHAR(50) NOT NULL,
+    `payload` JSON NOT NULL,
+    `dispatched_at` DATETIME NULL DEFAULT NULL,
+
+    `created_at` DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+
+    INDEX `idx_webhook_events_dispatched_at` (`dispatched_at`, `created_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `subscription_id` CHAR(36) NOT NULL,
+    `event_id` CHAR(36) NOT NULL,
+    `status` ENUM('pending', 'delivered', 'failed') NOT NULL DEFAULT 'pending',
+    `attempts` INT NOT NULL DEFAULT 0,
+    `next_attempt_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `delivered_at` DATETIME NULL DEFAULT NULL,
+
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` DATETIME NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY `idx_webhook_deliveries_subscription_event` (`subscription_id`, `event_id`),
+    INDEX `idx_webhook_deliveries_status_next_attempt_at` (`status`, `next_attempt_at`),
+    FOREIGN KEY (`subscription_id`) REFERENCES `webhook_subscriptions`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`event_id`) REFERENCES `webhook_events`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `webhook_delivery_attempts` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `delivery_id` CHAR(36) NOT NULL,
+    `status_code` INT NULL DEFAULT NULL,
+    `error` VARCHAR(255) NULL DEFAULT NULL,
+    `response_body` VARCHAR(1024) NULL DEFAULT NULL,
+    `duration_ms` INT NOT NULL,
+
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX `idx_webhook_delivery_attempts_delivery_id` (`delivery_id`, `created_at`),
+    FOREIGN KEY (`delivery_id`) REFERENCES `webhook_deliveries`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- the ISBNs stay normalized: the forms they were written in are not kept
+DROP TABLE IF EXISTS `book_isbn_duplicates`;
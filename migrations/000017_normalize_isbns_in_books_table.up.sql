@@ -0,0 +1,83 @@
+-- ISBNs are stored as ISBN-13 digits: "978-0-306-40615-7" and "0-306-40615-2"
+-- both become "9780306406157". An ISBN with a wrong checksum is left as it is.
+
+-- a book whose ISBN normalizes to the ISBN of another book keeps its own and is
+-- listed here, with the book that got the normalized ISBN, to be merged or
+-- corrected by hand
+CREATE TABLE IF NOT EXISTS `book_isbn_duplicates` (
+    `book_id` CHAR(36) NOT NULL PRIMARY KEY,
+    `isbn` VARCHAR(100) NOT NULL,
+    `normalized_isbn` CHAR(13) NOT NULL,
+    `kept_book_id` CHAR(36) NOT NULL,
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX `idx_book_isbn_duplicates_normalized_isbn` (`normalized_isbn`)
+);
+
+-- prefix holds the first 12 digits of the ISBN-13 of a well-formed ISBN
+CREATE TABLE `book_isbn_normalization` (
+    `book_id` CHAR(36) NOT NULL PRIMARY KEY,
+    `digits` VARCHAR(100) NOT NULL,
+    `prefix` CHAR(12) NULL DEFAULT NULL,
+    `normalized_isbn` CHAR(13) NULL DEFAULT NULL
+);
+
+INSERT INTO `book_isbn_normalization` (`book_id`, `digits`)
+SELECT `id`, UPPER(REPLACE(REPLACE(`isbn`, '-', ''), ' ', '')) FROM `books`;
+
+-- an ISBN-10 with a valid checksum is prefixed with 978 and loses its check digit
+UPDATE `book_isbn_normalization`
+SET `prefix` = CONCAT('978', LEFT(`digits`, 9))
+WHERE `digits` REGEXP '^[0-9]{9}[0-9X]$'
+  AND (
+      10 * SUBSTRING(`digits`, 1, 1) + 9 * SUBSTRING(`digits`, 2, 1) + 8 * SUBSTRING(`digits`, 3, 1)
+    + 7 * SUBSTRING(`digits`, 4, 1) + 6 * SUBSTRING(`digits`, 5, 1) + 5 * SUBSTRING(`digits`, 6, 1)
+    + 4 * SUBSTRING(`digits`, 7, 1) + 3 * SUBSTRING(`digits`, 8, 1) + 2 * SUBSTRING(`digits`, 9, 1)
+    + IF(RIGHT(`digits`, 1) = 'X', 10, RIGHT(`digits`, 1))
+  ) % 11 = 0;
+
+UPDATE `book_isbn_normalization`
+SET `prefix` = LEFT(`digits`, 12)
+WHERE `digits` REGEXP '^97[89][0-9]{10}$';
+
+-- the check digit of the ISBN-13 weighs its first 12 digits 1, 3, 1, 3, ...
+UPDATE `book_isbn_normalization`
+SET `normalized_isbn` = CONCAT(`prefix`, (10 - (
+      SUBSTRING(`prefix`, 1, 1) + 3 * SUBSTRING(`prefix`, 2, 1) + SUBSTRING(`prefix`, 3, 1) + 3 * SUBSTRING(`prefix`, 4, 1)
+    + SUBSTRING(`prefix`, 5, 1) + 3 * SUBSTRING(`prefix`, 6, 1) + SUBSTRING(`prefix`, 7, 1) + 3 * SUBSTRING(`prefix`, 8, 1)
+    + SUBSTRING(`prefix`, 9, 1) + 3 * SUBSTRING(`prefix`, 10, 1) + SUBSTRING(`prefix`, 11, 1) + 3 * SUBSTRING(`prefix`, 12, 1)
+) % 10) % 10)
+WHERE `prefix` IS NOT NULL;
+
+-- an ISBN-13 is valid when its own check digit is the one computed
+UPDATE `book_isbn_normalization`
+SET `normalized_isbn` = NULL
+WHERE LENGTH(`digits`) = 13 AND `normalized_isbn` <> `digits`;
+
+-- among books sharing a normalized ISBN, the one already holding it keeps it,
+-- else the live book added first
+INSERT INTO `book_isbn_duplicates` (`book_id`, `isbn`, `normalized_isbn`, `kept_book_id`)
+SELECT `ranked`.`book_id`, `ranked`.`isbn`, `ranked`.`normalized_isbn`, `ranked`.`kept_book_id`
+FROM (
+    SELECT `n`.`book_id`, `b`.`isbn`, `n`.`normalized_isbn`,
+        ROW_NUMBER() OVER `same_isbn` AS `position`,
+        FIRST_VALUE(`n`.`book_id`) OVER `same_isbn` AS `kept_book_id`
+    FROM `book_isbn_normalization` `n`
+    JOIN `books` `b` ON `b`.`id` = `n`.`book_id`
+    WHERE `n`.`normalized_isbn` IS NOT NULL
+    WINDOW `same_isbn` AS (
+        PARTITION BY `n`.`normalized_isbn`
+        ORDER BY `b`.`isbn` = `n`.`normalized_isbn` DESC, `b`.`deleted_at` IS NOT NULL, `b`.`created_at`, `b`.`id`
+    )
+) `ranked`
+WHERE `ranked`.`position` > 1;
+
+UPDATE `books` `b`
+JOIN `book_isbn_normalization` `n` ON `n`.`book_id` = `b`.`id`
+LEFT JOIN `book_isbn_duplicates` `d` ON `d`.`book_id` = `b`.`id`
+SET `b`.`isbn` = `n`.`normalized_isbn`, `b`.`updated_by` = 'migration'
+WHERE `n`.`normalized_isbn` IS NOT NULL
+  AND `d`.`book_id` IS NULL
+  AND `b`.`isbn` <> `n`.`normalized_isbn`;
+
+DROP TABLE `book_isbn_normalization`;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS book_categories;
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS authors;
+
+-- fails while a book has more authors than fit the old column
+ALTER TABLE `books` MODIFY `author` VARCHAR(100) NOT NULL;
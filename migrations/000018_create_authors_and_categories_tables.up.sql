@@ -0,0 +1,84 @@
+CREATE TABLE IF NOT EXISTS `authors` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `name` VARCHAR(100) NOT NULL UNIQUE,
+
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by` VARCHAR(100) NOT NULL,
+    `updated_at` DATETIME NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    `updated_by` VARCHAR(100) NULL DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `categories` (
+    `id` CHAR(36) NOT NULL PRIMARY KEY,
+    `name` VARCHAR(100) NOT NULL UNIQUE,
+
+    `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `created_by` VARCHAR(100) NOT NULL,
+    `updated_at` DATETIME NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP,
+    `updated_by` VARCHAR(100) NULL DEFAULT NULL
+);
+
+-- position orders the authors and categories of a book, the first category
+-- being the one lending policies and fine rates go by
+CREATE TABLE IF NOT EXISTS `book_authors` (
+    `book_id` CHAR(36) NOT NULL,
+    `author_id` CHAR(36) NOT NULL,
+    `position` INT NOT NULL DEFAULT 1,
+
+    PRIMARY KEY (`book_id`, `author_id`),
+    INDEX `idx_book_authors_author` (`author_id`),
+    FOREIGN KEY (`book_id`) REFERENCES `books`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`author_id`) REFERENCES `authors`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `book_categories` (
+    `book_id` CHAR(36) NOT NULL,
+    `category_id` CHAR(36) NOT NULL,
+    `position` INT NOT NULL DEFAULT 1,
+
+    PRIMARY KEY (`book_id`, `category_id`),
+    INDEX `idx_book_categories_category` (`category_id`),
+    FOREIGN KEY (`book_id`) REFERENCES `books`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`) ON DELETE CASCADE
+);
+
+-- books.author now holds the names of all the authors of a book, separated by
+-- commas, for display, sorting and full-text search
+ALTER TABLE `books` MODIFY `author` VARCHAR(1100) NOT NULL;
+
+-- every author and category written on a book becomes one, names differing
+-- only in case or accents included, as the unique index would have them
+INSERT INTO `authors` (`id`, `name`, `created_by`)
+SELECT UUID(), MIN(TRIM(`author`)), 'migration'
+FROM `books`
+WHERE TRIM(`author`) <> ''
+GROUP BY TRIM(`author`);
+
+INSERT INTO `categories` (`id`, `name`, `created_by`)
+SELECT UUID(), MIN(TRIM(`category`)), 'migration'
+FROM `books`
+WHERE TRIM(`category`) <> ''
+GROUP BY TRIM(`category`);
+
+INSERT INTO `book_authors` (`book_id`, `author_id`)
+SELECT `b`.`id`, `a`.`id`
+FROM `books` `b`
+JOIN `authors` `a` ON `a`.`name` = TRIM(`b`.`author`);
+
+INSERT INTO `book_categories` (`book_id`, `category_id`)
+SELECT `b`.`id`, `c`.`id`
+FROM `books` `b`
+JOIN `categories` `c` ON `c`.`name` = TRIM(`b`.`category`);
+
+-- books spelling a name another way take the spelling of the new record
+UPDATE `books` `b`
+JOIN `book_authors` `ba` ON `ba`.`book_id` = `b`.`id`
+JOIN `authors` `a` ON `a`.`id` = `ba`.`author_id`
+SET `b`.`author` = `a`.`name`, `b`.`updated_by` = 'migration'
+WHERE BINARY `b`.`author` <> BINARY `a`.`name`;
+
+UPDATE `books` `b`
+JOIN `book_categories` `bc` ON `bc`.`book_id` = `b`.`id`
+JOIN `categories` `c` ON `c`.`id` = `bc`.`category_id`
+SET `b`.`category` = `c`.`name`, `b`.`updated_by` = 'migration'
+WHERE BINARY `b`.`category` <> BINARY `c`.`name`;
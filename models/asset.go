@@ -0,0 +1,10 @@
+package models
+
+// Asset is a file of a book kept in the configured storage. Checksum is the
+// hex SHA-256 of the content, computed when the file is uploaded.
+type Asset struct {
+	Key         *string `json:"-" gorm:"column:key"`
+	ContentType *string `json:"content_type" gorm:"column:content_type"`
+	Size        *int64  `json:"size" gorm:"column:size"`
+	Checksum    *string `json:"checksum" gorm:"column:checksum"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+func (Author) TableName() string {
+	return "authors"
+}
+
+// Author is a person credited on books. Books is the number of books in the
+// catalog by the author, set when authors are listed or fetched on their own.
+type Author struct {
+	ID        string     `json:"id" gorm:"column:id;primaryKey"`
+	Name      string     `json:"name" gorm:"column:name"`
+	Books     *int64     `json:"books,omitempty" gorm:"->;column:books"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at"`
+	CreatedBy string     `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt *time.Time `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy string     `json:"updated_by" gorm:"column:updated_by"`
+}
+
+func (BookAuthor) TableName() string {
+	return "book_authors"
+}
+
+// BookAuthor links a book to one of its authors, Position ordering them.
+type BookAuthor struct {
+	BookId   string `gorm:"column:book_id;primaryKey"`
+	AuthorId string `gorm:"column:author_id;primaryKey"`
+	Position int    `gorm:"column:position"`
+}
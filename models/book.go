@@ -10,17 +10,70 @@ func (Book) TableName() string {
 	return "books"
 }
 
+// ChangedAt is when the book last changed, its deletion included, as the
+// books repository orders harvests by.
+func (b Book) ChangedAt() time.Time {
+	switch {
+	case b.DeletedAt.Valid:
+		return b.DeletedAt.Time
+	case b.UpdatedAt != nil:
+		return *b.UpdatedAt
+	default:
+		return b.CreatedAt
+	}
+}
+
+// Book is a title in the catalog. Author holds the names of its authors joined
+// by commas and Category the name of its main category, both kept in step with
+// Authors and Categories, which are filled in when books are returned.
 type Book struct {
-	ID        string         `json:"id" gorm:"column:id;primaryKey"`
-	Title     string         `json:"title" gorm:"column:title"`
-	Author    string         `json:"author" gorm:"column:author"`
-	ISBN      string         `json:"isbn" gorm:"column:isbn"`
-	Category  string         `json:"category" gorm:"column:category"`
-	Quantity  int            `json:"quantity" gorm:"column:quantity"`
-	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
-	CreatedBy string         `json:"created_by" gorm:"column:created_by"`
-	UpdatedAt *time.Time     `json:"updated_at" gorm:"column:updated_at"`
-	UpdatedBy string         `json:"updated_by" gorm:"column:updated_by"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
-	DeletedBy string         `json:"-" gorm:"column:deleted_by"`
+	ID          string         `json:"id" gorm:"column:id;primaryKey"`
+	Title       string         `json:"title" gorm:"column:title"`
+	Author      string         `json:"author" gorm:"column:author"`
+	Authors     []Author       `json:"authors" gorm:"-"`
+	ISBN        string         `json:"isbn" gorm:"column:isbn"`
+	Category    string         `json:"category" gorm:"column:category"`
+	Categories  []Category     `json:"categories" gorm:"-"`
+	Quantity    int            `json:"quantity" gorm:"column:quantity"`
+	Cover       Asset          `json:"cover" gorm:"embedded;embeddedPrefix:cover_"`
+	EbookFormat *string        `json:"ebook_format" gorm:"column:ebook_format"`
+	Ebook       Asset          `json:"ebook" gorm:"embedded;embeddedPrefix:ebook_"`
+	License     License        `json:"license" gorm:"embedded;embeddedPrefix:license_"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"column:created_at"`
+	CreatedBy   string         `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt   *time.Time     `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy   string         `json:"updated_by" gorm:"column:updated_by"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
+	DeletedBy   string         `json:"-" gorm:"column:deleted_by"`
+}
+
+// Availability is the live lending state of a book. For a digital title the
+// copies are the seats of its license, and Available is nil when the license
+// has no seat limit.
+type Availability struct {
+	OnLoan             int64      `json:"on_loan"`
+	Available          *int64     `json:"available"`
+	OnHoldShelf        int64      `json:"on_hold_shelf"`
+	HoldQueue          int64      `json:"hold_queue"`
+	NextExpectedReturn *time.Time `json:"next_expected_return"`
+}
+
+type BookDetail struct {
+	Book
+	Availability Availability `json:"availability"`
+}
+
+// FacetCount is the number of books by an author or in a category; ID is the
+// author's or category's.
+type FacetCount struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// BookFacets counts the books matching a search per category and per author.
+// Each facet ignores its own filter, so the other values stay selectable.
+type BookFacets struct {
+	Categories []FacetCount `json:"categories"`
+	Authors    []FacetCount `json:"authors"`
 }
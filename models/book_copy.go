@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+func (BookCopy) TableName() string {
+	return "book_copies"
+}
+
+// BookCopy is a single physical or digital item of a book. Book.Quantity is the
+// number of copies that are available.
+type BookCopy struct {
+	Id        string     `json:"id" gorm:"column:id;primaryKey"`
+	BookId    string     `json:"book_id" gorm:"column:book_id"`
+	Barcode   string     `json:"barcode" gorm:"column:barcode"`
+	Condition string     `json:"condition" gorm:"column:condition"`
+	Location  string     `json:"location" gorm:"column:location"`
+	Status    string     `json:"status" gorm:"column:status"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at"`
+	CreatedBy string     `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt *time.Time `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy string     `json:"updated_by" gorm:"column:updated_by"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// ImportReport is the outcome of a catalog import, row by row. In a dry run
+// nothing is saved and the statuses tell what the import would do.
+type ImportReport struct {
+	DryRun  bool        `json:"dry_run"`
+	Total   int         `json:"total"`
+	Created int         `json:"created"`
+	Updated int         `json:"updated"`
+	Skipped int         `json:"skipped"`
+	Failed  int         `json:"failed"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+// ImportRow is the outcome of one line of the file; Row is its line number.
+type ImportRow struct {
+	Row    int           `json:"row"`
+	ISBN   string        `json:"isbn"`
+	Status string        `json:"status"`
+	BookId string        `json:"book_id,omitempty"`
+	Errors []ImportIssue `json:"errors,omitempty"`
+}
+
+// ImportIssue is why a row failed, with the column at fault when there is one.
+type ImportIssue struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestBookChangedAt(t *testing.T) {
+	created := time.Date(2024, 5, 1, 1, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	deleted := created.Add(2 * time.Hour)
+
+	tests := []struct {
+		name string
+		book Book
+		want time.Time
+	}{
+		{name: "never updated", book: Book{CreatedAt: created}, want: created},
+		{name: "updated", book: Book{CreatedAt: created, UpdatedAt: &updated}, want: updated},
+		{name: "deleted", book: Book{CreatedAt: created, UpdatedAt: &updated, DeletedAt: gorm.DeletedAt{Time: deleted, Valid: true}}, want: deleted},
+		{name: "deleted without update", book: Book{CreatedAt: created, DeletedAt: gorm.DeletedAt{Time: deleted, Valid: true}}, want: deleted},
+	}
+
+	for _, tt := range tests {
+		if got := tt.book.ChangedAt(); !got.Equal(tt.want) {
+			t.Errorf("%s: ChangedAt() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+func (Category) TableName() string {
+	return "categories"
+}
+
+// Category is a subject books are shelved under. Books is the number of books
+// in the catalog in the category, set when categories are listed or fetched on
+// their own.
+type Category struct {
+	ID        string     `json:"id" gorm:"column:id;primaryKey"`
+	Name      string     `json:"name" gorm:"column:name"`
+	Books     *int64     `json:"books,omitempty" gorm:"->;column:books"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at"`
+	CreatedBy string     `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt *time.Time `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy string     `json:"updated_by" gorm:"column:updated_by"`
+}
+
+func (BookCategory) TableName() string {
+	return "book_categories"
+}
+
+// BookCategory links a book to one of its categories. The category in the
+// first position is the book's main one.
+type BookCategory struct {
+	BookId     string `gorm:"column:book_id;primaryKey"`
+	CategoryId string `gorm:"column:category_id;primaryKey"`
+	Position   int    `gorm:"column:position"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// EbookLink is a signed link to the e-book file of an active loan.
+type EbookLink struct {
+	LendingId string    `json:"lending_id"`
+	Url       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
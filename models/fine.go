@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+func (Fine) TableName() string {
+	return "fines"
+}
+
+// Fine is a single entry in the fines ledger. Charges add to a member's
+// outstanding balance, payments and waivers take it down.
+type Fine struct {
+	Id          string    `json:"id" gorm:"column:id;primaryKey"`
+	UserId      string    `json:"user_id" gorm:"column:user_id"`
+	LendingId   *string   `json:"lending_id" gorm:"column:lending_id"`
+	Type        string    `json:"type" gorm:"column:type"`
+	Amount      Money     `json:"amount" gorm:"column:amount"`
+	DaysOverdue int       `json:"days_overdue" gorm:"column:days_overdue"`
+	Reason      string    `json:"reason" gorm:"column:reason"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+	CreatedBy   string    `json:"created_by" gorm:"column:created_by"`
+}
+
+type FineSummary struct {
+	UserId  string `json:"user_id"`
+	Balance Money  `json:"balance"`
+	Ledger  []Fine `json:"ledger"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+func (Hold) TableName() string {
+	return "holds"
+}
+
+type Hold struct {
+	Id        string       `json:"id" gorm:"column:id;primaryKey"`
+	UserId    string       `json:"user_id" gorm:"column:user_id"`
+	BookId    string       `json:"book_id" gorm:"column:book_id"`
+	CopyId    *string      `json:"copy_id" gorm:"column:copy_id"`
+	Status    string       `json:"status" gorm:"column:status"`
+	Position  int64        `json:"position,omitempty" gorm:"-"`
+	ReadyAt   sql.NullTime `json:"ready_at" gorm:"column:ready_at"`
+	ExpiresAt sql.NullTime `json:"expires_at" gorm:"column:expires_at"`
+	CreatedAt time.Time    `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time    `json:"updated_at" gorm:"column:updated_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+func (LendingPolicy) TableName() string {
+	return "lending_policies"
+}
+
+// LendingPolicy is a lending rule for a role and a book category. An empty
+// Role or Category applies the rule to every role or category.
+type LendingPolicy struct {
+	Id                 string     `json:"id" gorm:"column:id;primaryKey"`
+	Name               string     `json:"name" gorm:"column:name"`
+	Role               string     `json:"role" gorm:"column:role"`
+	Category           string     `json:"category" gorm:"column:category"`
+	MaxConcurrentLoans *int       `json:"max_concurrent_loans" gorm:"column:max_concurrent_loans"`
+	WindowDays         *int       `json:"window_days" gorm:"column:window_days"`
+	WindowMaxBorrows   *int       `json:"window_max_borrows" gorm:"column:window_max_borrows"`
+	LoanPeriodDays     int        `json:"loan_period_days" gorm:"column:loan_period_days"`
+	MaxRenewals        int        `json:"max_renewals" gorm:"column:max_renewals"`
+	CreatedAt          time.Time  `json:"created_at" gorm:"column:created_at"`
+	CreatedBy          string     `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt          *time.Time `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy          string     `json:"updated_by" gorm:"column:updated_by"`
+}
@@ -10,12 +10,19 @@ func (LendingRecord) TableName() string {
 }
 
 type LendingRecord struct {
-	Id         string       `json:"id" gorm:"column:id;primaryKey"`
-	UserId     string       `json:"user_id" gorm:"column:user_id"`
-	BookId     string       `json:"book_id" gorm:"column:book_id"`
-	BorrowDate time.Time    `json:"borrow_date" gorm:"column:borrow_date"`
-	ReturnDate sql.NullTime `json:"return_date" gorm:"column:return_date"`
-	Status     string       `json:"status" gorm:"column:status"`
-	CreatedAt  time.Time    `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt  time.Time    `json:"updated_at" gorm:"column:updated_at"`
+	Id           string       `json:"id" gorm:"column:id;primaryKey"`
+	UserId       string       `json:"user_id" gorm:"column:user_id"`
+	BookId       string       `json:"book_id" gorm:"column:book_id"`
+	CopyId       *string      `json:"copy_id" gorm:"column:copy_id"`
+	IsDigital    bool         `json:"is_digital" gorm:"column:is_digital"`
+	BorrowDate   time.Time    `json:"borrow_date" gorm:"column:borrow_date"`
+	DueDate      time.Time    `json:"due_date" gorm:"column:due_date"`
+	ReturnDate   sql.NullTime `json:"return_date" gorm:"column:return_date"`
+	Status       string       `json:"status" gorm:"column:status"`
+	RenewalCount int          `json:"renewal_count" gorm:"column:renewal_count"`
+	CreatedAt    time.Time    `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt    time.Time    `json:"updated_at" gorm:"column:updated_at"`
+
+	Book *Book     `json:"book,omitempty" gorm:"foreignKey:BookId"`
+	Copy *BookCopy `json:"copy,omitempty" gorm:"foreignKey:CopyId"`
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// License is the publisher license of a digital title. A nil Seats allows any
+// number of simultaneous loans, a nil MaxCheckouts any number of loans and a
+// nil ExpiresAt a perpetual license.
+type License struct {
+	Type         *string    `json:"type" gorm:"column:type"`
+	Seats        *int       `json:"seats" gorm:"column:seats"`
+	MaxCheckouts *int       `json:"max_checkouts" gorm:"column:max_checkouts"`
+	Checkouts    int        `json:"checkouts" gorm:"column:checkouts"`
+	ExpiresAt    *time.Time `json:"expires_at" gorm:"column:expires_at"`
+}
+
+// LicenseReport is a digital title whose license is close to running out,
+// either by date or by checkouts.
+type LicenseReport struct {
+	Book               Book  `json:"book"`
+	ActiveLoans        int64 `json:"active_loans"`
+	RemainingCheckouts *int  `json:"remaining_checkouts"`
+	DaysLeft           *int  `json:"days_left"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidMoney = errors.New("amount must be a number with at most 2 decimals")
+
+// Money is an amount in cents. It is read from and written to DECIMAL(12, 2)
+// columns and JSON numbers with two decimals exactly, where float64 would
+// round, e.g. 0.1 + 0.2.
+type Money int64
+
+// ParseMoney reads an amount such as "12", "12.5" or "-12.50".
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	sign := Money(1)
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		sign, s = -1, rest
+	}
+
+	units, cents, _ := strings.Cut(s, ".")
+	if units == "" || len(cents) > 2 || strings.ContainsAny(units+cents, "+-") {
+		return 0, ErrInvalidMoney
+	}
+	cents += strings.Repeat("0", 2-len(cents))
+
+	value, err := strconv.ParseInt(units+cents, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidMoney
+	}
+
+	return sign * Money(value), nil
+}
+
+// String writes the amount with two decimals, e.g. "12.50".
+func (m Money) String() string {
+	sign, value := "", int64(m)
+	if value < 0 {
+		sign, value = "-", -value
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	value, err := ParseMoney(string(data))
+	if err != nil {
+		return err
+	}
+	*m = value
+	return nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	var (
+		value Money
+		err   error
+	)
+	switch src := src.(type) {
+	case []byte:
+		value, err = ParseMoney(string(src))
+	case string:
+		value, err = ParseMoney(src)
+	case int64:
+		value = Money(src * 100)
+	case nil:
+		value = 0
+	default:
+		err = fmt.Errorf("cannot scan %T into Money", src)
+	}
+	if err != nil {
+		return err
+	}
+
+	*m = value
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "12", want: 1200},
+		{in: "12.5", want: 1250},
+		{in: "12.50", want: 1250},
+		{in: "0.01", want: 1},
+		{in: "-3.20", want: -320},
+		{in: " 7 ", want: 700},
+		{in: "12.505", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "+5", wantErr: true},
+		{in: "--5", wantErr: true},
+		{in: ".5", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMoney(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := map[Money]string{0: "0.00", 1: "0.01", 1250: "12.50", -5: "-0.05", -320: "-3.20"}
+	for in, want := range tests {
+		if got := in.String(); got != want {
+			t.Errorf("Money(%d).String() = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var payment struct {
+		Amount Money `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 0.3}`), &payment); err != nil {
+		t.Fatal(err)
+	}
+	if payment.Amount != 30 {
+		t.Fatalf("Amount = %d, want 30", payment.Amount)
+	}
+
+	out, err := json.Marshal(payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"amount":0.30}` {
+		t.Errorf("Marshal = %s", out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": 0.333}`), &payment); err == nil {
+		t.Error("Unmarshal of 0.333 should fail")
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want Money
+	}{
+		{src: []byte("1234.50"), want: 123450},
+		{src: "-0.10", want: -10},
+		{src: int64(3), want: 300},
+		{src: nil, want: 0},
+	}
+
+	for _, tt := range tests {
+		var got Money
+		if err := got.Scan(tt.src); err != nil {
+			t.Errorf("Scan(%v) error = %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Scan(%v) = %d, want %d", tt.src, got, tt.want)
+		}
+	}
+
+	value, err := Money(123450).Value()
+	if err != nil || value != "1234.50" {
+		t.Errorf("Value() = %v, %v", value, err)
+	}
+}
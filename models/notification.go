@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// Notification is a message waiting in the outbox to be sent to a member.
+// DueAt is the due date of the loan, or the pickup deadline of a ready hold.
+type Notification struct {
+	Id          string     `json:"id" gorm:"column:id;primaryKey"`
+	UserId      string     `json:"user_id" gorm:"column:user_id"`
+	BookId      string     `json:"book_id" gorm:"column:book_id"`
+	Type        string     `json:"type" gorm:"column:type"`
+	ReferenceId string     `json:"reference_id" gorm:"column:reference_id"`
+	DueAt       time.Time  `json:"due_at" gorm:"column:due_at"`
+	DedupKey    string     `json:"-" gorm:"column:dedup_key"`
+	Status      string     `json:"status" gorm:"column:status"`
+	Attempts    int        `json:"attempts" gorm:"column:attempts"`
+	LastError   *string    `json:"last_error" gorm:"column:last_error"`
+	SentAt      *time.Time `json:"sent_at" gorm:"column:sent_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   *time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// NotificationPreference is the language and the messages a member wants to get.
+type NotificationPreference struct {
+	UserId    string     `json:"user_id" gorm:"column:user_id;primaryKey"`
+	Locale    string     `json:"locale" gorm:"column:locale"`
+	DueSoon   bool       `json:"due_soon" gorm:"column:due_soon"`
+	Overdue   bool       `json:"overdue" gorm:"column:overdue"`
+	HoldReady bool       `json:"hold_ready" gorm:"column:hold_ready"`
+	UpdatedAt *time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// Message is a rendered notification ready to hand to a sender.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
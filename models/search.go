@@ -0,0 +1,18 @@
+package models
+
+// SearchHit is a book id found in the search index, with its score and the
+// matching parts of each field highlighted in <mark> tags.
+type SearchHit struct {
+	Id         string              `json:"id"`
+	Title      string              `json:"title"`
+	Author     string              `json:"author"`
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+// BookHit is a search result: the current book and how it matched.
+type BookHit struct {
+	Book       Book                `json:"book"`
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
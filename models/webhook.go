@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookSubscription is a partner endpoint that gets the listed events. The
+// secret signs every delivery and is only shown when the subscription is created.
+type WebhookSubscription struct {
+	Id          string     `json:"id" gorm:"column:id;primaryKey"`
+	Url         string     `json:"url" gorm:"column:url"`
+	Description string     `json:"description" gorm:"column:description"`
+	Events      []string   `json:"events" gorm:"column:events;serializer:json"`
+	Secret      string     `json:"secret,omitempty" gorm:"column:secret"`
+	IsActive    bool       `json:"is_active" gorm:"column:is_active"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
+	CreatedBy   string     `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt   *time.Time `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy   string     `json:"updated_by" gorm:"column:updated_by"`
+}
+
+func (WebhookEvent) TableName() string {
+	return "webhook_events"
+}
+
+// WebhookEvent is written to the outbox in the transaction that caused it.
+// Payload is the exact request body sent to subscribers.
+type WebhookEvent struct {
+	Id           string     `json:"id" gorm:"column:id;primaryKey"`
+	Type         string     `json:"type" gorm:"column:type"`
+	Payload      string     `json:"-" gorm:"column:payload"`
+	DispatchedAt *time.Time `json:"dispatched_at" gorm:"column:dispatched_at"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDelivery is one event on its way to one subscription.
+type WebhookDelivery struct {
+	Id             string     `json:"id" gorm:"column:id;primaryKey"`
+	SubscriptionId string     `json:"subscription_id" gorm:"column:subscription_id"`
+	EventId        string     `json:"event_id" gorm:"column:event_id"`
+	Status         string     `json:"status" gorm:"column:status"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"column:next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at" gorm:"column:delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt      *time.Time `json:"updated_at" gorm:"column:updated_at"`
+
+	Event        *WebhookEvent            `json:"event,omitempty" gorm:"foreignKey:EventId"`
+	Subscription *WebhookSubscription     `json:"-" gorm:"foreignKey:SubscriptionId"`
+	AttemptLog   []WebhookDeliveryAttempt `json:"attempt_log,omitempty" gorm:"foreignKey:DeliveryId"`
+}
+
+func (WebhookDeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+// WebhookDeliveryAttempt logs a single HTTP request of a delivery.
+type WebhookDeliveryAttempt struct {
+	Id           string    `json:"id" gorm:"column:id;primaryKey"`
+	DeliveryId   string    `json:"delivery_id" gorm:"column:delivery_id"`
+	StatusCode   *int      `json:"status_code" gorm:"column:status_code"`
+	Error        *string   `json:"error" gorm:"column:error"`
+	ResponseBody *string   `json:"response_body" gorm:"column:response_body"`
+	DurationMs   int64     `json:"duration_ms" gorm:"column:duration_ms"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+}
@@ -0,0 +1,22 @@
+package notifier
+
+import (
+	"context"
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"fmt"
+)
+
+type logNotifier struct{}
+
+// NewLogNotifier writes notifications to the application log instead of
+// sending them, for development.
+func NewLogNotifier() interfaces.Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Send(_ context.Context, msg models.Message) error {
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("[Notifier]; To: %s; Subject: %s;\n%s", msg.To, msg.Subject, msg.Body))
+	return nil
+}
@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/utils"
+	"fmt"
+)
+
+const (
+	DriverLog  = "log"
+	DriverSMTP = "smtp"
+)
+
+// NewNotifier builds the sender picked by NOTIFIER_DRIVER.
+func NewNotifier() (interfaces.Notifier, error) {
+	switch driver := utils.GetEnv("NOTIFIER_DRIVER", DriverLog).(string); driver {
+	case DriverLog:
+		return NewLogNotifier(), nil
+	case DriverSMTP:
+		return NewSMTPNotifier(SMTPConfig{
+			Host:     utils.GetEnv("SMTP_HOST", "localhost").(string),
+			Port:     utils.GetEnv("SMTP_PORT", 1025).(int),
+			Username: utils.GetEnv("SMTP_USERNAME", "").(string),
+			Password: utils.GetEnv("SMTP_PASSWORD", "").(string),
+			From:     utils.GetEnv("SMTP_FROM", "library@localhost").(string),
+		})
+	default:
+		return nil, fmt.Errorf("unknown notifier driver: %s", driver)
+	}
+}
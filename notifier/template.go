@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+const defaultLocale = "en"
+
+var (
+	monthsID = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+	daysID   = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+)
+
+// TemplateData is what the notification templates get to fill in.
+type TemplateData struct {
+	Name      string
+	BookTitle string
+	DueAt     time.Time
+	Library   string
+}
+
+// Render fills in the subject and body of a notification type in the member's
+// language. Unknown languages fall back to English.
+func Render(locale, notificationType string, data TemplateData) (subject, body string, err error) {
+	if _, err := templateFS.Open(templatePath(locale, notificationType)); err != nil {
+		locale = defaultLocale
+	}
+
+	tmpl, err := template.New(notificationType).
+		Funcs(template.FuncMap{"date": dateFormatter(locale)}).
+		ParseFS(templateFS, templatePath(locale, notificationType))
+	if err != nil {
+		return "", "", err
+	}
+
+	var subjectBuf, bodyBuf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subjectBuf, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.ExecuteTemplate(&bodyBuf, "body", data); err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(subjectBuf.String()), strings.TrimSpace(bodyBuf.String()) + "\n", nil
+}
+
+func templatePath(locale, notificationType string) string {
+	return fmt.Sprintf("templates/%s/%s.tmpl", locale, notificationType)
+}
+
+// dateFormatter writes dates the way readers of the locale expect them, in
+// the server's time zone.
+func dateFormatter(locale string) func(t time.Time) string {
+	if locale == "id" {
+		return func(t time.Time) string {
+			t = t.Local()
+			return fmt.Sprintf("%s, %d %s %d pukul %s", daysID[t.Weekday()], t.Day(), monthsID[t.Month()-1], t.Year(), t.Format("15.04"))
+		}
+	}
+
+	return func(t time.Time) string {
+		return t.Local().Format("Monday, 2 January 2006 at 15:04")
+	}
+}
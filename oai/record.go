@@ -0,0 +1,116 @@
+package oai
+
+import (
+	"digital-book-lending/models"
+	"encoding/xml"
+	"time"
+)
+
+const (
+	PrefixDC    = "oai_dc"
+	SchemaDC    = "http://www.openarchives.org/OAI/2.0/oai_dc.xsd"
+	NamespaceDC = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+
+	StatusDeleted = "deleted"
+)
+
+type Header struct {
+	Status     string `xml:"status,attr,omitempty"`
+	Identifier string `xml:"identifier"`
+	Datestamp  string `xml:"datestamp"`
+}
+
+// Record is a header and, unless the record is deleted, its metadata.
+type Record struct {
+	Header   Header    `xml:"header"`
+	Metadata *Metadata `xml:"metadata,omitempty"`
+}
+
+type Metadata struct {
+	DC DublinCore `xml:"oai_dc:dc"`
+}
+
+// DublinCore is the unqualified Dublin Core every OAI-PMH repository serves.
+type DublinCore struct {
+	XmlnsOaiDC     string   `xml:"xmlns:oai_dc,attr"`
+	XmlnsDC        string   `xml:"xmlns:dc,attr"`
+	XmlnsXsi       string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	Title          []string `xml:"dc:title"`
+	Creator        []string `xml:"dc:creator"`
+	Subject        []string `xml:"dc:subject"`
+	Type           []string `xml:"dc:type"`
+	Format         []string `xml:"dc:format"`
+	Identifier     []string `xml:"dc:identifier"`
+}
+
+// Identifier is the OAI identifier of a book in the repository.
+func Identifier(repository, id string) string {
+	return "oai:" + repository + ":" + id
+}
+
+func FormatDatestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func HeaderOf(repository string, book models.Book) Header {
+	header := Header{
+		Identifier: Identifier(repository, book.ID),
+		Datestamp:  FormatDatestamp(book.ChangedAt()),
+	}
+	if book.DeletedAt.Valid {
+		header.Status = StatusDeleted
+	}
+
+	return header
+}
+
+// FromBook describes a book in Dublin Core: the title, the authors as
+// creators, the categories as subjects and the ISBN as identifier. A deleted
+// book has only its header.
+func FromBook(repository string, book models.Book) Record {
+	record := Record{Header: HeaderOf(repository, book)}
+	if book.DeletedAt.Valid {
+		return record
+	}
+
+	dc := DublinCore{
+		XmlnsOaiDC:     NamespaceDC,
+		XmlnsDC:        "http://purl.org/dc/elements/1.1/",
+		XmlnsXsi:       xsiNamespace,
+		SchemaLocation: NamespaceDC + " " + SchemaDC,
+		Title:          []string{book.Title},
+		Type:           []string{"Text"},
+		Identifier:     []string{"urn:isbn:" + book.ISBN},
+	}
+	for _, author := range book.Authors {
+		dc.Creator = append(dc.Creator, author.Name)
+	}
+	if len(book.Authors) == 0 {
+		dc.Creator = []string{book.Author}
+	}
+	for _, category := range book.Categories {
+		dc.Subject = append(dc.Subject, category.Name)
+	}
+	if len(book.Categories) == 0 {
+		dc.Subject = []string{book.Category}
+	}
+	if book.EbookFormat != nil && book.Ebook.ContentType != nil {
+		dc.Format = []string{*book.Ebook.ContentType}
+	}
+	record.Metadata = &Metadata{DC: dc}
+
+	return record
+}
+
+// MetadataFormat is a format records can be disseminated in.
+type MetadataFormat struct {
+	XMLName           xml.Name `xml:"metadataFormat"`
+	MetadataPrefix    string   `xml:"metadataPrefix"`
+	Schema            string   `xml:"schema"`
+	MetadataNamespace string   `xml:"metadataNamespace"`
+}
+
+var Formats = []MetadataFormat{
+	{MetadataPrefix: PrefixDC, Schema: SchemaDC, MetadataNamespace: NamespaceDC},
+}
@@ -0,0 +1,160 @@
+package oai
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	namespace    = "http://www.openarchives.org/OAI/2.0/"
+	xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+	ProtocolVersion = "2.0"
+	Granularity     = "YYYY-MM-DDThh:mm:ssZ"
+	// DeletedPersistent says deleted records are kept for good, as books are
+	// only ever soft deleted.
+	DeletedPersistent = "persistent"
+
+	VerbIdentify            = "Identify"
+	VerbListMetadataFormats = "ListMetadataFormats"
+	VerbListSets            = "ListSets"
+	VerbListIdentifiers     = "ListIdentifiers"
+	VerbListRecords         = "ListRecords"
+	VerbGetRecord           = "GetRecord"
+
+	ErrBadArgument             = "badArgument"
+	ErrBadResumptionToken      = "badResumptionToken"
+	ErrBadVerb                 = "badVerb"
+	ErrCannotDisseminateFormat = "cannotDisseminateFormat"
+	ErrIdDoesNotExist          = "idDoesNotExist"
+	ErrNoRecordsMatch          = "noRecordsMatch"
+	ErrNoSetHierarchy          = "noSetHierarchy"
+)
+
+// Response is an OAI-PMH response: the request it answers and either errors
+// or the result of its verb.
+type Response struct {
+	XMLName             xml.Name             `xml:"OAI-PMH"`
+	Xmlns               string               `xml:"xmlns,attr"`
+	XmlnsXsi            string               `xml:"xmlns:xsi,attr"`
+	SchemaLocation      string               `xml:"xsi:schemaLocation,attr"`
+	ResponseDate        string               `xml:"responseDate"`
+	Request             Request              `xml:"request"`
+	Errors              []Error              `xml:"error"`
+	Identify            *Identify            `xml:"Identify,omitempty"`
+	ListMetadataFormats *ListMetadataFormats `xml:"ListMetadataFormats,omitempty"`
+	GetRecord           *GetRecord           `xml:"GetRecord,omitempty"`
+	ListIdentifiers     *ListIdentifiers     `xml:"ListIdentifiers,omitempty"`
+	ListRecords         *ListRecords         `xml:"ListRecords,omitempty"`
+}
+
+// Request echoes the arguments of a request; it is left empty but for the
+// base URL when the verb or the arguments were not understood.
+type Request struct {
+	Verb            string `xml:"verb,attr,omitempty"`
+	Identifier      string `xml:"identifier,attr,omitempty"`
+	MetadataPrefix  string `xml:"metadataPrefix,attr,omitempty"`
+	From            string `xml:"from,attr,omitempty"`
+	Until           string `xml:"until,attr,omitempty"`
+	Set             string `xml:"set,attr,omitempty"`
+	ResumptionToken string `xml:"resumptionToken,attr,omitempty"`
+	BaseURL         string `xml:",chardata"`
+}
+
+type Error struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+type Identify struct {
+	RepositoryName    string      `xml:"repositoryName"`
+	BaseURL           string      `xml:"baseURL"`
+	ProtocolVersion   string      `xml:"protocolVersion"`
+	AdminEmail        string      `xml:"adminEmail"`
+	EarliestDatestamp string      `xml:"earliestDatestamp"`
+	DeletedRecord     string      `xml:"deletedRecord"`
+	Granularity       string      `xml:"granularity"`
+	Description       Description `xml:"description"`
+}
+
+// Description says how the repository makes identifiers, following the
+// oai-identifier scheme.
+type Description struct {
+	OaiIdentifier struct {
+		Xmlns                string `xml:"xmlns,attr"`
+		XmlnsXsi             string `xml:"xmlns:xsi,attr"`
+		SchemaLocation       string `xml:"xsi:schemaLocation,attr"`
+		Scheme               string `xml:"scheme"`
+		RepositoryIdentifier string `xml:"repositoryIdentifier"`
+		Delimiter            string `xml:"delimiter"`
+		SampleIdentifier     string `xml:"sampleIdentifier"`
+	} `xml:"oai-identifier"`
+}
+
+type ListMetadataFormats struct {
+	Formats []MetadataFormat
+}
+
+type GetRecord struct {
+	Record Record `xml:"record"`
+}
+
+type ListIdentifiers struct {
+	Headers         []Header         `xml:"header"`
+	ResumptionToken *ResumptionToken `xml:"resumptionToken,omitempty"`
+}
+
+type ListRecords struct {
+	Records         []Record         `xml:"record"`
+	ResumptionToken *ResumptionToken `xml:"resumptionToken,omitempty"`
+}
+
+// ResumptionToken continues an incomplete list. It is empty in the last part
+// of a list that was resumed.
+type ResumptionToken struct {
+	CompleteListSize int64  `xml:"completeListSize,attr"`
+	Cursor           int    `xml:"cursor,attr"`
+	Value            string `xml:",chardata"`
+}
+
+func NewResponse(baseURL string) Response {
+	return Response{
+		Xmlns:          namespace,
+		XmlnsXsi:       xsiNamespace,
+		SchemaLocation: namespace + " http://www.openarchives.org/OAI/2.0/OAI-PMH.xsd",
+		ResponseDate:   FormatDatestamp(time.Now()),
+		Request:        Request{BaseURL: baseURL},
+	}
+}
+
+func NewIdentify(name, baseURL, adminEmail, repository string, earliest time.Time) *Identify {
+	identify := &Identify{
+		RepositoryName:    name,
+		BaseURL:           baseURL,
+		ProtocolVersion:   ProtocolVersion,
+		AdminEmail:        adminEmail,
+		EarliestDatestamp: FormatDatestamp(earliest),
+		DeletedRecord:     DeletedPersistent,
+		Granularity:       Granularity,
+	}
+
+	description := &identify.Description.OaiIdentifier
+	description.Xmlns = "http://www.openarchives.org/OAI/2.0/oai-identifier"
+	description.XmlnsXsi = xsiNamespace
+	description.SchemaLocation = description.Xmlns + " http://www.openarchives.org/OAI/2.0/oai-identifier.xsd"
+	description.Scheme = "oai"
+	description.RepositoryIdentifier = repository
+	description.Delimiter = ":"
+	description.SampleIdentifier = Identifier(repository, "00000000-0000-0000-0000-000000000000")
+
+	return identify
+}
+
+func (r Response) Marshal() ([]byte, error) {
+	out, err := xml.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
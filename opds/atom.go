@@ -0,0 +1,156 @@
+package opds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+const (
+	atomNamespace       = "http://www.w3.org/2005/Atom"
+	dcNamespace         = "http://purl.org/dc/terms/"
+	opdsNamespace       = "http://opds-spec.org/2010/catalog"
+	openSearchNamespace = "http://a9.com/-/spec/opensearch/1.1/"
+)
+
+type atomFeed struct {
+	XMLName      xml.Name    `xml:"feed"`
+	Xmlns        string      `xml:"xmlns,attr"`
+	XmlnsDC      string      `xml:"xmlns:dc,attr"`
+	XmlnsOPDS    string      `xml:"xmlns:opds,attr"`
+	XmlnsSearch  string      `xml:"xmlns:opensearch,attr"`
+	ID           string      `xml:"id"`
+	Title        string      `xml:"title"`
+	Updated      string      `xml:"updated"`
+	TotalResults *int64      `xml:"opensearch:totalResults,omitempty"`
+	ItemsPerPage *int        `xml:"opensearch:itemsPerPage,omitempty"`
+	StartIndex   *int        `xml:"opensearch:startIndex,omitempty"`
+	Links        []atomLink  `xml:"link"`
+	Entries      []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel          string            `xml:"rel,attr,omitempty"`
+	Href         string            `xml:"href,attr"`
+	Type         string            `xml:"type,attr,omitempty"`
+	Title        string            `xml:"title,attr,omitempty"`
+	Availability *atomAvailability `xml:"opds:availability,omitempty"`
+}
+
+type atomAvailability struct {
+	Status string `xml:"status,attr"`
+	Until  string `xml:"until,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Authors    []atomAuthor   `xml:"author"`
+	Identifier string         `xml:"dc:identifier,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Content    *atomContent   `xml:"content,omitempty"`
+	Links      []atomLink     `xml:"link"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// Atom writes the feed as an OPDS 1.2 Atom document.
+func (f Feed) Atom() ([]byte, error) {
+	feed := atomFeed{
+		Xmlns:       atomNamespace,
+		XmlnsDC:     dcNamespace,
+		XmlnsOPDS:   opdsNamespace,
+		XmlnsSearch: openSearchNamespace,
+		ID:          f.ID,
+		Title:       f.Title,
+		Updated:     atomTime(f.Updated),
+		Links:       atomLinks(f.Links),
+	}
+	if f.PerPage > 0 {
+		start := (f.Page-1)*f.PerPage + 1
+		feed.TotalResults, feed.ItemsPerPage, feed.StartIndex = &f.Total, &f.PerPage, &start
+	}
+
+	for _, navigation := range f.Navigation {
+		entry := atomEntry{
+			ID:      navigation.Href,
+			Title:   navigation.Title,
+			Updated: atomTime(f.Updated),
+			Links:   []atomLink{{Rel: "subsection", Href: navigation.Href, Type: AtomType(navigation.Kind)}},
+		}
+		if navigation.Count > 0 {
+			entry.Content = &atomContent{Type: "text", Text: fmt.Sprintf("%d books", navigation.Count)}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	for _, publication := range f.Publications {
+		entry := atomEntry{
+			ID:      publication.ID,
+			Title:   publication.Title,
+			Updated: atomTime(publication.Updated),
+			Links:   atomLinks(publication.Links),
+		}
+		for _, author := range publication.Authors {
+			entry.Authors = append(entry.Authors, atomAuthor{Name: author})
+		}
+		if publication.ISBN != "" {
+			entry.Identifier = "urn:isbn:" + publication.ISBN
+		}
+		for _, category := range publication.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category, Label: category})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
+
+// AtomType is the media type of an OPDS 1.2 feed of the kind.
+func AtomType(kind string) string {
+	return "application/atom+xml;profile=opds-catalog;kind=" + kind
+}
+
+func atomType(link Link) string {
+	if link.Kind != "" {
+		return AtomType(link.Kind)
+	}
+	return link.Type
+}
+
+func atomLinks(links []Link) []atomLink {
+	ret := make([]atomLink, 0, len(links))
+	for _, link := range links {
+		atom := atomLink{Rel: link.Rel, Href: link.Href, Type: atomType(link), Title: link.Title}
+		if link.Availability != nil {
+			atom.Availability = &atomAvailability{Status: link.Availability.Status}
+			if link.Availability.Until != nil {
+				atom.Availability.Until = atomTime(*link.Availability.Until)
+			}
+		}
+		ret = append(ret, atom)
+	}
+	return ret
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
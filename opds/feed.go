@@ -0,0 +1,131 @@
+package opds
+
+import (
+	"digital-book-lending/models"
+	"time"
+)
+
+const (
+	Version1 = "1.2"
+	Version2 = "2.0"
+
+	KindNavigation  = "navigation"
+	KindAcquisition = "acquisition"
+
+	TypeJSON       = "application/opds+json"
+	TypeOpenSearch = "application/opensearchdescription+xml"
+
+	RelAcquisition = "http://opds-spec.org/acquisition"
+	RelBorrow      = "http://opds-spec.org/acquisition/borrow"
+	RelImage       = "http://opds-spec.org/image"
+	RelThumbnail   = "http://opds-spec.org/image/thumbnail"
+	// RelRevoke ends a loan; OPDS has no rel of its own for it, so this is the
+	// one Library Simplified clients use.
+	RelRevoke = "http://librarysimplified.org/terms/rel/revoke"
+
+	AvailabilityAvailable   = "available"
+	AvailabilityUnavailable = "unavailable"
+	AvailabilityReady       = "ready"
+)
+
+// Feed is one page of the catalog, written as OPDS 1.2 by Atom or as OPDS 2.0
+// by JSON. A navigation feed lists other feeds, an acquisition feed lists
+// books.
+type Feed struct {
+	ID           string
+	Title        string
+	Kind         string
+	Updated      time.Time
+	Links        []Link
+	Navigation   []Navigation
+	Publications []Publication
+
+	// paging of the entries, left zero on a feed that is not paged
+	Total   int64
+	Page    int
+	PerPage int
+}
+
+// Link points at a feed of the given Kind or at anything else of the given
+// Type. A Templated href is a URI template, which only OPDS 2.0 supports.
+type Link struct {
+	Rel          string
+	Href         string
+	Type         string
+	Kind         string
+	Title        string
+	Templated    bool
+	Availability *Availability
+}
+
+// Availability tells whether an acquisition link can be followed now; Until
+// is when a loan that is Ready runs out.
+type Availability struct {
+	Status string
+	Until  *time.Time
+}
+
+// Navigation is an entry of a navigation feed; Count is the number of books
+// behind it, zero when it is not worth counting.
+type Navigation struct {
+	Title string
+	Href  string
+	Kind  string
+	Count int64
+}
+
+type Publication struct {
+	ID         string
+	Title      string
+	Authors    []string
+	ISBN       string
+	Categories []string
+	Updated    time.Time
+	Links      []Link
+}
+
+// FromBook describes a book as a publication without any links.
+func FromBook(book models.Book) Publication {
+	updated := book.CreatedAt
+	if book.UpdatedAt != nil {
+		updated = *book.UpdatedAt
+	}
+
+	publication := Publication{
+		ID:      "urn:uuid:" + book.ID,
+		Title:   book.Title,
+		ISBN:    book.ISBN,
+		Updated: updated,
+	}
+	for _, author := range book.Authors {
+		publication.Authors = append(publication.Authors, author.Name)
+	}
+	if len(book.Authors) == 0 && book.Author != "" {
+		publication.Authors = []string{book.Author}
+	}
+	for _, category := range book.Categories {
+		publication.Categories = append(publication.Categories, category.Name)
+	}
+	if len(book.Categories) == 0 && book.Category != "" {
+		publication.Categories = []string{book.Category}
+	}
+
+	return publication
+}
+
+// PageLinks are the first, previous, next and last links of a paged feed;
+// href builds the link to a page.
+func PageLinks(page, perPage int, total int64, kind string, href func(page int) string) []Link {
+	last := max(int((total+int64(perPage)-1)/int64(perPage)), 1)
+
+	links := []Link{{Rel: "first", Href: href(1), Kind: kind}}
+	if page > 1 {
+		links = append(links, Link{Rel: "previous", Href: href(min(page-1, last)), Kind: kind})
+	}
+	if page < last {
+		links = append(links, Link{Rel: "next", Href: href(page + 1), Kind: kind})
+	}
+	links = append(links, Link{Rel: "last", Href: href(last), Kind: kind})
+
+	return links
+}
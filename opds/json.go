@@ -0,0 +1,144 @@
+package opds
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type jsonFeed struct {
+	Metadata jsonMetadata `json:"metadata"`
+	Links    []jsonLink   `json:"links"`
+	// pointers, as a feed must have the collection of its kind even when it is
+	// empty and must not have the other one
+	Navigation   *[]jsonLink        `json:"navigation,omitempty"`
+	Publications *[]jsonPublication `json:"publications,omitempty"`
+}
+
+type jsonMetadata struct {
+	Title         string     `json:"title"`
+	Modified      *time.Time `json:"modified,omitempty"`
+	NumberOfItems *int64     `json:"numberOfItems,omitempty"`
+	ItemsPerPage  *int       `json:"itemsPerPage,omitempty"`
+	CurrentPage   *int       `json:"currentPage,omitempty"`
+}
+
+type jsonLink struct {
+	Rel        string          `json:"rel,omitempty"`
+	Href       string          `json:"href"`
+	Type       string          `json:"type,omitempty"`
+	Title      string          `json:"title,omitempty"`
+	Templated  bool            `json:"templated,omitempty"`
+	Properties *jsonProperties `json:"properties,omitempty"`
+}
+
+type jsonProperties struct {
+	NumberOfItems *int64            `json:"numberOfItems,omitempty"`
+	Availability  *jsonAvailability `json:"availability,omitempty"`
+}
+
+type jsonAvailability struct {
+	State string     `json:"state"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+type jsonPublication struct {
+	Metadata jsonPublicationMetadata `json:"metadata"`
+	Links    []jsonLink              `json:"links"`
+	Images   []jsonLink              `json:"images,omitempty"`
+}
+
+type jsonPublicationMetadata struct {
+	Type       string        `json:"@type"`
+	Identifier string        `json:"identifier"`
+	Title      string        `json:"title"`
+	Author     []jsonContrib `json:"author,omitempty"`
+	Subject    []jsonContrib `json:"subject,omitempty"`
+	Modified   time.Time     `json:"modified"`
+}
+
+type jsonContrib struct {
+	Name string `json:"name"`
+}
+
+// JSON writes the feed as an OPDS 2.0 JSON document.
+func (f Feed) JSON() ([]byte, error) {
+	feed := jsonFeed{
+		Metadata: jsonMetadata{Title: f.Title, Modified: &f.Updated},
+		Links:    jsonLinks(f.Links),
+	}
+	if f.PerPage > 0 {
+		feed.Metadata.NumberOfItems, feed.Metadata.ItemsPerPage, feed.Metadata.CurrentPage = &f.Total, &f.PerPage, &f.Page
+	}
+
+	if f.Kind == KindNavigation {
+		navigation := make([]jsonLink, 0, len(f.Navigation))
+		for _, entry := range f.Navigation {
+			link := jsonLink{Href: entry.Href, Type: TypeJSON, Title: entry.Title}
+			if entry.Count > 0 {
+				count := entry.Count
+				link.Properties = &jsonProperties{NumberOfItems: &count}
+			}
+			navigation = append(navigation, link)
+		}
+		feed.Navigation = &navigation
+	} else {
+		publications := make([]jsonPublication, 0, len(f.Publications))
+		for _, publication := range f.Publications {
+			publications = append(publications, jsonPublicationOf(publication))
+		}
+		feed.Publications = &publications
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
+
+// jsonPublicationOf moves the image link of a publication to its images, where
+// OPDS 2.0 keeps them. A thumbnail of the same image is left out.
+func jsonPublicationOf(publication Publication) jsonPublication {
+	ret := jsonPublication{
+		Metadata: jsonPublicationMetadata{
+			Type:       "http://schema.org/Book",
+			Identifier: publication.ID,
+			Title:      publication.Title,
+			Modified:   publication.Updated,
+		},
+		Links: []jsonLink{},
+	}
+	if publication.ISBN != "" {
+		ret.Metadata.Identifier = "urn:isbn:" + publication.ISBN
+	}
+	for _, author := range publication.Authors {
+		ret.Metadata.Author = append(ret.Metadata.Author, jsonContrib{Name: author})
+	}
+	for _, category := range publication.Categories {
+		ret.Metadata.Subject = append(ret.Metadata.Subject, jsonContrib{Name: category})
+	}
+
+	for _, link := range jsonLinks(publication.Links) {
+		switch link.Rel {
+		case RelImage:
+			link.Rel = ""
+			ret.Images = append(ret.Images, link)
+		case RelThumbnail:
+		default:
+			ret.Links = append(ret.Links, link)
+		}
+	}
+
+	return ret
+}
+
+func jsonLinks(links []Link) []jsonLink {
+	ret := make([]jsonLink, 0, len(links))
+	for _, link := range links {
+		out := jsonLink{Rel: link.Rel, Href: link.Href, Type: link.Type, Title: link.Title, Templated: link.Templated}
+		if link.Kind != "" {
+			out.Type = TypeJSON
+		}
+		if link.Availability != nil {
+			out.Properties = &jsonProperties{Availability: &jsonAvailability{State: link.Availability.Status, Until: link.Availability.Until}}
+		}
+		ret = append(ret, out)
+	}
+	return ret
+}
@@ -0,0 +1,35 @@
+package opds
+
+import "encoding/xml"
+
+type openSearchDescription struct {
+	XMLName       xml.Name        `xml:"OpenSearchDescription"`
+	Xmlns         string          `xml:"xmlns,attr"`
+	ShortName     string          `xml:"ShortName"`
+	Description   string          `xml:"Description"`
+	InputEncoding string          `xml:"InputEncoding"`
+	URLs          []openSearchURL `xml:"Url"`
+}
+
+type openSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
+
+// OpenSearch describes how an OPDS 1.2 client searches the catalog. The
+// template is the acquisition feed URL with {searchTerms} where the query
+// goes.
+func OpenSearch(name, description, template string) ([]byte, error) {
+	out, err := xml.MarshalIndent(openSearchDescription{
+		Xmlns:         openSearchNamespace,
+		ShortName:     name,
+		Description:   description,
+		InputEncoding: "UTF-8",
+		URLs:          []openSearchURL{{Type: AtomType(KindAcquisition), Template: template}},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
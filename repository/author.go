@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repoAuthor struct {
+	DB *gorm.DB
+}
+
+func NewAuthorRepo(db *gorm.DB) interfaces.Author {
+	return &repoAuthor{DB: db}
+}
+
+func (r *repoAuthor) Store(tx *gorm.DB, m models.Author) error {
+	if err := tx.Create(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.Store; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repoAuthor) Update(tx *gorm.DB, m models.Author, data interface{}) (int64, error) {
+	res := tx.Table(m.TableName()).Where("id = ?", m.ID).Updates(data)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.Update; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// Delete removes an author along with its links to books.
+func (r *repoAuthor) Delete(tx *gorm.DB, m models.Author) (int64, error) {
+	res := tx.Where("id = ?", m.ID).Delete(&m)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.Delete; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// Fetch lists the authors whose name contains the search, alphabetically, with
+// their number of books.
+func (r *repoAuthor) Fetch(page, limit int, search string) (ret []models.Author, totalData int64, err error) {
+	query := r.DB.Model(&models.Author{})
+	if search = strings.TrimSpace(search); search != "" {
+		query = query.Where("LOWER(name) LIKE LOWER(?)", "%"+search+"%")
+	}
+
+	if err = query.Count(&totalData).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.Fetch.Count; "+err.Error())
+		return nil, 0, err
+	}
+
+	if limit > 0 {
+		query = query.Offset((page - 1) * limit).Limit(limit)
+	}
+	if err = r.withBooks(query).Order("name asc").Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.Fetch; "+err.Error())
+		return nil, 0, err
+	}
+
+	return ret, totalData, nil
+}
+
+// GetById finds an author with their number of books.
+func (r *repoAuthor) GetById(id string) (ret models.Author, err error) {
+	err = r.withBooks(r.DB.Model(&models.Author{})).First(&ret, "id = ?", id).Error
+	return ret, err
+}
+
+func (r *repoAuthor) GetByIdForUpdate(tx *gorm.DB, id string) (ret models.Author, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ret, "id = ?", id).Error
+	return ret, err
+}
+
+// GetByName finds an author by name, ignoring case as the unique index does.
+func (r *repoAuthor) GetByName(tx *gorm.DB, name string) (ret models.Author, err error) {
+	err = tx.First(&ret, "name = ?", strings.TrimSpace(name)).Error
+	return ret, err
+}
+
+func (r *repoAuthor) FetchByIds(tx *gorm.DB, ids []string) (ret []models.Author, err error) {
+	if err = tx.Where("id IN ?", ids).Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.FetchByIds; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// FetchByBooks returns the authors of each of the books, in order.
+func (r *repoAuthor) FetchByBooks(tx *gorm.DB, bookIds []string) (map[string][]models.Author, error) {
+	var rows []struct {
+		BookId string
+		models.Author
+	}
+	err := tx.Table(models.BookAuthor{}.TableName()).
+		Select("book_authors.book_id, authors.*").
+		Joins("JOIN authors ON authors.id = book_authors.author_id").
+		Where("book_authors.book_id IN ?", bookIds).
+		Order("book_authors.position asc").
+		Scan(&rows).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.FetchByBooks; "+err.Error())
+		return nil, err
+	}
+
+	ret := make(map[string][]models.Author, len(bookIds))
+	for _, row := range rows {
+		ret[row.BookId] = append(ret[row.BookId], row.Author)
+	}
+
+	return ret, nil
+}
+
+// FetchBookIds lists the books by any of the authors, deleted ones included.
+func (r *repoAuthor) FetchBookIds(tx *gorm.DB, ids []string) (ret []string, err error) {
+	err = tx.Model(&models.BookAuthor{}).Distinct("book_id").Where("author_id IN ?", ids).Pluck("book_id", &ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.FetchBookIds; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// CountBooks counts the books in the catalog by the author.
+func (r *repoAuthor) CountBooks(tx *gorm.DB, id string) (total int64, err error) {
+	err = tx.Model(&models.BookAuthor{}).
+		Joins("JOIN books ON books.id = book_authors.book_id AND books.deleted_at IS NULL").
+		Where("book_authors.author_id = ?", id).
+		Count(&total).Error
+	return total, err
+}
+
+// LinkBook makes the authors, in order, the only authors of the book.
+func (r *repoAuthor) LinkBook(tx *gorm.DB, bookId string, ids []string) error {
+	if err := tx.Where("book_id = ?", bookId).Delete(&models.BookAuthor{}).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.LinkBook.Delete; "+err.Error())
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	links := make([]models.BookAuthor, len(ids))
+	for i, id := range ids {
+		links[i] = models.BookAuthor{BookId: bookId, AuthorId: id, Position: i + 1}
+	}
+	if err := tx.Create(&links).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.LinkBook.Create; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Merge moves the books of the source authors to the author and deletes the
+// sources. A book already by the author keeps it where it was in its list, and
+// a book by several of the sources keeps the first of them.
+func (r *repoAuthor) Merge(tx *gorm.DB, id string, sourceIds []string) error {
+	var links []models.BookAuthor
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("author_id IN ?", append([]string{id}, sourceIds...)).
+		Order("book_id").Order("position").
+		Find(&links).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.Merge.Find; "+err.Error())
+		return err
+	}
+
+	// a book keeps one link to the merged author: its link to the author, or
+	// else its first link to a source; the others would be duplicates
+	kept := map[string]string{}
+	for _, link := range links {
+		if link.AuthorId == id {
+			kept[link.BookId] = id
+		}
+	}
+	var duplicates [][]interface{}
+	for _, link := range links {
+		if _, ok := kept[link.BookId]; !ok {
+			kept[link.BookId] = link.AuthorId
+		} else if kept[link.BookId] != link.AuthorId {
+			duplicates = append(duplicates, []interface{}{link.BookId, link.AuthorId})
+		}
+	}
+	if len(duplicates) > 0 {
+		if err := tx.Where("(book_id, author_id) IN ?", duplicates).Delete(&models.BookAuthor{}).Error; err != nil {
+			utils.WriteLog(utils.LogLevelError, "sqlAuthor.Merge.DeleteLinks; "+err.Error())
+			return err
+		}
+	}
+
+	err = tx.Model(&models.BookAuthor{}).
+		Where("author_id IN ?", sourceIds).
+		Update("author_id", id).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.Merge.Update; "+err.Error())
+		return err
+	}
+
+	if err := tx.Where("id IN ?", sourceIds).Delete(&models.Author{}).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlAuthor.Merge.Delete; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// withBooks adds the number of books in the catalog by each author.
+func (r *repoAuthor) withBooks(query *gorm.DB) *gorm.DB {
+	return query.Select("authors.*, (?) AS books", r.DB.Model(&models.BookAuthor{}).
+		Select("COUNT(*)").
+		Joins("JOIN books ON books.id = book_authors.book_id AND books.deleted_at IS NULL").
+		Where("book_authors.author_id = authors.id"))
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeAuthorsOfTheSameBook(t *testing.T) {
+	// b1 is by the author and a source, b2 by both sources, b3 by one source
+	db, conn := newFakeDB(t, "mysql", [][]driver.Value{
+		{"b1", "a1", int64(1)},
+		{"b1", "a2", int64(2)},
+		{"b2", "a3", int64(1)},
+		{"b2", "a2", int64(2)},
+		{"b3", "a3", int64(1)},
+	})
+	conn.columns = []string{"book_id", "author_id", "position"}
+
+	if err := NewAuthorRepo(db).Merge(db, "a1", []string{"a2", "a3"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(conn.query, "FOR UPDATE") {
+		t.Errorf("query %q does not lock the links", conn.query)
+	}
+
+	want := []fakeExec{
+		{query: "DELETE FROM `book_authors` WHERE (book_id, author_id) IN ((?,?),(?,?))", args: []driver.Value{"b1", "a2", "b2", "a2"}},
+		{query: "UPDATE `book_authors` SET `author_id`=? WHERE author_id IN (?,?)", args: []driver.Value{"a1", "a2", "a3"}},
+		{query: "DELETE FROM `authors` WHERE id IN (?,?)", args: []driver.Value{"a2", "a3"}},
+	}
+	if !reflect.DeepEqual(conn.execs, want) {
+		t.Errorf("Merge ran %v, want %v", conn.execs, want)
+	}
+}
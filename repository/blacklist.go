@@ -3,6 +3,8 @@ package repository
 import (
 	"digital-book-lending/interfaces"
 	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -26,3 +28,13 @@ func (r *blacklistRepo) GetByToken(token string) (models.Blacklist, error) {
 	err := r.DB.Where("token = ?", token).First(&blacklist).Error
 	return blacklist, err
 }
+
+func (r *blacklistRepo) DeleteCreatedBefore(before time.Time) (int64, error) {
+	res := r.DB.Where("created_at < ?", before).Delete(&models.Blacklist{})
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBlacklist.DeleteCreatedBefore; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
@@ -1,16 +1,36 @@
 package repository
 
 import (
+	"database/sql"
 	"digital-book-lending/interfaces"
 	"digital-book-lending/models"
 	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+var validColumns = map[string]bool{
+	"id":         true,
+	"title":      true,
+	"author":     true,
+	"isbn":       true,
+	"category":   true,
+	"quantity":   true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+var validDirections = map[string]bool{
+	"asc":  true,
+	"desc": true,
+}
+
 func NewBookRepo(db *gorm.DB) interfaces.Book {
 	return &repoBook{
 		DB: db,
@@ -21,8 +41,8 @@ type repoBook struct {
 	DB *gorm.DB
 }
 
-func (r *repoBook) Store(m models.Book) error {
-	if err := r.DB.Create(&m).Error; err != nil {
+func (r *repoBook) Store(tx *gorm.DB, m models.Book) error {
+	if err := tx.Create(&m).Error; err != nil {
 		utils.WriteLog(utils.LogLevelError, "sqlBook.Store; "+err.Error())
 		return err
 	}
@@ -50,8 +70,8 @@ func (r *repoBook) Delete(m models.Book) (int64, error) {
 	return result.RowsAffected, nil
 }
 
-func (r *repoBook) SoftDelete(m models.Book, data interface{}) (int64, error) {
-	res := r.DB.Table(m.TableName()).Where("id = ?", m.ID).Updates(data)
+func (r *repoBook) SoftDelete(tx *gorm.DB, m models.Book, data interface{}) (int64, error) {
+	res := tx.Table(m.TableName()).Where("id = ? AND deleted_at IS NULL", m.ID).Updates(data)
 	if res.Error != nil {
 		utils.WriteLog(utils.LogLevelError, "sqlBook.SoftDelete; "+res.Error.Error())
 		return 0, res.Error
@@ -60,8 +80,9 @@ func (r *repoBook) SoftDelete(m models.Book, data interface{}) (int64, error) {
 	return res.RowsAffected, nil
 }
 
+// GetByIsbn finds a book by its ISBN-10 or ISBN-13, with or without hyphens.
 func (r *repoBook) GetByIsbn(isbn string) (ret models.Book, err error) {
-	if err = r.DB.Where("isbn = ?", isbn).First(&ret).Error; err != nil {
+	if err = r.byIsbn(r.DB, isbn).Take(&ret).Error; err != nil {
 		utils.WriteLog(utils.LogLevelError, "sqlBook.GetByIsbn; "+err.Error())
 		return models.Book{}, err
 	}
@@ -69,42 +90,46 @@ func (r *repoBook) GetByIsbn(isbn string) (ret models.Book, err error) {
 	return ret, nil
 }
 
-func (r *repoBook) Fetch(page, limit int, orderBy, orderDir, search string) (ret []models.Book, totalData int64, err error) {
-	query := r.DB.Table(models.Book{}.TableName()).Where("deleted_at IS NULL")
+func (r *repoBook) GetByIsbnForUpdate(tx *gorm.DB, isbn string) (ret models.Book, err error) {
+	err = r.byIsbn(tx.Clauses(clause.Locking{Strength: "UPDATE"}), isbn).Take(&ret).Error
+	return ret, err
+}
+
+// byIsbn matches the normalized ISBN-13 and, for a book the migration could
+// not normalize, the ISBN as given. The normalized match comes first.
+func (r *repoBook) byIsbn(query *gorm.DB, isbn string) *gorm.DB {
+	forms := isbnForms(isbn)
+	return query.Where("isbn IN ?", forms).Order(clause.OrderBy{Expression: clause.Expr{SQL: "FIELD(isbn, ?)", Vars: []interface{}{forms}, WithoutParentheses: true}})
+}
 
-	if strings.TrimSpace(search) != "" {
-		searchPattern := "%" + search + "%"
-		query = query.Where("LOWER(title) LIKE LOWER(?) OR LOWER(author) LIKE LOWER(?) OR LOWER(isbn) LIKE LOWER(?)", searchPattern, searchPattern, searchPattern)
+func isbnForms(isbn string) []string {
+	if normalized, err := utils.NormalizeISBN(isbn); err == nil && normalized != isbn {
+		return []string{normalized, isbn}
 	}
+	return []string{isbn}
+}
+
+func (r *repoBook) Fetch(page, limit int, filter request.BookFilter) (ret []models.Book, totalData int64, err error) {
+	query := r.filtered(filter, "")
 
 	if err := query.Count(&totalData).Error; err != nil {
 		return nil, 0, err
 	}
 
-	if orderBy != "" && orderDir != "" {
-		validColumns := map[string]bool{
-			"title":      true,
-			"author":     true,
-			"isbn":       true,
-			"category":   true,
-			"quantity":   true,
-			"created_at": true,
-			"updated_at": true,
+	if filter.OrderBy == "relevance" {
+		if filter.OrderDir != "asc" && filter.OrderDir != "desc" {
+			return nil, 0, fmt.Errorf("invalid orderDir: %s", filter.OrderDir)
 		}
-
-		validDirections := map[string]bool{
-			"asc":  true,
-			"desc": true,
-		}
-
-		if _, ok := validColumns[orderBy]; !ok {
-			return nil, 0, fmt.Errorf("invalid orderBy column: %s", orderBy)
+		query = query.Order(r.relevance(filter, filter.OrderDir)).Order("updated_at desc")
+	} else if filter.OrderBy != "" && filter.OrderDir != "" {
+		if _, ok := validColumns[filter.OrderBy]; !ok {
+			return nil, 0, fmt.Errorf("invalid orderBy column: %s", filter.OrderBy)
 		}
-		if _, ok := validDirections[orderDir]; !ok {
-			return nil, 0, fmt.Errorf("invalid orderDir: %s", orderDir)
+		if _, ok := validDirections[filter.OrderDir]; !ok {
+			return nil, 0, fmt.Errorf("invalid orderDir: %s", filter.OrderDir)
 		}
 
-		query = query.Order(fmt.Sprintf("%s %s", orderBy, orderDir))
+		query = query.Order(fmt.Sprintf("%s %s", filter.OrderBy, filter.OrderDir))
 	}
 
 	if limit > 0 {
@@ -120,7 +145,408 @@ func (r *repoBook) Fetch(page, limit int, orderBy, orderDir, search string) (ret
 	return ret, totalData, nil
 }
 
+// FetchAfter returns the books after the cursor, or the first ones when it is
+// nil, in the filter's order with the id breaking ties. The next cursor is nil
+// on the last page. NULLs sort first ascending and last descending, as they
+// do in MySQL.
+func (r *repoBook) FetchAfter(limit int, filter request.BookFilter, after *request.BookCursor) (ret []models.Book, next *request.BookCursor, err error) {
+	if _, ok := validColumns[filter.OrderBy]; !ok {
+		return nil, nil, fmt.Errorf("invalid orderBy column: %s", filter.OrderBy)
+	}
+	if _, ok := validDirections[filter.OrderDir]; !ok {
+		return nil, nil, fmt.Errorf("invalid orderDir: %s", filter.OrderDir)
+	}
+
+	column, desc := filter.OrderBy, filter.OrderDir == "desc"
+	query := r.filtered(filter, "")
+
+	if after != nil {
+		var value interface{}
+		if after.Value != nil {
+			if value, err = cursorValue(column, *after.Value); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		switch {
+		case value == nil && desc:
+			query = query.Where(column+" IS NULL AND id < ?", after.Id)
+		case value == nil:
+			query = query.Where("("+column+" IS NULL AND id > ?) OR "+column+" IS NOT NULL", after.Id)
+		case desc:
+			query = query.Where(column+" < ? OR ("+column+" = ? AND id < ?) OR "+column+" IS NULL", value, value, after.Id)
+		default:
+			query = query.Where(column+" > ? OR ("+column+" = ? AND id > ?)", value, value, after.Id)
+		}
+	}
+
+	err = query.Order(fmt.Sprintf("%s %s", column, filter.OrderDir)).
+		Order("id " + filter.OrderDir).
+		Limit(limit + 1).
+		Find(&ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBooks.FetchAfter; "+err.Error())
+		return nil, nil, err
+	}
+
+	if len(ret) <= limit {
+		return ret, nil, nil
+	}
+
+	ret = ret[:limit]
+	last := ret[limit-1]
+	return ret, &request.BookCursor{
+		OrderBy:  filter.OrderBy,
+		OrderDir: filter.OrderDir,
+		Value:    columnValue(last, column),
+		Id:       last.ID,
+	}, nil
+}
+
+// columnValue is the value of an order column of a book as kept in a cursor.
+func columnValue(book models.Book, column string) *string {
+	var value string
+	switch column {
+	case "id":
+		value = book.ID
+	case "title":
+		value = book.Title
+	case "author":
+		value = book.Author
+	case "isbn":
+		value = book.ISBN
+	case "category":
+		value = book.Category
+	case "quantity":
+		value = strconv.Itoa(book.Quantity)
+	case "created_at":
+		value = book.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		if book.UpdatedAt == nil {
+			return nil
+		}
+		value = book.UpdatedAt.Format(time.RFC3339Nano)
+	}
+
+	return &value
+}
+
+// cursorValue turns the value kept in a cursor back into the column's type.
+func cursorValue(column, value string) (interface{}, error) {
+	switch column {
+	case "quantity":
+		return strconv.Atoi(value)
+	case "created_at", "updated_at":
+		return time.Parse(time.RFC3339Nano, value)
+	default:
+		return value, nil
+	}
+}
+
+// FetchFacets counts the books matching the filter per category and per
+// author, the most common first. The author facet is cut at limit values.
+func (r *repoBook) FetchFacets(filter request.BookFilter, limit int) (facets models.BookFacets, err error) {
+	err = r.facetCounts("category", r.filtered(filter, "category")).
+		Order("count desc, value asc").
+		Scan(&facets.Categories).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBooks.FetchFacets.Categories; "+err.Error())
+		return facets, err
+	}
+
+	err = r.facetCounts("author", r.filtered(filter, "author")).
+		Order("count desc, value asc").
+		Limit(limit).
+		Scan(&facets.Authors).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBooks.FetchFacets.Authors; "+err.Error())
+		return facets, err
+	}
+
+	return facets, nil
+}
+
+// FetchFacetValues lists every category or author with its number of books,
+// alphabetically.
+func (r *repoBook) FetchFacetValues(facet string, page, limit int) (ret []models.FacetCount, totalData int64, err error) {
+	if facet != "category" && facet != "author" {
+		return nil, 0, fmt.Errorf("invalid facet: %s", facet)
+	}
+
+	query := r.facetCounts(facet, r.DB.Table(models.Book{}.TableName()).Where("deleted_at IS NULL"))
+
+	if err = r.DB.Table("(?) AS facet_values", query).Count(&totalData).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBooks.FetchFacetValues.Count; "+err.Error())
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err = query.Order("value asc").Offset(offset).Limit(limit).Scan(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBooks.FetchFacetValues; "+err.Error())
+		return nil, 0, err
+	}
+
+	return ret, totalData, nil
+}
+
+// facetLinks are the join table and the table of each facet.
+var facetLinks = map[string][2]string{
+	"category": {"book_categories", "categories"},
+	"author":   {"book_authors", "authors"},
+}
+
+// facetCounts counts the books among books per category or author.
+func (r *repoBook) facetCounts(facet string, books *gorm.DB) *gorm.DB {
+	link, table := facetLinks[facet][0], facetLinks[facet][1]
+	return r.DB.Table(link).
+		Select(table+".id AS id, "+table+".name AS value, COUNT(*) AS count").
+		Joins("JOIN "+table+" ON "+table+".id = "+link+"."+facet+"_id").
+		Where(link+".book_id IN (?)", books.Select("id")).
+		Group(table + ".id, " + table + ".name")
+}
+
+// datestamp is when a book last changed, its deletion included, which is what
+// OAI-PMH harvests by.
+const datestamp = "COALESCE(deleted_at, updated_at, created_at)"
+
+// FetchChanged lists the books, deleted ones included, whose datestamp is in
+// the harvest's range and after its position, in datestamp then id order.
+func (r *repoBook) FetchChanged(limit int, harvest request.Harvest) (ret []models.Book, err error) {
+	query := r.changed(harvest)
+	if harvest.After != nil {
+		query = query.Where(datestamp+" > ? OR ("+datestamp+" = ? AND id > ?)", *harvest.After, *harvest.After, harvest.AfterId)
+	}
+
+	err = query.Order(datestamp + " asc").Order("id asc").Limit(limit).Find(&ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBooks.FetchChanged; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// CountChanged counts every book in the harvest's range, wherever the harvest
+// has got to.
+func (r *repoBook) CountChanged(harvest request.Harvest) (totalData int64, err error) {
+	if err = r.changed(harvest).Count(&totalData).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBooks.CountChanged; "+err.Error())
+		return 0, err
+	}
+
+	return totalData, nil
+}
+
+func (r *repoBook) changed(harvest request.Harvest) *gorm.DB {
+	query := r.DB.Unscoped().Model(&models.Book{})
+	if harvest.From != nil {
+		query = query.Where(datestamp+" >= ?", *harvest.From)
+	}
+	if harvest.Until != nil {
+		query = query.Where(datestamp+" <= ?", *harvest.Until)
+	}
+
+	return query
+}
+
+// GetByIdWithDeleted finds a book even after it has been deleted.
+func (r *repoBook) GetByIdWithDeleted(id string) (ret models.Book, err error) {
+	err = r.DB.Unscoped().First(&ret, "id = ?", id).Error
+	return ret, err
+}
+
+// EarliestCreatedAt is when the first book was added, deleted ones included,
+// and nil when there are no books.
+func (r *repoBook) EarliestCreatedAt() (*time.Time, error) {
+	var earliest sql.NullTime
+	if err := r.DB.Unscoped().Model(&models.Book{}).Select("MIN(created_at)").Scan(&earliest).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBooks.EarliestCreatedAt; "+err.Error())
+		return nil, err
+	}
+	if !earliest.Valid {
+		return nil, nil
+	}
+
+	return &earliest.Time, nil
+}
+
+// filtered applies every filter except the facet being counted, if any.
+func (r *repoBook) filtered(filter request.BookFilter, facet string) *gorm.DB {
+	query := r.DB.Table(models.Book{}.TableName()).Where("deleted_at IS NULL")
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		if mode := r.searchMode(filter); mode != utils.SearchLike {
+			query = query.Where(matchAgainst(mode)+" OR isbn IN ?", search, isbnForms(search))
+		} else {
+			searchPattern := "%" + plainSearch(search, filter.SearchMode) + "%"
+			query = query.Where("LOWER(title) LIKE LOWER(?) OR LOWER(author) LIKE LOWER(?) OR LOWER(isbn) LIKE LOWER(?)", searchPattern, searchPattern, searchPattern)
+		}
+	}
+	if len(filter.Categories) > 0 && facet != "category" {
+		query = query.Where("id IN (?)", r.DB.Model(&models.BookCategory{}).
+			Select("book_categories.book_id").
+			Joins("JOIN categories ON categories.id = book_categories.category_id").
+			Where("LOWER(categories.name) IN ?", lowerAll(filter.Categories)))
+	}
+	if len(filter.CategoryIds) > 0 && facet != "category" {
+		query = query.Where("id IN (?)", r.DB.Model(&models.BookCategory{}).Select("book_id").Where("category_id IN ?", filter.CategoryIds))
+	}
+	if len(filter.Authors) > 0 && facet != "author" {
+		query = query.Where("id IN (?)", r.DB.Model(&models.BookAuthor{}).
+			Select("book_authors.book_id").
+			Joins("JOIN authors ON authors.id = book_authors.author_id").
+			Where("LOWER(authors.name) IN ?", lowerAll(filter.Authors)))
+	}
+	if len(filter.AuthorIds) > 0 && facet != "author" {
+		query = query.Where("id IN (?)", r.DB.Model(&models.BookAuthor{}).Select("book_id").Where("author_id IN ?", filter.AuthorIds))
+	}
+
+	// a digital title is in stock while its license is valid, even when every
+	// seat happens to be taken
+	if filter.InStock {
+		now := time.Now()
+		query = query.Where("quantity > 0 OR (license_type IS NOT NULL AND (license_expires_at IS NULL OR license_expires_at > ?) AND (license_max_checkouts IS NULL OR license_checkouts < license_max_checkouts))", now)
+	}
+
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", filter.CreatedTo.AddDate(0, 0, 1))
+	}
+	if filter.MinQuantity != nil {
+		query = query.Where("quantity >= ?", *filter.MinQuantity)
+	}
+	if filter.MaxQuantity != nil {
+		query = query.Where("quantity <= ?", *filter.MaxQuantity)
+	}
+
+	return query
+}
+
+// searchMode is the mode the search actually runs in. FULLTEXT indexes only
+// exist on MySQL, so other databases, such as SQLite in tests, always use LIKE.
+func (r *repoBook) searchMode(filter request.BookFilter) string {
+	if filter.SearchMode == "" || r.DB.Dialector.Name() != "mysql" {
+		return utils.SearchLike
+	}
+	return filter.SearchMode
+}
+
+// relevance orders by the FULLTEXT score of the search. Under LIKE a match in
+// the title weighs more than one in the author, which weighs more than one in
+// the category.
+func (r *repoBook) relevance(filter request.BookFilter, dir string) clause.OrderBy {
+	search := strings.TrimSpace(filter.Search)
+	if mode := r.searchMode(filter); mode != utils.SearchLike {
+		return clause.OrderBy{Expression: clause.Expr{SQL: matchAgainst(mode) + " " + dir, Vars: []interface{}{search}}}
+	}
+
+	searchPattern := "%" + plainSearch(search, filter.SearchMode) + "%"
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL: "(CASE WHEN LOWER(title) LIKE LOWER(?) THEN 4 ELSE 0 END + " +
+			"CASE WHEN LOWER(author) LIKE LOWER(?) THEN 2 ELSE 0 END + " +
+			"CASE WHEN LOWER(category) LIKE LOWER(?) THEN 1 ELSE 0 END) " + dir,
+		Vars: []interface{}{searchPattern, searchPattern, searchPattern},
+	}}
+}
+
+func matchAgainst(mode string) string {
+	if mode == utils.SearchBoolean {
+		return "MATCH(title, author, category) AGAINST (? IN BOOLEAN MODE)"
+	}
+	return "MATCH(title, author, category) AGAINST (? IN NATURAL LANGUAGE MODE)"
+}
+
+// plainSearch drops the boolean mode operators from a search that falls back
+// to LIKE, so "+harry +potter" still finds "Harry Potter".
+func plainSearch(search, mode string) string {
+	if mode != utils.SearchBoolean {
+		return search
+	}
+	plain := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`+-<>()~*"@`, r) {
+			return ' '
+		}
+		return r
+	}, search)
+	return strings.Join(strings.Fields(plain), " ")
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
+
+func (r *repoBook) GetById(id string) (ret models.Book, err error) {
+	err = r.DB.First(&ret, "id = ?", id).Error
+	return ret, err
+}
+
+func (r *repoBook) FetchByIds(ids []string) (ret []models.Book, err error) {
+	if err = r.DB.Where("id IN ?", ids).Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBooks.FetchByIds; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
 func (r *repoBook) GetByIdForUpdate(tx *gorm.DB, id string) (ret models.Book, err error) {
 	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ret, "id = ?", id).Error
 	return ret, err
 }
+
+// SyncQuantity recounts the available copies of a book into books.quantity.
+func (r *repoBook) SyncQuantity(tx *gorm.DB, id string) error {
+	available := tx.Model(&models.BookCopy{}).Select("COUNT(*)").Where("book_id = ? AND status = ?", id, utils.CopyAvailable)
+	if err := tx.Table(models.Book{}.TableName()).Where("id = ?", id).Update("quantity", available).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBook.SyncQuantity; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// SyncNames copies the names of the authors of the books, in order, into
+// books.author and the name of their main category into books.category.
+func (r *repoBook) SyncNames(tx *gorm.DB, ids []string) error {
+	authors := tx.Model(&models.BookAuthor{}).
+		Select("GROUP_CONCAT(authors.name ORDER BY book_authors.position SEPARATOR ', ')").
+		Joins("JOIN authors ON authors.id = book_authors.author_id").
+		Where("book_authors.book_id = books.id")
+	category := tx.Model(&models.BookCategory{}).
+		Select("categories.name").
+		Joins("JOIN categories ON categories.id = book_categories.category_id").
+		Where("book_categories.book_id = books.id").
+		Order("book_categories.position asc").
+		Limit(1)
+
+	err := tx.Table(models.Book{}.TableName()).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"author":   gorm.Expr("COALESCE((?), author)", authors),
+		"category": gorm.Expr("COALESCE((?), category)", category),
+	}).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBook.SyncNames; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repoBook) FetchExpiringLicenses(until time.Time, checkoutsLeft int) (ret []models.Book, err error) {
+	err = r.DB.Where("license_type IS NOT NULL").
+		Where(r.DB.Where("license_expires_at <= ?", until).
+			Or("license_max_checkouts - license_checkouts <= ?", checkoutsLeft)).
+		Order("license_expires_at IS NULL, license_expires_at asc").
+		Find(&ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBook.FetchExpiringLicenses; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repoBookCopy struct {
+	DB *gorm.DB
+}
+
+func NewBookCopyRepo(db *gorm.DB) interfaces.BookCopy {
+	return &repoBookCopy{DB: db}
+}
+
+func (r *repoBookCopy) Store(tx *gorm.DB, m models.BookCopy) (models.BookCopy, error) {
+	if err := tx.Create(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBookCopy.Store; "+err.Error())
+		return m, err
+	}
+
+	return m, nil
+}
+
+func (r *repoBookCopy) Update(tx *gorm.DB, m models.BookCopy, data interface{}) error {
+	return tx.Model(&m).Updates(data).Error
+}
+
+func (r *repoBookCopy) GetById(id string) (ret models.BookCopy, err error) {
+	err = r.DB.First(&ret, "id = ?", id).Error
+	return ret, err
+}
+
+func (r *repoBookCopy) GetByIdForUpdate(tx *gorm.DB, id string) (ret models.BookCopy, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ret, "id = ?", id).Error
+	return ret, err
+}
+
+func (r *repoBookCopy) GetAvailableForUpdate(tx *gorm.DB, bookId string) (ret models.BookCopy, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("book_id = ? AND status = ?", bookId, utils.CopyAvailable).
+		Order("created_at asc").
+		First(&ret).Error
+	return ret, err
+}
+
+func (r *repoBookCopy) FetchAvailableForUpdate(tx *gorm.DB, bookId string, limit int) (ret []models.BookCopy, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("book_id = ? AND status = ?", bookId, utils.CopyAvailable).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&ret).Error
+	return ret, err
+}
+
+func (r *repoBookCopy) FetchByBook(bookId string) (ret []models.BookCopy, err error) {
+	if err = r.DB.Where("book_id = ?", bookId).Order("created_at asc").Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBookCopy.FetchByBook; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *repoBookCopy) CountByBook(tx *gorm.DB, bookId string) (int64, error) {
+	var count int64
+	err := tx.Model(&models.BookCopy{}).Where("book_id = ?", bookId).Count(&count).Error
+	return count, err
+}
+
+func (r *repoBookCopy) CountAvailable(tx *gorm.DB, bookId string) (int64, error) {
+	var count int64
+	err := tx.Model(&models.BookCopy{}).
+		Where("book_id = ? AND status = ?", bookId, utils.CopyAvailable).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *repoBookCopy) CountByStatus(bookId string) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.DB.Model(&models.BookCopy{}).
+		Select("status, COUNT(*) AS count").
+		Where("book_id = ?", bookId).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlBookCopy.CountByStatus; "+err.Error())
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
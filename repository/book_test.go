@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeConn answers every query with the same rows, of books unless columns
+// says otherwise, and keeps the last query and its arguments and every
+// statement run, so the SQL built by the repository can be checked without a
+// MySQL server.
+type fakeConn struct {
+	columns []string
+	rows    [][]driver.Value
+	query   string
+	args    []driver.Value
+	execs   []fakeExec
+}
+
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+var bookColumns = []string{"id", "title", "quantity", "updated_at"}
+
+func (c *fakeConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *fakeConn) Driver() driver.Driver                        { return nil }
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements are not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.query, c.args = query, values(args)
+	columns := c.columns
+	if columns == nil {
+		columns = bookColumns
+	}
+	return &fakeRows{columns: columns, rows: c.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.execs = append(c.execs, fakeExec{query: query, args: values(args)})
+	return driver.RowsAffected(1), nil
+}
+
+func values(args []driver.NamedValue) (ret []driver.Value) {
+	for _, arg := range args {
+		ret = append(ret, arg.Value)
+	}
+	return ret
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// otherDialect is MySQL under another name, for the code paths taken on
+// databases without FULLTEXT support.
+type otherDialect struct {
+	*mysql.Dialector
+}
+
+func (otherDialect) Name() string { return "sqlite" }
+
+func newFakeBookRepo(t *testing.T, rows [][]driver.Value) (*repoBook, *fakeConn) {
+	t.Helper()
+	return newFakeBookRepoOn(t, "mysql", rows)
+}
+
+func newFakeBookRepoOn(t *testing.T, dialect string, rows [][]driver.Value) (*repoBook, *fakeConn) {
+	t.Helper()
+
+	db, conn := newFakeDB(t, dialect, rows)
+	return &repoBook{DB: db}, conn
+}
+
+func newFakeDB(t *testing.T, dialect string, rows [][]driver.Value) (*gorm.DB, *fakeConn) {
+	t.Helper()
+
+	conn := &fakeConn{rows: rows}
+	dialector := mysql.New(mysql.Config{Conn: sql.OpenDB(conn), SkipInitializeWithVersion: true})
+	if dialect != "mysql" {
+		dialector = otherDialect{dialector.(*mysql.Dialector)}
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, conn
+}
+
+func bookRow(id string, updatedAt interface{}) []driver.Value {
+	return []driver.Value{id, "Title " + id, int64(1), updatedAt}
+}
+
+func TestFetchAfterLastPage(t *testing.T) {
+	filter := request.BookFilter{OrderBy: "title", OrderDir: "asc"}
+
+	repo, conn := newFakeBookRepo(t, [][]driver.Value{bookRow("b1", nil), bookRow("b2", nil)})
+	books, next, err := repo.FetchAfter(2, filter, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 || next != nil {
+		t.Errorf("FetchAfter = %d books, next %+v, want 2 books and no next cursor", len(books), next)
+	}
+	if !strings.HasSuffix(conn.query, "LIMIT ?") || conn.args[len(conn.args)-1] != int64(3) {
+		t.Errorf("query %q %v does not ask for one more row than the page", conn.query, conn.args)
+	}
+
+	repo, _ = newFakeBookRepo(t, [][]driver.Value{bookRow("b1", nil), bookRow("b2", nil), bookRow("b3", nil)})
+	books, next, err = repo.FetchAfter(2, filter, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 || next == nil {
+		t.Fatalf("FetchAfter = %d books, next %+v, want 2 books and a next cursor", len(books), next)
+	}
+	if next.Id != "b2" || next.Value == nil || *next.Value != "Title b2" || next.OrderBy != "title" || next.OrderDir != "asc" {
+		t.Errorf("next = %+v, want the cursor of b2", next)
+	}
+}
+
+func TestFetchAfterNullSortKey(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC)
+	filter := request.BookFilter{OrderBy: "updated_at", OrderDir: "desc"}
+
+	repo, _ := newFakeBookRepo(t, [][]driver.Value{bookRow("b1", updatedAt), bookRow("b2", nil), bookRow("b3", nil)})
+	_, next, err := repo.FetchAfter(2, filter, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == nil || next.Id != "b2" || next.Value != nil {
+		t.Fatalf("next = %+v, want the cursor of b2 with a NULL value", next)
+	}
+
+	repo, _ = newFakeBookRepo(t, [][]driver.Value{bookRow("b1", updatedAt), bookRow("b2", updatedAt)})
+	_, next, err = repo.FetchAfter(1, filter, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == nil || next.Value == nil || *next.Value != "2024-05-01T10:00:00.123456Z" {
+		t.Fatalf("next = %+v, want the cursor of b1 with its update time", next)
+	}
+}
+
+func TestFetchAfterCursorQuery(t *testing.T) {
+	value := "2024-05-01T10:00:00.123456Z"
+	tests := []struct {
+		name     string
+		orderDir string
+		value    *string
+		where    string
+		args     int
+	}{
+		{name: "NULL descending", orderDir: "desc", where: "updated_at IS NULL AND id < ?", args: 1},
+		{name: "NULL ascending", orderDir: "asc", where: "(updated_at IS NULL AND id > ?) OR updated_at IS NOT NULL", args: 1},
+		{name: "value descending", orderDir: "desc", value: &value, where: "updated_at < ? OR (updated_at = ? AND id < ?) OR updated_at IS NULL", args: 3},
+		{name: "value ascending", orderDir: "asc", value: &value, where: "updated_at > ? OR (updated_at = ? AND id > ?)", args: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, conn := newFakeBookRepo(t, nil)
+			filter := request.BookFilter{OrderBy: "updated_at", OrderDir: tt.orderDir}
+			cursor := &request.BookCursor{OrderBy: "updated_at", OrderDir: tt.orderDir, Value: tt.value, Id: "b2"}
+
+			if _, _, err := repo.FetchAfter(2, filter, cursor); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(conn.query, tt.where) {
+				t.Errorf("query %q does not contain %q", conn.query, tt.where)
+			}
+			// the limit comes last
+			if len(conn.args) != tt.args+1 || conn.args[tt.args-1] != "b2" {
+				t.Fatalf("args = %v, want %d ending with the cursor id before the limit", conn.args, tt.args)
+			}
+			if tt.value != nil {
+				if at, ok := conn.args[0].(time.Time); !ok || at.Format(time.RFC3339Nano) != value {
+					t.Errorf("value arg = %#v, want the cursor time", conn.args[0])
+				}
+			}
+		})
+	}
+}
+
+func TestFetchAfterInvalidCursor(t *testing.T) {
+	tests := []struct {
+		orderBy string
+		value   string
+	}{
+		{orderBy: "quantity", value: "many"},
+		{orderBy: "created_at", value: "yesterday"},
+		{orderBy: "updated_at", value: "2024-05-01 10:00:00"},
+	}
+
+	for _, tt := range tests {
+		repo, conn := newFakeBookRepo(t, nil)
+		filter := request.BookFilter{OrderBy: tt.orderBy, OrderDir: "asc"}
+		cursor := &request.BookCursor{OrderBy: tt.orderBy, OrderDir: "asc", Value: &tt.value, Id: "b1"}
+
+		if _, _, err := repo.FetchAfter(2, filter, cursor); err == nil {
+			t.Errorf("FetchAfter with %s %q succeeded, want an error", tt.orderBy, tt.value)
+		}
+		if conn.query != "" {
+			t.Errorf("FetchAfter with %s %q ran %q", tt.orderBy, tt.value, conn.query)
+		}
+	}
+
+	repo, _ := newFakeBookRepo(t, nil)
+	if _, _, err := repo.FetchAfter(2, request.BookFilter{OrderBy: "password", OrderDir: "asc"}, nil); err == nil {
+		t.Error("FetchAfter accepted an unknown order column")
+	}
+}
+
+func TestSearchFallsBackToLike(t *testing.T) {
+	tests := []struct {
+		dialect string
+		mode    string
+		want    string
+		args    []driver.Value
+	}{
+		{dialect: "mysql", mode: utils.SearchNatural, want: "MATCH(title, author, category) AGAINST (? IN NATURAL LANGUAGE MODE)"},
+		{dialect: "mysql", mode: utils.SearchBoolean, want: "IN BOOLEAN MODE"},
+		{dialect: "mysql", mode: utils.SearchLike, want: "LOWER(title) LIKE LOWER(?)", args: []driver.Value{"%+go -java%"}},
+		{dialect: "sqlite", mode: utils.SearchNatural, want: "LOWER(title) LIKE LOWER(?)", args: []driver.Value{"%+go -java%"}},
+		{dialect: "sqlite", mode: utils.SearchBoolean, want: "LOWER(title) LIKE LOWER(?)", args: []driver.Value{"%go java%"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect+" "+tt.mode, func(t *testing.T) {
+			repo, conn := newFakeBookRepoOn(t, tt.dialect, nil)
+			filter := request.BookFilter{Search: "+go -java", SearchMode: tt.mode, OrderBy: "title", OrderDir: "asc"}
+
+			if _, _, err := repo.FetchAfter(2, filter, nil); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(conn.query, tt.want) {
+				t.Errorf("query %q does not contain %q", conn.query, tt.want)
+			}
+			if tt.args != nil && (strings.Contains(conn.query, "MATCH") || conn.args[0] != tt.args[0]) {
+				t.Errorf("query %q %v, want a LIKE search for %v", conn.query, conn.args, tt.args[0])
+			}
+		})
+	}
+}
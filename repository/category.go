@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repoCategory struct {
+	DB *gorm.DB
+}
+
+func NewCategoryRepo(db *gorm.DB) interfaces.Category {
+	return &repoCategory{DB: db}
+}
+
+func (r *repoCategory) Store(tx *gorm.DB, m models.Category) error {
+	if err := tx.Create(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.Store; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repoCategory) Update(tx *gorm.DB, m models.Category, data interface{}) (int64, error) {
+	res := tx.Table(m.TableName()).Where("id = ?", m.ID).Updates(data)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.Update; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// Delete removes a category along with its links to books.
+func (r *repoCategory) Delete(tx *gorm.DB, m models.Category) (int64, error) {
+	res := tx.Where("id = ?", m.ID).Delete(&m)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.Delete; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// Fetch lists the categories whose name contains the search, alphabetically,
+// with their number of books.
+func (r *repoCategory) Fetch(page, limit int, search string) (ret []models.Category, totalData int64, err error) {
+	query := r.DB.Model(&models.Category{})
+	if search = strings.TrimSpace(search); search != "" {
+		query = query.Where("LOWER(name) LIKE LOWER(?)", "%"+search+"%")
+	}
+
+	if err = query.Count(&totalData).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.Fetch.Count; "+err.Error())
+		return nil, 0, err
+	}
+
+	if limit > 0 {
+		query = query.Offset((page - 1) * limit).Limit(limit)
+	}
+	if err = r.withBooks(query).Order("name asc").Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.Fetch; "+err.Error())
+		return nil, 0, err
+	}
+
+	return ret, totalData, nil
+}
+
+// GetById finds a category with its number of books.
+func (r *repoCategory) GetById(id string) (ret models.Category, err error) {
+	err = r.withBooks(r.DB.Model(&models.Category{})).First(&ret, "id = ?", id).Error
+	return ret, err
+}
+
+func (r *repoCategory) GetByIdForUpdate(tx *gorm.DB, id string) (ret models.Category, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ret, "id = ?", id).Error
+	return ret, err
+}
+
+// GetByName finds a category by name, ignoring case as the unique index does.
+func (r *repoCategory) GetByName(tx *gorm.DB, name string) (ret models.Category, err error) {
+	err = tx.First(&ret, "name = ?", strings.TrimSpace(name)).Error
+	return ret, err
+}
+
+func (r *repoCategory) FetchByIds(tx *gorm.DB, ids []string) (ret []models.Category, err error) {
+	if err = tx.Where("id IN ?", ids).Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.FetchByIds; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// FetchByBooks returns the categories of each of the books, the main one first.
+func (r *repoCategory) FetchByBooks(tx *gorm.DB, bookIds []string) (map[string][]models.Category, error) {
+	var rows []struct {
+		BookId string
+		models.Category
+	}
+	err := tx.Table(models.BookCategory{}.TableName()).
+		Select("book_categories.book_id, categories.*").
+		Joins("JOIN categories ON categories.id = book_categories.category_id").
+		Where("book_categories.book_id IN ?", bookIds).
+		Order("book_categories.position asc").
+		Scan(&rows).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.FetchByBooks; "+err.Error())
+		return nil, err
+	}
+
+	ret := make(map[string][]models.Category, len(bookIds))
+	for _, row := range rows {
+		ret[row.BookId] = append(ret[row.BookId], row.Category)
+	}
+
+	return ret, nil
+}
+
+// FetchBookIds lists the books in any of the categories, deleted ones included.
+func (r *repoCategory) FetchBookIds(tx *gorm.DB, ids []string) (ret []string, err error) {
+	err = tx.Model(&models.BookCategory{}).Distinct("book_id").Where("category_id IN ?", ids).Pluck("book_id", &ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.FetchBookIds; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// CountBooks counts the books in the catalog in the category.
+func (r *repoCategory) CountBooks(tx *gorm.DB, id string) (total int64, err error) {
+	err = tx.Model(&models.BookCategory{}).
+		Joins("JOIN books ON books.id = book_categories.book_id AND books.deleted_at IS NULL").
+		Where("book_categories.category_id = ?", id).
+		Count(&total).Error
+	return total, err
+}
+
+// LinkBook makes the categories, the main one first, the only categories of
+// the book.
+func (r *repoCategory) LinkBook(tx *gorm.DB, bookId string, ids []string) error {
+	if err := tx.Where("book_id = ?", bookId).Delete(&models.BookCategory{}).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.LinkBook.Delete; "+err.Error())
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	links := make([]models.BookCategory, len(ids))
+	for i, id := range ids {
+		links[i] = models.BookCategory{BookId: bookId, CategoryId: id, Position: i + 1}
+	}
+	if err := tx.Create(&links).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.LinkBook.Create; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Merge moves the books of the source categories to the category and deletes
+// the sources. A book already in the category keeps its place in the book's
+// list, and a book in several of the sources keeps the first of them.
+func (r *repoCategory) Merge(tx *gorm.DB, id string, sourceIds []string) error {
+	var links []models.BookCategory
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("category_id IN ?", append([]string{id}, sourceIds...)).
+		Order("book_id").Order("position").
+		Find(&links).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.Merge.Find; "+err.Error())
+		return err
+	}
+
+	// a book keeps one link to the merged category: its link to the category,
+	// or else its first link to a source; the others would be duplicates
+	kept := map[string]string{}
+	for _, link := range links {
+		if link.CategoryId == id {
+			kept[link.BookId] = id
+		}
+	}
+	var duplicates [][]interface{}
+	for _, link := range links {
+		if _, ok := kept[link.BookId]; !ok {
+			kept[link.BookId] = link.CategoryId
+		} else if kept[link.BookId] != link.CategoryId {
+			duplicates = append(duplicates, []interface{}{link.BookId, link.CategoryId})
+		}
+	}
+	if len(duplicates) > 0 {
+		if err := tx.Where("(book_id, category_id) IN ?", duplicates).Delete(&models.BookCategory{}).Error; err != nil {
+			utils.WriteLog(utils.LogLevelError, "sqlCategory.Merge.DeleteLinks; "+err.Error())
+			return err
+		}
+	}
+
+	err = tx.Model(&models.BookCategory{}).
+		Where("category_id IN ?", sourceIds).
+		Update("category_id", id).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.Merge.Update; "+err.Error())
+		return err
+	}
+
+	if err := tx.Where("id IN ?", sourceIds).Delete(&models.Category{}).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlCategory.Merge.Delete; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// withBooks adds the number of books in the catalog in each category.
+func (r *repoCategory) withBooks(query *gorm.DB) *gorm.DB {
+	return query.Select("categories.*, (?) AS books", r.DB.Model(&models.BookCategory{}).
+		Select("COUNT(*)").
+		Joins("JOIN books ON books.id = book_categories.book_id AND books.deleted_at IS NULL").
+		Where("book_categories.category_id = categories.id"))
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestMergeCategoriesOfTheSameBook(t *testing.T) {
+	db, conn := newFakeDB(t, "mysql", [][]driver.Value{
+		{"b1", "c2", int64(1)},
+		{"b1", "c1", int64(2)},
+		{"b2", "c2", int64(1)},
+	})
+	conn.columns = []string{"book_id", "category_id", "position"}
+
+	if err := NewCategoryRepo(db).Merge(db, "c1", []string{"c2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// b1 keeps the category second in its list
+	want := []fakeExec{
+		{query: "DELETE FROM `book_categories` WHERE (book_id, category_id) IN ((?,?))", args: []driver.Value{"b1", "c2"}},
+		{query: "UPDATE `book_categories` SET `category_id`=? WHERE category_id IN (?)", args: []driver.Value{"c1", "c2"}},
+		{query: "DELETE FROM `categories` WHERE id IN (?)", args: []driver.Value{"c2"}},
+	}
+	if !reflect.DeepEqual(conn.execs, want) {
+		t.Errorf("Merge ran %v, want %v", conn.execs, want)
+	}
+}
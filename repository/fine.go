@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repoFine struct {
+	DB *gorm.DB
+}
+
+func NewFineRepo(db *gorm.DB) interfaces.Fine {
+	return &repoFine{DB: db}
+}
+
+func (r *repoFine) Store(tx *gorm.DB, m models.Fine) (models.Fine, error) {
+	if err := tx.Create(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlFine.Store; "+err.Error())
+		return m, err
+	}
+
+	return m, nil
+}
+
+func (r *repoFine) FetchByUser(userId string) (ret []models.Fine, err error) {
+	if err = r.DB.Where("user_id = ?", userId).Order("created_at desc").Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlFine.FetchByUser; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *repoFine) GetBalance(tx *gorm.DB, userId string) (models.Money, error) {
+	return r.balance(tx, userId)
+}
+
+// GetBalanceForUpdate reads the balance with the user's ledger entries locked,
+// so payments and waivers for the same user are settled one after the other.
+func (r *repoFine) GetBalanceForUpdate(tx *gorm.DB, userId string) (models.Money, error) {
+	return r.balance(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userId)
+}
+
+func (r *repoFine) balance(tx *gorm.DB, userId string) (models.Money, error) {
+	var balance models.Money
+	err := tx.Model(&models.Fine{}).
+		Select("COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE -amount END), 0)", utils.FineCharge).
+		Where("user_id = ?", userId).
+		Scan(&balance).Error
+	return balance, err
+}
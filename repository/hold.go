@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repoHold struct {
+	DB *gorm.DB
+}
+
+func NewHoldRepo(db *gorm.DB) interfaces.Hold {
+	return &repoHold{DB: db}
+}
+
+func (r *repoHold) Store(tx *gorm.DB, m models.Hold) (models.Hold, error) {
+	if err := tx.Create(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlHold.Store; "+err.Error())
+		return m, err
+	}
+
+	return m, nil
+}
+
+func (r *repoHold) Update(tx *gorm.DB, m models.Hold, data interface{}) error {
+	return tx.Model(&m).Updates(data).Error
+}
+
+func (r *repoHold) GetById(id string) (models.Hold, error) {
+	var m models.Hold
+	err := r.DB.First(&m, "id = ?", id).Error
+	return m, err
+}
+
+func (r *repoHold) GetByIdForUpdate(tx *gorm.DB, id string) (models.Hold, error) {
+	var m models.Hold
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, "id = ?", id).Error
+	return m, err
+}
+
+func (r *repoHold) GetActiveByUserAndBook(tx *gorm.DB, userId, bookId string) (models.Hold, error) {
+	var m models.Hold
+	err := tx.Where("user_id = ? AND book_id = ? AND status IN ?", userId, bookId, []string{utils.HoldWaiting, utils.HoldReady}).
+		First(&m).Error
+	return m, err
+}
+
+func (r *repoHold) GetNextWaiting(tx *gorm.DB, bookId string) (models.Hold, error) {
+	var m models.Hold
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("book_id = ? AND status = ?", bookId, utils.HoldWaiting).
+		Order("created_at asc").
+		First(&m).Error
+	return m, err
+}
+
+func (r *repoHold) CountWaiting(tx *gorm.DB, bookId string) (int64, error) {
+	var count int64
+	err := tx.Model(&models.Hold{}).
+		Where("book_id = ? AND status = ?", bookId, utils.HoldWaiting).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *repoHold) CountReady(tx *gorm.DB, bookId string) (int64, error) {
+	var count int64
+	err := tx.Model(&models.Hold{}).
+		Where("book_id = ? AND status = ?", bookId, utils.HoldReady).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *repoHold) GetQueuePosition(tx *gorm.DB, m models.Hold) (int64, error) {
+	var ahead int64
+	err := tx.Model(&models.Hold{}).
+		Where("book_id = ? AND status = ? AND created_at < ?", m.BookId, utils.HoldWaiting, m.CreatedAt).
+		Count(&ahead).Error
+	return ahead + 1, err
+}
+
+func (r *repoHold) FetchByUser(userId string) (ret []models.Hold, err error) {
+	if err = r.DB.Where("user_id = ?", userId).Order("created_at desc").Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlHold.FetchByUser; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *repoHold) FetchLapsed(tx *gorm.DB, now time.Time) (ret []models.Hold, err error) {
+	err = tx.Where("status = ? AND expires_at < ?", utils.HoldReady, now).
+		Order("expires_at asc").
+		Find(&ret).Error
+	return ret, err
+}
+
+func (r *repoHold) FetchLapsedByBook(tx *gorm.DB, bookId string, now time.Time) (ret []models.Hold, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("book_id = ? AND status = ? AND expires_at < ?", bookId, utils.HoldReady, now).
+		Order("expires_at asc").
+		Find(&ret).Error
+	return ret, err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repoLendingPolicy struct {
+	DB *gorm.DB
+}
+
+func NewLendingPolicyRepo(db *gorm.DB) interfaces.LendingPolicy {
+	return &repoLendingPolicy{DB: db}
+}
+
+func (r *repoLendingPolicy) Store(m models.LendingPolicy) error {
+	if err := r.DB.Create(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLendingPolicy.Store; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repoLendingPolicy) Update(m models.LendingPolicy, data interface{}) (int64, error) {
+	res := r.DB.Table(m.TableName()).Where("id = ?", m.Id).Updates(data)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLendingPolicy.Update; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *repoLendingPolicy) Delete(m models.LendingPolicy) (int64, error) {
+	res := r.DB.Where("id = ?", m.Id).Delete(&m)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLendingPolicy.Delete; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *repoLendingPolicy) Fetch() (ret []models.LendingPolicy, err error) {
+	if err = r.DB.Order("role asc, category asc").Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLendingPolicy.Fetch; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *repoLendingPolicy) FetchApplicable(tx *gorm.DB, role, category string) (ret []models.LendingPolicy, err error) {
+	err = tx.Where("role IN ? AND (category = '' OR LOWER(category) = LOWER(?))", []string{role, ""}, category).
+		Find(&ret).Error
+	return ret, err
+}
+
+// RenameCategory moves the policies of the categories to another category. A
+// policy is left as it is when its role already has one for the new category.
+func (r *repoLendingPolicy) RenameCategory(tx *gorm.DB, from []string, to string) error {
+	err := tx.Table(models.LendingPolicy{}.TableName()).
+		Clauses(clause.Update{Modifier: "IGNORE"}).
+		Where("LOWER(category) IN ?", lowerAll(from)).
+		Update("category", to).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLendingPolicy.RenameCategory; "+err.Error())
+		return err
+	}
+
+	return nil
+}
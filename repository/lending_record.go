@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"database/sql"
 	"digital-book-lending/interfaces"
 	"digital-book-lending/models"
 	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type repoLending struct {
@@ -31,23 +35,214 @@ func (r *repoLending) Update(tx *gorm.DB, m models.LendingRecord, data interface
 
 func (r *repoLending) GetActiveByUserAndBook(tx *gorm.DB, userId, bookId string) (models.LendingRecord, error) {
 	var m models.LendingRecord
-	err := tx.Where("user_id = ? AND book_id = ? AND status = ?", userId, bookId, utils.Borrowed).
+	err := tx.Where("user_id = ? AND book_id = ? AND status IN ?", userId, bookId, []string{utils.Borrowed, utils.Overdue}).
 		First(&m).Error
 
 	return m, err
 }
 
-func (r *repoLending) CountBorrowsByUser(tx *gorm.DB, userId string, since time.Time) (int64, error) {
+func (r *repoLending) CountBorrowsByUser(tx *gorm.DB, userId, category string, since time.Time) (int64, error) {
+	var count int64
+	query := tx.Model(&models.LendingRecord{}).
+		Where("lending_records.user_id = ? AND lending_records.borrow_date >= ?", userId, since)
+	if category != "" {
+		query = query.Joins("JOIN books ON books.id = lending_records.book_id").
+			Where("LOWER(books.category) = LOWER(?)", category)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+func (r *repoLending) CountActiveByUser(tx *gorm.DB, userId, category string) (int64, error) {
+	var count int64
+	query := tx.Model(&models.LendingRecord{}).
+		Where("lending_records.user_id = ? AND lending_records.status IN ?", userId, []string{utils.Borrowed, utils.Overdue})
+	if category != "" {
+		query = query.Joins("JOIN books ON books.id = lending_records.book_id").
+			Where("LOWER(books.category) = LOWER(?)", category)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+func (r *repoLending) CountActiveDigitalByBook(tx *gorm.DB, bookId string) (int64, error) {
 	var count int64
 	err := tx.Model(&models.LendingRecord{}).
-		Where("user_id = ? AND borrow_date >= ?", userId, since).
+		Where("book_id = ? AND is_digital = ? AND status IN ?", bookId, true, []string{utils.Borrowed, utils.Overdue}).
 		Count(&count).Error
 	return count, err
 }
 
+// GetNextDueDate returns the earliest due date among the active loans of a
+// book, or nil when none of its copies is out.
+func (r *repoLending) GetNextDueDate(bookId string) (*time.Time, error) {
+	var next sql.NullTime
+	err := r.DB.Model(&models.LendingRecord{}).
+		Select("MIN(due_date)").
+		Where("book_id = ? AND status IN ?", bookId, []string{utils.Borrowed, utils.Overdue}).
+		Row().Scan(&next)
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLending.GetNextDueDate; "+err.Error())
+		return nil, err
+	}
+	if !next.Valid {
+		return nil, nil
+	}
+
+	return &next.Time, nil
+}
+
+// GetBorrowedById locks an active loan, so concurrent returns and renewals of
+// it run one after the other and see each other's changes.
 func (r *repoLending) GetBorrowedById(tx *gorm.DB, id string) (models.LendingRecord, error) {
 	var m models.LendingRecord
-	err := tx.Where("id = ? AND status = ?", id, utils.Borrowed).
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status IN ?", id, []string{utils.Borrowed, utils.Overdue}).
 		First(&m).Error
 	return m, err
 }
+
+func (r *repoLending) GetById(id string) (models.LendingRecord, error) {
+	var m models.LendingRecord
+	err := r.DB.Preload("Book", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		First(&m, "id = ?", id).Error
+	return m, err
+}
+
+func (r *repoLending) MarkOverdue(tx *gorm.DB, now time.Time) (int64, error) {
+	res := tx.Model(&models.LendingRecord{}).
+		Where("status = ? AND is_digital = ? AND due_date < ?", utils.Borrowed, false, now).
+		Update("status", utils.Overdue)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLending.MarkOverdue; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *repoLending) FetchExpiredDigital(now time.Time) (ret []models.LendingRecord, err error) {
+	err = r.DB.Where("is_digital = ? AND status IN ? AND due_date < ?", true, []string{utils.Borrowed, utils.Overdue}, now).
+		Order("due_date asc").
+		Find(&ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLending.FetchExpiredDigital; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// FetchOverdue lists the overdue loans, counting a borrowed loan past its due
+// date as overdue before the status check flags it.
+func (r *repoLending) FetchOverdue() (ret []models.LendingRecord, err error) {
+	err = r.DB.Where("status = ? OR (status = ? AND due_date < ?)", utils.Overdue, utils.Borrowed, time.Now()).
+		Order("due_date asc").
+		Find(&ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLending.FetchOverdue; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *repoLending) FetchDueBetween(from, to time.Time) (ret []models.LendingRecord, err error) {
+	err = r.DB.Where("status = ? AND due_date BETWEEN ? AND ?", utils.Borrowed, from, to).
+		Order("due_date asc").
+		Find(&ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLending.FetchDueBetween; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *repoLending) Fetch(page, limit int, filter request.LendingFilter) (ret []models.LendingRecord, totalData int64, err error) {
+	query := r.DB.Model(&models.LendingRecord{})
+
+	if filter.UserId != "" {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	if filter.BookId != "" {
+		query = query.Where("book_id = ?", filter.BookId)
+	}
+	if len(filter.BookIds) > 0 {
+		query = query.Where("book_id IN ?", filter.BookIds)
+	}
+
+	switch filter.Status {
+	case "":
+	case utils.LendingActive:
+		query = query.Where("status IN ?", []string{utils.Borrowed, utils.Overdue})
+	default:
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.FromDate != nil {
+		query = query.Where("borrow_date >= ?", *filter.FromDate)
+	}
+	if filter.ToDate != nil {
+		query = query.Where("borrow_date < ?", filter.ToDate.AddDate(0, 0, 1))
+	}
+
+	// a borrowed loan past its due date is overdue even before the status check flags it
+	if filter.Overdue != nil {
+		overdue := "(status = ? OR (status = ? AND due_date < ?))"
+		if *filter.Overdue {
+			query = query.Where(overdue, utils.Overdue, utils.Borrowed, time.Now())
+		} else {
+			query = query.Not(overdue, utils.Overdue, utils.Borrowed, time.Now())
+		}
+	}
+
+	if err = query.Count(&totalData).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLending.Fetch.Count; "+err.Error())
+		return nil, 0, err
+	}
+
+	orderBy, orderDir := "borrow_date", "desc"
+	if filter.OrderBy != "" && filter.OrderDir != "" {
+		validColumns := map[string]bool{
+			"borrow_date":   true,
+			"due_date":      true,
+			"return_date":   true,
+			"status":        true,
+			"renewal_count": true,
+			"created_at":    true,
+			"updated_at":    true,
+		}
+
+		validDirections := map[string]bool{
+			"asc":  true,
+			"desc": true,
+		}
+
+		if _, ok := validColumns[filter.OrderBy]; !ok {
+			return nil, 0, fmt.Errorf("invalid orderBy column: %s", filter.OrderBy)
+		}
+		if _, ok := validDirections[filter.OrderDir]; !ok {
+			return nil, 0, fmt.Errorf("invalid orderDir: %s", filter.OrderDir)
+		}
+
+		orderBy, orderDir = filter.OrderBy, filter.OrderDir
+	}
+
+	if limit > 0 {
+		offset := (page - 1) * limit
+		query = query.Offset(offset).Limit(limit)
+	}
+
+	// soft-deleted books are still shown in the borrowing history
+	err = query.Preload("Book", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Copy").
+		Order(fmt.Sprintf("%s %s", orderBy, orderDir)).
+		Find(&ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlLending.Fetch; "+err.Error())
+		return nil, 0, err
+	}
+
+	return ret, totalData, nil
+}
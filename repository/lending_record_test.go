@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestGetBorrowedByIdLocksTheLoan(t *testing.T) {
+	db, conn := newFakeDB(t, "mysql", nil)
+	repo := NewLendingRepo(db)
+
+	if _, err := repo.GetBorrowedById(db, "l1"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetBorrowedById = %v, want ErrRecordNotFound", err)
+	}
+	if !strings.HasSuffix(conn.query, "FOR UPDATE") {
+		t.Errorf("query %q does not lock the loan", conn.query)
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repoNotification struct {
+	DB *gorm.DB
+}
+
+func NewNotificationRepo(db *gorm.DB) interfaces.Notification {
+	return &repoNotification{DB: db}
+}
+
+// Enqueue adds a notification to the outbox, unless one with the same dedup
+// key was queued before.
+func (r *repoNotification) Enqueue(tx *gorm.DB, m models.Notification) (int64, error) {
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&m)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlNotification.Enqueue; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *repoNotification) Update(m models.Notification, data interface{}) error {
+	if err := r.DB.Model(&m).Updates(data).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlNotification.Update; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repoNotification) FetchPending(limit, maxAttempts int) (ret []models.Notification, err error) {
+	err = r.DB.Where("status = ? AND attempts < ?", utils.NotificationPending, maxAttempts).
+		Order("created_at asc").
+		Limit(limit).
+		Find(&ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlNotification.FetchPending; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *repoNotification) GetPreference(userId string) (models.NotificationPreference, error) {
+	var m models.NotificationPreference
+	err := r.DB.First(&m, "user_id = ?", userId).Error
+	return m, err
+}
+
+func (r *repoNotification) SavePreference(m models.NotificationPreference) error {
+	if err := r.DB.Save(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlNotification.SavePreference; "+err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -33,3 +33,12 @@ func (r *repo) GetByEmail(email string) (ret models.Users, err error) {
 
 	return ret, nil
 }
+
+func (r *repo) GetById(id string) (ret models.Users, err error) {
+	if err = r.DB.Where("id = ?", id).First(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlUsers.GetById; "+err.Error())
+		return models.Users{}, err
+	}
+
+	return ret, nil
+}
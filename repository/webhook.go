@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type repoWebhook struct {
+	DB *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) interfaces.Webhook {
+	return &repoWebhook{DB: db}
+}
+
+func (r *repoWebhook) StoreSubscription(m models.WebhookSubscription) error {
+	if err := r.DB.Create(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.StoreSubscription; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repoWebhook) UpdateSubscription(m models.WebhookSubscription, data interface{}) (int64, error) {
+	res := r.DB.Table(m.TableName()).Where("id = ?", m.Id).Updates(data)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.UpdateSubscription; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *repoWebhook) DeleteSubscription(m models.WebhookSubscription) (int64, error) {
+	res := r.DB.Where("id = ?", m.Id).Delete(&m)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.DeleteSubscription; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *repoWebhook) GetSubscriptionById(id string) (models.WebhookSubscription, error) {
+	var m models.WebhookSubscription
+	err := r.DB.First(&m, "id = ?", id).Error
+	return m, err
+}
+
+func (r *repoWebhook) FetchSubscriptions() (ret []models.WebhookSubscription, err error) {
+	if err = r.DB.Order("created_at asc").Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.FetchSubscriptions; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *repoWebhook) FetchActiveSubscriptions(tx *gorm.DB) (ret []models.WebhookSubscription, err error) {
+	err = tx.Where("is_active = ?", true).Find(&ret).Error
+	return ret, err
+}
+
+func (r *repoWebhook) StoreEvent(tx *gorm.DB, m models.WebhookEvent) error {
+	if err := tx.Create(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.StoreEvent; "+err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repoWebhook) FetchUndispatchedEvents(tx *gorm.DB, limit int) (ret []models.WebhookEvent, err error) {
+	err = tx.Where("dispatched_at IS NULL").
+		Order("created_at asc").
+		Limit(limit).
+		Find(&ret).Error
+	return ret, err
+}
+
+func (r *repoWebhook) MarkEventsDispatched(tx *gorm.DB, ids []string, now time.Time) error {
+	return tx.Model(&models.WebhookEvent{}).Where("id IN ?", ids).Update("dispatched_at", now).Error
+}
+
+func (r *repoWebhook) StoreDeliveries(tx *gorm.DB, m []models.WebhookDelivery) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&m).Error
+}
+
+func (r *repoWebhook) UpdateDelivery(m models.WebhookDelivery, data interface{}) (int64, error) {
+	res := r.DB.Table(m.TableName()).Where("id = ?", m.Id).Updates(data)
+	if res.Error != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.UpdateDelivery; "+res.Error.Error())
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+func (r *repoWebhook) GetDeliveryById(id string) (models.WebhookDelivery, error) {
+	var m models.WebhookDelivery
+	err := r.DB.Preload("Event").
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("created_at asc") }).
+		First(&m, "id = ?", id).Error
+	return m, err
+}
+
+func (r *repoWebhook) FetchDueDeliveries(now time.Time, limit int) (ret []models.WebhookDelivery, err error) {
+	err = r.DB.Preload("Event").
+		Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", utils.WebhookPending, now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Find(&ret).Error
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.FetchDueDeliveries; "+err.Error())
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *repoWebhook) FetchDeliveries(page, limit int, subscriptionId, status string) (ret []models.WebhookDelivery, totalData int64, err error) {
+	query := r.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err = query.Count(&totalData).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.FetchDeliveries.Count; "+err.Error())
+		return nil, 0, err
+	}
+
+	if limit > 0 {
+		offset := (page - 1) * limit
+		query = query.Offset(offset).Limit(limit)
+	}
+
+	if err = query.Preload("Event").Order("created_at desc").Find(&ret).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.FetchDeliveries; "+err.Error())
+		return nil, 0, err
+	}
+
+	return ret, totalData, nil
+}
+
+func (r *repoWebhook) StoreAttempt(m models.WebhookDeliveryAttempt) error {
+	if err := r.DB.Create(&m).Error; err != nil {
+		utils.WriteLog(utils.LogLevelError, "sqlWebhook.StoreAttempt; "+err.Error())
+		return err
+	}
+
+	return nil
+}
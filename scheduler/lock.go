@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"digital-book-lending/utils"
+	"fmt"
+)
+
+// MySQL lock names are limited to 64 characters.
+const maxLockNameLength = 64
+
+// tryLock takes the advisory lock of a job without waiting. GET_LOCK belongs
+// to the session that took it, so the lock is held on a connection of its own
+// that stays out of the pool until release is called. If the process dies the
+// connection drops and MySQL frees the lock.
+func (s *Scheduler) tryLock(ctx context.Context, name string) (release func(), acquired bool, err error) {
+	lockName := fmt.Sprintf("%s:job:%s", utils.GetEnv("APP_NAME", "digital-book-lending").(string), name)
+	if len(lockName) > maxLockNameLength {
+		lockName = lockName[:maxLockNameLength]
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", lockName).Scan(&result); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !result.Valid || result.Int64 != 1 {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release = func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("[Scheduler]; RELEASE_LOCK %s; Error: %s", lockName, err.Error()))
+		}
+		conn.Close()
+	}
+
+	return release, true, nil
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"digital-book-lending/utils"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Job is a task that runs every Interval. Run reports how many records it
+// touched, for the log. A PerReplica job looks after something each replica
+// keeps for itself, such as its search index, so it runs on every replica.
+type Job struct {
+	Name       string
+	Interval   time.Duration
+	PerReplica bool
+	Run        func() (int64, error)
+}
+
+// Scheduler runs jobs in the background. Every run holds a MySQL advisory lock
+// named after the job, so when several replicas share a database only one of
+// them runs a job at a time and the others skip that tick. PerReplica jobs
+// run without the lock.
+type Scheduler struct {
+	db   *sql.DB
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func NewScheduler(db *sql.DB) *Scheduler {
+	return &Scheduler{db: db}
+}
+
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job once and then on its interval until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+
+			for {
+				s.runOnce(ctx, job)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait blocks until every job has stopped after ctx is done.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	logPrefix := fmt.Sprintf("[Scheduler][%s]", job.Name)
+
+	if !job.PerReplica {
+		release, acquired, err := s.tryLock(ctx, job.Name)
+		if err != nil {
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; tryLock; Error: %s", logPrefix, err.Error()))
+			return
+		}
+		if !acquired {
+			utils.WriteLog(utils.LogLevelDebug, fmt.Sprintf("%s; Skipped, running on another replica", logPrefix))
+			return
+		}
+		defer release()
+	}
+
+	// a panicking job must not take the scheduler, or the API, down with it
+	defer func() {
+		if r := recover(); r != nil {
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Panic: %v", logPrefix, r))
+		}
+	}()
+
+	start := time.Now()
+	count, err := job.Run()
+	if err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("%s; Error after %d records: %s", logPrefix, count, err.Error()))
+		return
+	}
+
+	utils.WriteLog(utils.LogLevelInfo, fmt.Sprintf("%s; Done; Records: %d; Took: %s", logPrefix, count, time.Since(start)))
+}
@@ -0,0 +1,341 @@
+package search
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+const catalogAnalyzer = "catalog"
+
+// syncedAtKey keeps SyncedAt in the index, beside the documents.
+var syncedAtKey = []byte("synced_at")
+
+// rename moves a directory; tests replace it to make a swap fail.
+var rename = os.Rename
+
+// a match in the title counts more than one in the author, and that more
+// than one in the category; _all lets the words of a query match across
+// fields, as in "tolkien hobbit"
+var searchFields = []struct {
+	field string
+	boost float64
+}{
+	{"title", 3},
+	{"author", 2},
+	{"category", 1},
+	{"_all", 1},
+}
+
+// document is what the index keeps of a book.
+type document struct {
+	Title    string `json:"title"`
+	Author   string `json:"author"`
+	Category string `json:"category"`
+	ISBN     string `json:"isbn"`
+}
+
+type bleveIndex struct {
+	mu        sync.RWMutex
+	path      string
+	index     bleve.Index
+	fuzziness int
+}
+
+// NewBleveIndex opens the index at path, creating an empty one the first time.
+// Only one process can hold the index open; another one gives up after a few
+// seconds instead of waiting for the lock.
+func NewBleveIndex(path string, fuzziness int) (interfaces.SearchIndex, error) {
+	index, err := openIndex(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bleveIndex{path: path, index: index, fuzziness: fuzziness}, nil
+}
+
+func (b *bleveIndex) Index(book models.Book) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.index.Index(book.ID, newDocument(book))
+}
+
+func (b *bleveIndex) Delete(id string) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.index.Delete(id)
+}
+
+// Search finds books matching every word of the query, in any field and
+// allowing typos, so "harry poter" finds "Harry Potter". An exact ISBN, in
+// either form, matches too.
+func (b *bleveIndex) Search(text string, page, limit int) ([]models.SearchHit, int64, error) {
+	disjuncts := []query.Query{}
+	for _, field := range searchFields {
+		match := bleve.NewMatchQuery(text)
+		match.SetField(field.field)
+		match.SetOperator(query.MatchQueryOperatorAnd)
+		match.SetFuzziness(b.fuzziness)
+		match.SetBoost(field.boost)
+		disjuncts = append(disjuncts, match)
+	}
+	term := strings.TrimSpace(text)
+	if normalized, err := utils.NormalizeISBN(term); err == nil {
+		term = normalized
+	}
+	isbn := bleve.NewTermQuery(term)
+	isbn.SetField("isbn")
+	isbn.SetBoost(10)
+	disjuncts = append(disjuncts, isbn)
+
+	req := bleve.NewSearchRequestOptions(bleve.NewDisjunctionQuery(disjuncts...), limit, (page-1)*limit, false)
+	req.Fields = []string{"title", "author"}
+	req.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	req.Highlight.Fields = []string{"title", "author", "category"}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	result, err := b.index.Search(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return hits(result), int64(result.Total), nil
+}
+
+// Suggest completes what has been typed so far: the last word is taken as the
+// start of a word in the title or author, the words before it must match.
+func (b *bleveIndex) Suggest(prefix string, limit int) ([]models.SearchHit, error) {
+	words := strings.Fields(strings.ToLower(prefix))
+	if len(words) == 0 {
+		return []models.SearchHit{}, nil
+	}
+
+	conjuncts := []query.Query{}
+	if len(words) > 1 {
+		match := bleve.NewMatchQuery(strings.Join(words[:len(words)-1], " "))
+		match.SetField("_all")
+		match.SetOperator(query.MatchQueryOperatorAnd)
+		conjuncts = append(conjuncts, match)
+	}
+
+	last := words[len(words)-1]
+	titlePrefix := bleve.NewPrefixQuery(last)
+	titlePrefix.SetField("title")
+	titlePrefix.SetBoost(2)
+	authorPrefix := bleve.NewPrefixQuery(last)
+	authorPrefix.SetField("author")
+	conjuncts = append(conjuncts, bleve.NewDisjunctionQuery(titlePrefix, authorPrefix))
+
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(conjuncts...), limit, 0, false)
+	req.Fields = []string{"title", "author"}
+	req.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	req.Highlight.Fields = []string{"title", "author"}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	result, err := b.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return hits(result), nil
+}
+
+// Rebuild writes a new index next to the current one and swaps it in once
+// every book is in, so a failed rebuild leaves the current index untouched.
+// Changes made while it runs are not in the new index and the new index is
+// not synced; the caller marks how far it got.
+func (b *bleveIndex) Rebuild(next func() ([]models.Book, error)) (int, error) {
+	fresh := b.path + ".rebuild"
+	if err := os.RemoveAll(fresh); err != nil {
+		return 0, err
+	}
+
+	index, err := bleve.New(fresh, newMapping())
+	if err != nil {
+		return 0, err
+	}
+
+	var indexed int
+	for {
+		books, err := next()
+		if err == nil && len(books) == 0 {
+			break
+		}
+
+		if err == nil {
+			batch := index.NewBatch()
+			for _, book := range books {
+				if err = batch.Index(book.ID, newDocument(book)); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				err = index.Batch(batch)
+			}
+		}
+
+		if err != nil {
+			index.Close()
+			os.RemoveAll(fresh)
+			return indexed, err
+		}
+		indexed += len(books)
+	}
+
+	if err := index.Close(); err != nil {
+		return indexed, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the current index is moved aside rather than deleted, so it can be put
+	// back and reopened when the swap fails halfway
+	old := b.path + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return indexed, err
+	}
+	if err := b.index.Close(); err != nil {
+		return indexed, errors.Join(err, b.reopen())
+	}
+	if err := rename(b.path, old); err != nil {
+		return indexed, errors.Join(err, b.reopen())
+	}
+	if err := rename(fresh, b.path); err != nil {
+		return indexed, errors.Join(err, rename(old, b.path), b.reopen())
+	}
+
+	index, err = openIndex(b.path)
+	if err != nil {
+		return indexed, errors.Join(err, os.RemoveAll(b.path), rename(old, b.path), b.reopen())
+	}
+	b.index = index
+	if err := os.RemoveAll(old); err != nil {
+		utils.WriteLog(utils.LogLevelError, "[Search][Rebuild]; RemoveAll "+old+"; Error: "+err.Error())
+	}
+
+	return indexed, nil
+}
+
+// reopen opens the index at the path again after a failed swap, so searches
+// keep working on the index as it was.
+func (b *bleveIndex) reopen() error {
+	index, err := openIndex(b.path)
+	if err != nil {
+		return err
+	}
+
+	b.index = index
+	return nil
+}
+
+func (b *bleveIndex) SyncedAt() (time.Time, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	value, err := b.index.GetInternal(syncedAtKey)
+	if err != nil || value == nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, string(value))
+}
+
+func (b *bleveIndex) SetSyncedAt(at time.Time) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.index.SetInternal(syncedAtKey, []byte(at.Format(time.RFC3339Nano)))
+}
+
+func (b *bleveIndex) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.index.Close()
+}
+
+func openIndex(path string) (bleve.Index, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return bleve.New(path, newMapping())
+	}
+
+	return bleve.OpenUsing(path, map[string]interface{}{"bolt_timeout": "5s"})
+}
+
+// newMapping indexes the text fields lowercased but otherwise as written,
+// without stemming or stop words, which suits titles and names in any
+// language. They are stored with term vectors so matches can be highlighted.
+func newMapping() mapping.IndexMapping {
+	indexMapping := bleve.NewIndexMapping()
+	_ = indexMapping.AddCustomAnalyzer(catalogAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = catalogAnalyzer
+	text.Store = true
+	text.IncludeTermVectors = true
+
+	isbn := bleve.NewTextFieldMapping()
+	isbn.Analyzer = keyword.Name
+	isbn.IncludeInAll = false
+
+	book := bleve.NewDocumentMapping()
+	book.AddFieldMappingsAt("title", text)
+	book.AddFieldMappingsAt("author", text)
+	book.AddFieldMappingsAt("category", text)
+	book.AddFieldMappingsAt("isbn", isbn)
+
+	indexMapping.DefaultMapping = book
+	indexMapping.DefaultAnalyzer = catalogAnalyzer
+
+	return indexMapping
+}
+
+func newDocument(book models.Book) document {
+	return document{
+		Title:    book.Title,
+		Author:   book.Author,
+		Category: book.Category,
+		ISBN:     book.ISBN,
+	}
+}
+
+func hits(result *bleve.SearchResult) []models.SearchHit {
+	ret := make([]models.SearchHit, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		title, _ := hit.Fields["title"].(string)
+		author, _ := hit.Fields["author"].(string)
+		ret = append(ret, models.SearchHit{
+			Id:         hit.ID,
+			Title:      title,
+			Author:     author,
+			Score:      hit.Score,
+			Highlights: hit.Fragments,
+		})
+	}
+
+	return ret
+}
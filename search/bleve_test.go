@@ -0,0 +1,139 @@
+package search
+
+import (
+	"digital-book-lending/models"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestIndex(t *testing.T, books ...models.Book) *bleveIndex {
+	t.Helper()
+
+	index, err := NewBleveIndex(filepath.Join(t.TempDir(), "books.bleve"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { index.Close() })
+
+	for _, book := range books {
+		if err := index.Index(book); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return index.(*bleveIndex)
+}
+
+// batches returns the books in one batch, then the empty batch that ends a
+// rebuild.
+func batches(books ...models.Book) func() ([]models.Book, error) {
+	done := false
+	return func() ([]models.Book, error) {
+		if done {
+			return nil, nil
+		}
+		done = true
+		return books, nil
+	}
+}
+
+func searchIds(t *testing.T, index *bleveIndex, text string) []string {
+	t.Helper()
+
+	hits, _, err := index.Search(text, 1, 10)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", text, err)
+	}
+	ids := []string{}
+	for _, hit := range hits {
+		ids = append(ids, hit.Id)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	index := newTestIndex(t,
+		models.Book{ID: "b1", Title: "Harry Potter and the Philosopher's Stone", Author: "J. K. Rowling", ISBN: "9780747532699"},
+		models.Book{ID: "b2", Title: "The Hobbit", Author: "J. R. R. Tolkien", ISBN: "9780261102217"},
+	)
+
+	tests := map[string]string{
+		"harry poter":    "b1",
+		"tolkien hobbit": "b2",
+		"0-261-10221-4":  "b2",
+	}
+	for text, want := range tests {
+		if ids := searchIds(t, index, text); len(ids) != 1 || ids[0] != want {
+			t.Errorf("Search(%q) = %v, want %s", text, ids, want)
+		}
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	index := newTestIndex(t, models.Book{ID: "b1", Title: "Dune"})
+
+	indexed, err := index.Rebuild(batches(models.Book{ID: "b2", Title: "Emma"}, models.Book{ID: "b3", Title: "Dracula"}))
+	if err != nil || indexed != 2 {
+		t.Fatalf("Rebuild = %d, %v", indexed, err)
+	}
+	if ids := searchIds(t, index, "dune"); len(ids) != 0 {
+		t.Errorf("a book left out of the rebuild is still found: %v", ids)
+	}
+	if ids := searchIds(t, index, "emma"); len(ids) != 1 {
+		t.Errorf("Search(emma) = %v after the rebuild", ids)
+	}
+	if _, err := os.Stat(index.path + ".old"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the old index was left behind: %v", err)
+	}
+}
+
+func TestRebuildFailedSwap(t *testing.T) {
+	for _, failing := range []int{1, 2} {
+		index := newTestIndex(t, models.Book{ID: "b1", Title: "Dune"})
+
+		calls := 0
+		rename = func(from, to string) error {
+			if calls++; calls == failing {
+				return errors.New("device busy")
+			}
+			return os.Rename(from, to)
+		}
+		_, err := index.Rebuild(batches(models.Book{ID: "b2", Title: "Emma"}))
+		rename = os.Rename
+
+		if err == nil {
+			t.Fatalf("rename %d failing: Rebuild succeeded", failing)
+		}
+		if ids := searchIds(t, index, "dune"); len(ids) != 1 {
+			t.Errorf("rename %d failing: Search(dune) = %v, want the index as it was", failing, ids)
+		}
+		if err := index.Index(models.Book{ID: "b3", Title: "Ulysses"}); err != nil {
+			t.Errorf("rename %d failing: Index: %v", failing, err)
+		}
+	}
+}
+
+func TestSyncedAt(t *testing.T) {
+	index := newTestIndex(t)
+
+	if synced, err := index.SyncedAt(); err != nil || !synced.IsZero() {
+		t.Fatalf("SyncedAt of a new index = %v, %v, want the zero time", synced, err)
+	}
+
+	at := time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC)
+	if err := index.SetSyncedAt(at); err != nil {
+		t.Fatal(err)
+	}
+	if synced, err := index.SyncedAt(); err != nil || !synced.Equal(at) {
+		t.Errorf("SyncedAt = %v, %v, want %v", synced, err, at)
+	}
+
+	if _, err := index.Rebuild(batches(models.Book{ID: "b1", Title: "Dune"})); err != nil {
+		t.Fatal(err)
+	}
+	if synced, err := index.SyncedAt(); err != nil || !synced.IsZero() {
+		t.Errorf("SyncedAt after a rebuild = %v, %v, want the zero time", synced, err)
+	}
+}
@@ -0,0 +1,14 @@
+package search
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/utils"
+)
+
+// NewSearchIndex opens the catalog search index at SEARCH_INDEX_PATH.
+func NewSearchIndex() (interfaces.SearchIndex, error) {
+	return NewBleveIndex(
+		utils.GetEnv("SEARCH_INDEX_PATH", "data/books.bleve").(string),
+		utils.GetEnv("SEARCH_FUZZINESS", 1).(int),
+	)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrUnknownAuthor = errors.New("author_ids lists an author that does not exist")
+
+type AuthorService struct {
+	authorRepo   interfaces.Author
+	categoryRepo interfaces.Category
+	bookRepo     interfaces.Book
+	webhookRepo  interfaces.Webhook
+	searchIndex  interfaces.SearchIndex
+	DB           *gorm.DB
+}
+
+func NewAuthorService(authorRepo interfaces.Author, categoryRepo interfaces.Category, bookRepo interfaces.Book, webhookRepo interfaces.Webhook, searchIndex interfaces.SearchIndex, db *gorm.DB) *AuthorService {
+	return &AuthorService{
+		authorRepo:   authorRepo,
+		categoryRepo: categoryRepo,
+		bookRepo:     bookRepo,
+		webhookRepo:  webhookRepo,
+		searchIndex:  searchIndex,
+		DB:           db,
+	}
+}
+
+func (s *AuthorService) ListAuthors(page, limit int, search string) ([]models.Author, int64, error) {
+	return s.authorRepo.Fetch(page, limit, search)
+}
+
+func (s *AuthorService) GetAuthor(id string) (models.Author, error) {
+	return s.authorRepo.GetById(id)
+}
+
+func (s *AuthorService) CreateAuthor(req request.Author, username string) (models.Author, error) {
+	author := models.Author{
+		ID:        utils.CreateUUID(),
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: time.Now(),
+		CreatedBy: username,
+	}
+
+	if err := s.authorRepo.Store(s.DB, author); err != nil {
+		return models.Author{}, err
+	}
+
+	return author, nil
+}
+
+// UpdateAuthor renames an author, and so every book by them. It returns 0 rows
+// when there is no such author.
+func (s *AuthorService) UpdateAuthor(id string, req request.Author, username string) (int64, error) {
+	var (
+		rows  int64
+		books []models.Book
+	)
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rows, err = s.authorRepo.Update(tx, models.Author{ID: id}, map[string]interface{}{
+			"name":       strings.TrimSpace(req.Name),
+			"updated_at": time.Now(),
+			"updated_by": username,
+		})
+		if err != nil || rows == 0 {
+			return err
+		}
+
+		bookIds, err := s.authorRepo.FetchBookIds(tx, []string{id})
+		if err != nil {
+			return err
+		}
+		books, err = syncBookNames(tx, s.bookRepo, s.authorRepo, s.categoryRepo, s.webhookRepo, bookIds)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	indexBooks(s.searchIndex, books...)
+
+	return rows, nil
+}
+
+// DeleteAuthor deletes an author no book in the catalog is by. It returns 0
+// rows when there is no such author.
+func (s *AuthorService) DeleteAuthor(id string) (int64, error) {
+	var rows int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.authorRepo.GetByIdForUpdate(tx, id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		books, err := s.authorRepo.CountBooks(tx, id)
+		if err != nil {
+			return err
+		}
+		if books > 0 {
+			return errors.New("the author still has books, merge them into another author instead")
+		}
+
+		rows, err = s.authorRepo.Delete(tx, models.Author{ID: id})
+		return err
+	})
+
+	return rows, err
+}
+
+// MergeAuthors folds the source authors into the author: their books become
+// the author's and the sources are deleted. It returns the author as it is
+// now.
+func (s *AuthorService) MergeAuthors(id string, req request.Merge, username string) (models.Author, error) {
+	var books []models.Book
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.authorRepo.GetByIdForUpdate(tx, id); err != nil {
+			return err
+		}
+		if slices.Contains(req.SourceIds, id) {
+			return errors.New("an author cannot be merged into itself")
+		}
+
+		sources, err := s.authorRepo.FetchByIds(tx, req.SourceIds)
+		if err != nil {
+			return err
+		}
+		if len(sources) != len(req.SourceIds) {
+			return errors.New("source_ids lists an author that does not exist")
+		}
+
+		bookIds, err := s.authorRepo.FetchBookIds(tx, req.SourceIds)
+		if err != nil {
+			return err
+		}
+		if err := s.authorRepo.Merge(tx, id, req.SourceIds); err != nil {
+			return err
+		}
+		if _, err := s.authorRepo.Update(tx, models.Author{ID: id}, map[string]interface{}{"updated_at": time.Now(), "updated_by": username}); err != nil {
+			return err
+		}
+
+		books, err = syncBookNames(tx, s.bookRepo, s.authorRepo, s.categoryRepo, s.webhookRepo, bookIds)
+		return err
+	})
+	if err != nil {
+		return models.Author{}, err
+	}
+	indexBooks(s.searchIndex, books...)
+
+	return s.authorRepo.GetById(id)
+}
+
+// bookAuthors are the authors a book is given: the ones listed by id, or else
+// the one named, added when the catalog has no author by that name.
+func bookAuthors(tx *gorm.DB, authorRepo interfaces.Author, ids []string, name, username string) ([]string, error) {
+	if len(ids) > 0 {
+		authors, err := authorRepo.FetchByIds(tx, ids)
+		if err != nil {
+			return nil, err
+		}
+		if len(authors) != len(ids) {
+			return nil, ErrUnknownAuthor
+		}
+		return ids, nil
+	}
+
+	author, err := authorRepo.GetByName(tx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		author = models.Author{
+			ID:        utils.CreateUUID(),
+			Name:      strings.TrimSpace(name),
+			CreatedAt: time.Now(),
+			CreatedBy: username,
+		}
+		err = authorRepo.Store(tx, author)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{author.ID}, nil
+}
@@ -5,71 +5,594 @@ import (
 	"digital-book-lending/models"
 	"digital-book-lending/utils"
 	"digital-book-lending/utils/request"
+	"errors"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type BookService struct {
-	bookRepo interfaces.Book
-	DB       *gorm.DB
+	bookRepo         interfaces.Book
+	authorRepo       interfaces.Author
+	categoryRepo     interfaces.Category
+	copyRepo         interfaces.BookCopy
+	holdRepo         interfaces.Hold
+	lendingRepo      interfaces.Lending
+	notificationRepo interfaces.Notification
+	webhookRepo      interfaces.Webhook
+	storage          interfaces.Storage
+	searchIndex      interfaces.SearchIndex
+	DB               *gorm.DB
 }
 
-func NewBookService(bookRepo interfaces.Book, db *gorm.DB) *BookService {
+func NewBookService(bookRepo interfaces.Book, authorRepo interfaces.Author, categoryRepo interfaces.Category, copyRepo interfaces.BookCopy, holdRepo interfaces.Hold, lendingRepo interfaces.Lending, notificationRepo interfaces.Notification, webhookRepo interfaces.Webhook, storage interfaces.Storage, searchIndex interfaces.SearchIndex, db *gorm.DB) *BookService {
 	return &BookService{
-		bookRepo: bookRepo,
-		DB:       db,
+		bookRepo:         bookRepo,
+		authorRepo:       authorRepo,
+		categoryRepo:     categoryRepo,
+		copyRepo:         copyRepo,
+		holdRepo:         holdRepo,
+		lendingRepo:      lendingRepo,
+		notificationRepo: notificationRepo,
+		webhookRepo:      webhookRepo,
+		storage:          storage,
+		searchIndex:      searchIndex,
+		DB:               db,
 	}
 }
 
 func (s *BookService) CreateBook(req request.AddBook, username string) (models.Book, error) {
+	var book models.Book
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		book, err = s.createBook(tx, req, username)
+		return err
+	})
+	if err != nil {
+		return models.Book{}, err
+	}
+	s.indexBook(book)
+
+	return book, nil
+}
+
+func (s *BookService) UpdateBook(id string, req request.UpdateBook, username string) (int64, error) {
+	var (
+		rows    int64
+		current models.Book
+	)
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rows, current, err = s.updateBook(tx, id, req, username)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if rows > 0 {
+		s.indexBook(current)
+	}
+
+	return rows, nil
+}
+
+// createBook stores a book with its authors, categories and copies and queues
+// its webhook event in the caller's transaction.
+func (s *BookService) createBook(tx *gorm.DB, req request.AddBook, username string) (models.Book, error) {
+	if isbn, err := utils.NormalizeISBN(req.ISBN); err == nil {
+		req.ISBN = isbn
+	}
+
 	book := models.Book{
 		ID:        utils.CreateUUID(),
 		Title:     req.Title,
-		Author:    req.Author,
 		ISBN:      req.ISBN,
-		Category:  req.Category,
 		Quantity:  req.Quantity,
 		CreatedAt: time.Now(),
 		CreatedBy: username,
 	}
 
-	if err := s.bookRepo.Store(book); err != nil {
+	if err := s.bookRepo.Store(tx, book); err != nil {
+		return models.Book{}, err
+	}
+	if err := s.linkBook(tx, book.ID, req.AuthorIds, req.Author, req.CategoryIds, req.Category, username); err != nil {
+		return models.Book{}, err
+	}
+
+	for i := 0; i < req.Quantity; i++ {
+		if _, err := storeCopy(tx, s.copyRepo, book, request.AddBookCopy{}, username); err != nil {
+			return models.Book{}, err
+		}
+	}
+
+	book, err := s.bookRepo.GetByIdForUpdate(tx, book.ID)
+	if err != nil {
+		return models.Book{}, err
+	}
+	books := []models.Book{book}
+	if err := fillLinks(tx, s.authorRepo, s.categoryRepo, books); err != nil {
+		return models.Book{}, err
+	}
+	book = books[0]
+
+	if err := queueWebhookEvent(tx, s.webhookRepo, utils.EventBookCreated, book); err != nil {
 		return models.Book{}, err
 	}
 
 	return book, nil
 }
 
-func (s *BookService) UpdateBook(id string, req request.UpdateBook, username string) (int64, error) {
+// linkBook gives a book the authors and categories listed by id or named,
+// when any are, and writes their names on it.
+func (s *BookService) linkBook(tx *gorm.DB, id string, authorIds []string, author string, categoryIds []string, category string, username string) error {
+	if len(authorIds) == 0 && author == "" && len(categoryIds) == 0 && category == "" {
+		return nil
+	}
+
+	if len(authorIds) > 0 || author != "" {
+		ids, err := bookAuthors(tx, s.authorRepo, authorIds, author, username)
+		if err != nil {
+			return err
+		}
+		if err := s.authorRepo.LinkBook(tx, id, ids); err != nil {
+			return err
+		}
+	}
+	if len(categoryIds) > 0 || category != "" {
+		ids, err := bookCategories(tx, s.categoryRepo, categoryIds, category, username)
+		if err != nil {
+			return err
+		}
+		if err := s.categoryRepo.LinkBook(tx, id, ids); err != nil {
+			return err
+		}
+	}
+
+	return s.bookRepo.SyncNames(tx, []string{id})
+}
+
+// updateBook updates a book in the caller's transaction and returns it as it
+// is now. It returns 0 rows when there is no such book.
+func (s *BookService) updateBook(tx *gorm.DB, id string, req request.UpdateBook, username string) (int64, models.Book, error) {
 	timeNow := time.Now()
+	if isbn, err := utils.NormalizeISBN(req.ISBN); err == nil {
+		req.ISBN = isbn
+	}
 
 	book := models.Book{
 		Title:     req.Title,
-		Author:    req.Author,
 		ISBN:      req.ISBN,
-		Category:  req.Category,
-		Quantity:  req.Quantity,
 		UpdatedAt: &timeNow,
 		UpdatedBy: username,
 	}
 
-	rows, err := s.bookRepo.Update(s.DB, models.Book{ID: id}, book)
+	rows, err := s.bookRepo.Update(tx, models.Book{ID: id}, book)
+	if err != nil || rows == 0 {
+		return rows, models.Book{}, err
+	}
+	if err := s.linkBook(tx, id, req.AuthorIds, req.Author, req.CategoryIds, req.Category, username); err != nil {
+		return 0, models.Book{}, err
+	}
+
+	current, err := s.bookRepo.GetByIdForUpdate(tx, id)
 	if err != nil {
-		return 0, err
+		return 0, models.Book{}, err
 	}
 
-	return rows, nil
+	// quantity is the number of copies on the shelf, so it is reached by
+	// adding or withdrawing copies
+	if req.Quantity > 0 {
+		if err := adjustCopies(tx, s.bookRepo, s.copyRepo, s.holdRepo, s.notificationRepo, current, req.Quantity, username); err != nil {
+			return 0, models.Book{}, err
+		}
+		if current, err = s.bookRepo.GetByIdForUpdate(tx, id); err != nil {
+			return 0, models.Book{}, err
+		}
+	}
+
+	books := []models.Book{current}
+	if err := fillLinks(tx, s.authorRepo, s.categoryRepo, books); err != nil {
+		return 0, models.Book{}, err
+	}
+	current = books[0]
+
+	if err := queueWebhookEvent(tx, s.webhookRepo, utils.EventBookUpdated, current); err != nil {
+		return 0, models.Book{}, err
+	}
+
+	return rows, current, nil
 }
 
 func (s *BookService) DeleteBook(id string, username string) error {
-	if _, err := s.bookRepo.SoftDelete(models.Book{ID: id}, map[string]interface{}{"deleted_at": time.Now(), "deleted_by": username}); err != nil {
+	var rows int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		deletedAt := time.Now()
+		rows, err = s.bookRepo.SoftDelete(tx, models.Book{ID: id}, map[string]interface{}{"deleted_at": deletedAt, "deleted_by": username})
+		if err != nil || rows == 0 {
+			return err
+		}
+
+		return queueWebhookEvent(tx, s.webhookRepo, utils.EventBookDeleted, map[string]interface{}{
+			"id":         id,
+			"deleted_at": deletedAt,
+			"deleted_by": username,
+		})
+	})
+	if err != nil {
 		return err
 	}
 
+	if rows > 0 {
+		if err := s.searchIndex.Delete(id); err != nil {
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("[Book][DeleteBook][%s]; searchIndex.Delete; Error: %+v", id, err))
+		}
+	}
+
 	return nil
 }
 
-func (s *BookService) ListBooks(page, limit int, orderBy, orderDir, search string) ([]models.Book, int64, error) {
-	return s.bookRepo.Fetch(page, limit, orderBy, orderDir, search)
+// GetBook returns a book with how many of its copies, or licensed seats, are
+// out, free and set aside right now, without locking anything.
+func (s *BookService) GetBook(id string) (models.BookDetail, error) {
+	book, err := s.bookRepo.GetById(id)
+	if err != nil {
+		return models.BookDetail{}, err
+	}
+
+	books := []models.Book{book}
+	if err := fillLinks(s.DB, s.authorRepo, s.categoryRepo, books); err != nil {
+		return models.BookDetail{}, err
+	}
+
+	detail := models.BookDetail{Book: books[0]}
+	if detail.Availability, err = s.getAvailability(book); err != nil {
+		return models.BookDetail{}, err
+	}
+
+	return detail, nil
+}
+
+func (s *BookService) getAvailability(book models.Book) (models.Availability, error) {
+	var (
+		availability models.Availability
+		err          error
+	)
+
+	if availability.HoldQueue, err = s.holdRepo.CountWaiting(s.DB, book.ID); err != nil {
+		return availability, err
+	}
+	if availability.NextExpectedReturn, err = s.lendingRepo.GetNextDueDate(book.ID); err != nil {
+		return availability, err
+	}
+
+	if book.License.Type == nil {
+		counts, err := s.copyRepo.CountByStatus(book.ID)
+		if err != nil {
+			return availability, err
+		}
+		available := counts[utils.CopyAvailable]
+		availability.OnLoan = counts[utils.CopyOnLoan]
+		availability.Available = &available
+		availability.OnHoldShelf = counts[utils.CopyOnHold]
+		return availability, nil
+	}
+
+	if availability.OnLoan, err = s.lendingRepo.CountActiveDigitalByBook(s.DB, book.ID); err != nil {
+		return availability, err
+	}
+	if availability.OnHoldShelf, err = s.holdRepo.CountReady(s.DB, book.ID); err != nil {
+		return availability, err
+	}
+
+	license := book.License
+	switch {
+	case license.ExpiresAt != nil && time.Now().After(*license.ExpiresAt),
+		license.MaxCheckouts != nil && license.Checkouts >= *license.MaxCheckouts:
+		none := int64(0)
+		availability.Available = &none
+	case license.Seats != nil:
+		free := max(int64(*license.Seats)-availability.OnLoan-availability.OnHoldShelf, 0)
+		availability.Available = &free
+	}
+
+	return availability, nil
+}
+
+func (s *BookService) ListBooks(page, limit int, filter request.BookFilter) ([]models.Book, int64, error) {
+	books, totalData, err := s.bookRepo.Fetch(page, limit, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := fillLinks(s.DB, s.authorRepo, s.categoryRepo, books); err != nil {
+		return nil, 0, err
+	}
+
+	return books, totalData, nil
+}
+
+// SearchBooks looks the query up in the search index, tolerating typos, and
+// returns the matching books as they are now, best match first.
+func (s *BookService) SearchBooks(text string, page, limit int) ([]models.BookHit, int64, error) {
+	searchHits, total, err := s.searchIndex.Search(text, page, limit)
+	if err != nil || len(searchHits) == 0 {
+		return []models.BookHit{}, total, err
+	}
+
+	ids := make([]string, 0, len(searchHits))
+	for _, hit := range searchHits {
+		ids = append(ids, hit.Id)
+	}
+	books, err := s.bookRepo.FetchByIds(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := fillLinks(s.DB, s.authorRepo, s.categoryRepo, books); err != nil {
+		return nil, 0, err
+	}
+
+	byId := make(map[string]models.Book, len(books))
+	for _, book := range books {
+		byId[book.ID] = book
+	}
+
+	// a hit without a book was deleted behind the index's back and is dropped
+	bookHits := make([]models.BookHit, 0, len(searchHits))
+	for _, hit := range searchHits {
+		if book, ok := byId[hit.Id]; ok {
+			bookHits = append(bookHits, models.BookHit{Book: book, Score: hit.Score, Highlights: hit.Highlights})
+		}
+	}
+
+	return bookHits, total, nil
+}
+
+func (s *BookService) SuggestBooks(prefix string, limit int) ([]models.SearchHit, error) {
+	return s.searchIndex.Suggest(prefix, limit)
+}
+
+// searchSyncOverlap is how far before the last sync every sync starts. A book
+// saved in a transaction that commits late, or by a replica whose clock is
+// behind, can have a datestamp before where the last sync got to.
+const searchSyncOverlap = 5 * time.Minute
+
+// ReindexBooks rebuilds the search index from the books table and marks it
+// synced as of when the rebuild started, so the next sync brings in what
+// changed while it ran.
+func (s *BookService) ReindexBooks() (int, error) {
+	batchSize := utils.GetEnv("SEARCH_REINDEX_BATCH_SIZE", 500).(int)
+	filter := request.BookFilter{OrderBy: "id", OrderDir: "asc"}
+	start := time.Now()
+
+	page := 0
+	indexed, err := s.searchIndex.Rebuild(func() ([]models.Book, error) {
+		page++
+		books, _, err := s.bookRepo.Fetch(page, batchSize, filter)
+		return books, err
+	})
+	if err != nil {
+		return indexed, err
+	}
+
+	return indexed, s.searchIndex.SetSyncedAt(start)
+}
+
+// SyncSearchIndex brings the search index of this replica up to date with the
+// books table, which other replicas write to as well. An index never synced,
+// such as the empty one of a fresh deploy, is rebuilt. After that, the books
+// whose datestamp moved since the last sync are indexed again and deleted
+// ones are taken out.
+func (s *BookService) SyncSearchIndex() (int64, error) {
+	synced, err := s.searchIndex.SyncedAt()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if synced.IsZero() {
+		indexed, err := s.ReindexBooks()
+		if err != nil {
+			return int64(indexed), err
+		}
+		count = int64(indexed)
+		if synced, err = s.searchIndex.SyncedAt(); err != nil {
+			return count, err
+		}
+	}
+
+	batchSize := utils.GetEnv("SEARCH_REINDEX_BATCH_SIZE", 500).(int)
+	from := synced.Add(-searchSyncOverlap)
+	harvest := request.Harvest{From: &from}
+	latest := synced
+	for {
+		books, err := s.bookRepo.FetchChanged(batchSize, harvest)
+		if err != nil {
+			return count, err
+		}
+
+		for _, book := range books {
+			if book.DeletedAt.Valid {
+				err = s.searchIndex.Delete(book.ID)
+			} else {
+				err = s.searchIndex.Index(book)
+			}
+			if err != nil {
+				return count, err
+			}
+			if at := book.ChangedAt(); at.After(latest) {
+				latest = at
+			}
+			count++
+		}
+
+		if len(books) < batchSize {
+			break
+		}
+		last := books[len(books)-1]
+		after := last.ChangedAt()
+		harvest.After, harvest.AfterId = &after, last.ID
+	}
+
+	if latest.After(synced) {
+		return count, s.searchIndex.SetSyncedAt(latest)
+	}
+	return count, nil
+}
+
+func (s *BookService) indexBook(book models.Book) {
+	indexBooks(s.searchIndex, book)
+}
+
+// indexBooks brings the books' entries in the search index up to date. The
+// books table is already committed, so a failure is only logged and is
+// repaired by the next reindex.
+func indexBooks(searchIndex interfaces.SearchIndex, books ...models.Book) {
+	for _, book := range books {
+		if err := searchIndex.Index(book); err != nil {
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("[Book][indexBook][%s]; searchIndex.Index; Error: %+v", book.ID, err))
+		}
+	}
+}
+
+// fillLinks sets the authors and categories of the books.
+func fillLinks(tx *gorm.DB, authorRepo interfaces.Author, categoryRepo interfaces.Category, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(books))
+	for i, book := range books {
+		ids[i] = book.ID
+	}
+	authors, err := authorRepo.FetchByBooks(tx, ids)
+	if err != nil {
+		return err
+	}
+	categories, err := categoryRepo.FetchByBooks(tx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range books {
+		books[i].Authors = append([]models.Author{}, authors[books[i].ID]...)
+		books[i].Categories = append([]models.Category{}, categories[books[i].ID]...)
+	}
+
+	return nil
+}
+
+// syncBookNames writes the names of their authors and categories on the books
+// after those changed, and queues a webhook event for each of them. It returns
+// the books as they are now, for the search index.
+func syncBookNames(tx *gorm.DB, bookRepo interfaces.Book, authorRepo interfaces.Author, categoryRepo interfaces.Category, webhookRepo interfaces.Webhook, ids []string) ([]models.Book, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := bookRepo.SyncNames(tx, ids); err != nil {
+		return nil, err
+	}
+
+	books := make([]models.Book, 0, len(ids))
+	for _, id := range ids {
+		book, err := bookRepo.GetByIdForUpdate(tx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// deleted books keep the names they had
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	if err := fillLinks(tx, authorRepo, categoryRepo, books); err != nil {
+		return nil, err
+	}
+
+	for _, book := range books {
+		if err := queueWebhookEvent(tx, webhookRepo, utils.EventBookUpdated, book); err != nil {
+			return nil, err
+		}
+	}
+
+	return books, nil
+}
+
+// ListBooksAfter is the cursor-paginated book list. It returns the token of
+// the next page, empty on the last one.
+func (s *BookService) ListBooksAfter(limit int, filter request.BookFilter, after *request.BookCursor) ([]models.Book, string, error) {
+	books, next, err := s.bookRepo.FetchAfter(limit, filter, after)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := fillLinks(s.DB, s.authorRepo, s.categoryRepo, books); err != nil {
+		return nil, "", err
+	}
+	if next == nil {
+		return books, "", nil
+	}
+
+	return books, next.Encode(), nil
+}
+
+func (s *BookService) GetFacets(filter request.BookFilter) (models.BookFacets, error) {
+	return s.bookRepo.FetchFacets(filter, utils.GetEnv("BOOK_FACET_LIMIT", 20).(int))
+}
+
+// ListFacetValues pages through every category or author with its number of
+// books, for browsing the catalog by them.
+func (s *BookService) ListFacetValues(facet string, page, limit int) ([]models.FacetCount, int64, error) {
+	return s.bookRepo.FetchFacetValues(facet, page, limit)
+}
+
+// HarvestBooks returns the next page of an OAI-PMH harvest, deleted books
+// included, with the size of the whole list and the harvest to resume it
+// from, nil after the last page.
+func (s *BookService) HarvestBooks(harvest request.Harvest, limit int) ([]models.Book, int64, *request.Harvest, error) {
+	totalData, err := s.bookRepo.CountChanged(harvest)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	books, err := s.bookRepo.FetchChanged(limit+1, harvest)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if len(books) <= limit {
+		return books, totalData, nil, fillLinks(s.DB, s.authorRepo, s.categoryRepo, books)
+	}
+
+	books = books[:limit]
+	if err := fillLinks(s.DB, s.authorRepo, s.categoryRepo, books); err != nil {
+		return nil, 0, nil, err
+	}
+	last := books[limit-1]
+	after := last.ChangedAt()
+	next := harvest
+	next.After, next.AfterId, next.Cursor = &after, last.ID, harvest.Cursor+limit
+
+	return books, totalData, &next, nil
+}
+
+// GetBookRecord finds a book for OAI-PMH, which also describes deleted ones.
+func (s *BookService) GetBookRecord(id string) (models.Book, error) {
+	book, err := s.bookRepo.GetByIdWithDeleted(id)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	books := []models.Book{book}
+	err = fillLinks(s.DB, s.authorRepo, s.categoryRepo, books)
+	return books[0], err
+}
+
+// EarliestDatestamp is a time no book changed before; the zero time when
+// there are no books.
+func (s *BookService) EarliestDatestamp() (time.Time, error) {
+	earliest, err := s.bookRepo.EarliestCreatedAt()
+	if err != nil || earliest == nil {
+		return time.Time{}, err
+	}
+
+	return *earliest, nil
 }
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	assetCover = "cover"
+	assetEbook = "ebook"
+)
+
+var coverExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// AttachCover stores a JPEG, PNG or WebP image as the cover of a book,
+// replacing the cover uploaded before.
+func (s *BookService) AttachCover(ctx context.Context, id string, file *multipart.FileHeader, username string) (models.Book, error) {
+	contentType, err := coverContentType(file)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	key := fmt.Sprintf("covers/%s/%s.%s", id, utils.CreateUUID(), coverExtensions[contentType])
+	return s.attachAsset(ctx, id, assetCover, key, contentType, file, nil, username)
+}
+
+// AttachEbook stores an EPUB or PDF file as the e-book of a book, replacing the
+// file uploaded before.
+func (s *BookService) AttachEbook(ctx context.Context, id string, file *multipart.FileHeader, username string) (models.Book, error) {
+	format, err := ebookFormat(file)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	contentType := "application/pdf"
+	if format == utils.EbookEpub {
+		contentType = "application/epub+zip"
+	}
+
+	key := fmt.Sprintf("ebooks/%s/%s.%s", id, utils.CreateUUID(), format)
+	return s.attachAsset(ctx, id, assetEbook, key, contentType, file, map[string]interface{}{"ebook_format": format}, username)
+}
+
+// OpenCover opens the cover image of a book from storage.
+func (s *BookService) OpenCover(ctx context.Context, id string) (models.Book, io.ReadSeekCloser, error) {
+	book, err := s.bookRepo.GetById(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return book, nil, errors.New("book not found")
+		}
+		return book, nil, err
+	}
+	if book.Cover.Key == nil {
+		return book, nil, errors.New("this book has no cover")
+	}
+
+	obj, err := s.storage.Open(ctx, *book.Cover.Key)
+	return book, obj, err
+}
+
+// attachAsset uploads a file under a key of its own and then records it in
+// the <asset>_* columns of the book. The upload runs before the transaction so
+// the book is not locked while it lasts; the file the book pointed at before is
+// removed once the book points at the new one, and the new file is removed when
+// the book cannot be updated.
+func (s *BookService) attachAsset(ctx context.Context, id, asset, key, contentType string, file *multipart.FileHeader, extra map[string]interface{}, username string) (models.Book, error) {
+	if _, err := s.bookRepo.GetById(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Book{}, errors.New("book not found")
+		}
+		return models.Book{}, err
+	}
+
+	stored, err := storeAsset(ctx, s.storage, key, contentType, file)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	var (
+		book   models.Book
+		oldKey string
+	)
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		book, err = s.bookRepo.GetByIdForUpdate(tx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("book not found")
+			}
+			return err
+		}
+
+		current := book.Cover
+		if asset == assetEbook {
+			current = book.Ebook
+		}
+		if current.Key != nil {
+			oldKey = *current.Key
+		}
+
+		timeNow := time.Now()
+		bookDataUpdate := map[string]interface{}{
+			asset + "_key":          stored.Key,
+			asset + "_content_type": stored.ContentType,
+			asset + "_size":         stored.Size,
+			asset + "_checksum":     stored.Checksum,
+			"updated_at":            timeNow,
+			"updated_by":            username,
+		}
+		for column, value := range extra {
+			bookDataUpdate[column] = value
+		}
+		if _, err := s.bookRepo.Update(tx, book, bookDataUpdate); err != nil {
+			return err
+		}
+
+		book, err = s.bookRepo.GetByIdForUpdate(tx, id)
+		return err
+	})
+	if err != nil {
+		s.deleteAsset(ctx, key)
+		return models.Book{}, err
+	}
+
+	if oldKey != "" {
+		s.deleteAsset(ctx, oldKey)
+	}
+
+	return book, nil
+}
+
+// deleteAsset removes a file no book points at. A failure is only logged, as
+// the file is no longer reachable anyway.
+func (s *BookService) deleteAsset(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("BookService.attachAsset; Delete %s; Error: %s", key, err.Error()))
+	}
+}
+
+// storeAsset streams an uploaded file to storage, hashing it on the way.
+func storeAsset(ctx context.Context, storage interfaces.Storage, key, contentType string, file *multipart.FileHeader) (models.Asset, error) {
+	src, err := file.Open()
+	if err != nil {
+		return models.Asset{}, err
+	}
+	defer src.Close()
+
+	hash := sha256.New()
+	if err := storage.Put(ctx, key, io.TeeReader(src, hash), file.Size, contentType); err != nil {
+		return models.Asset{}, err
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	size := file.Size
+	return models.Asset{Key: &key, ContentType: &contentType, Size: &size, Checksum: &checksum}, nil
+}
+
+// coverContentType sniffs the image type of an uploaded cover.
+func coverContentType(file *multipart.FileHeader) (string, error) {
+	maxSize := int64(utils.GetEnv("COVER_MAX_SIZE_MB", 5).(int)) << 20
+	if file.Size > maxSize {
+		return "", fmt.Errorf("cover image is larger than %d MB", maxSize>>20)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(src, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", errors.New("cover image is empty")
+	}
+
+	contentType := http.DetectContentType(header[:n])
+	if _, ok := coverExtensions[contentType]; !ok {
+		return "", errors.New("only JPEG, PNG and WebP images can be used as covers")
+	}
+
+	return contentType, nil
+}
+
+// ebookFormat tells the format of an uploaded e-book from its extension and
+// checks that the content matches: a PDF header or the ZIP container of EPUB.
+func ebookFormat(file *multipart.FileHeader) (string, error) {
+	maxSize := int64(utils.GetEnv("EBOOK_MAX_SIZE_MB", 100).(int)) << 20
+	if file.Size > maxSize {
+		return "", fmt.Errorf("e-book file is larger than %d MB", maxSize>>20)
+	}
+
+	var format string
+	var magic []byte
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".epub":
+		format, magic = utils.EbookEpub, []byte("PK\x03\x04")
+	case ".pdf":
+		format, magic = utils.EbookPdf, []byte("%PDF-")
+	default:
+		return "", errors.New("only EPUB and PDF files can be attached as e-books")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(src, header); err != nil || !bytes.Equal(header, magic) {
+		return "", fmt.Errorf("file content is not a valid %s", strings.ToUpper(format))
+	}
+
+	return format, nil
+}
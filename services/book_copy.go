@@ -0,0 +1,194 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BookCopyService struct {
+	bookRepo         interfaces.Book
+	copyRepo         interfaces.BookCopy
+	holdRepo         interfaces.Hold
+	notificationRepo interfaces.Notification
+	DB               *gorm.DB
+}
+
+func NewBookCopyService(bookRepo interfaces.Book, copyRepo interfaces.BookCopy, holdRepo interfaces.Hold, notificationRepo interfaces.Notification, db *gorm.DB) *BookCopyService {
+	return &BookCopyService{
+		bookRepo:         bookRepo,
+		copyRepo:         copyRepo,
+		holdRepo:         holdRepo,
+		notificationRepo: notificationRepo,
+		DB:               db,
+	}
+}
+
+func (s *BookCopyService) ListCopies(bookId string) ([]models.BookCopy, error) {
+	return s.copyRepo.FetchByBook(bookId)
+}
+
+func (s *BookCopyService) AddCopy(bookId string, req request.AddBookCopy, username string) (models.BookCopy, error) {
+	var newCopy models.BookCopy
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		book, err := s.bookRepo.GetByIdForUpdate(tx, bookId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("book not found")
+			}
+			return err
+		}
+
+		newCopy, err = storeCopy(tx, s.copyRepo, book, req, username)
+		if err != nil {
+			return err
+		}
+
+		// the new copy goes to the hold queue first
+		if err := releaseCopy(tx, s.bookRepo, s.copyRepo, s.holdRepo, s.notificationRepo, newCopy); err != nil {
+			return err
+		}
+
+		newCopy, err = s.copyRepo.GetByIdForUpdate(tx, newCopy.Id)
+		return err
+	})
+
+	return newCopy, err
+}
+
+func (s *BookCopyService) UpdateCopy(id string, req request.UpdateBookCopy, username string) (models.BookCopy, error) {
+	var updatedCopy models.BookCopy
+
+	current, err := s.copyRepo.GetById(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return updatedCopy, errors.New("copy not found")
+		}
+		return updatedCopy, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// the book is locked first, like every other flow that moves copies
+		if _, err := s.bookRepo.GetByIdForUpdate(tx, current.BookId); err != nil {
+			return err
+		}
+		bookCopy, err := s.copyRepo.GetByIdForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+
+		statusChanged := req.Status != "" && req.Status != bookCopy.Status
+		if statusChanged && (bookCopy.Status == utils.CopyOnLoan || bookCopy.Status == utils.CopyOnHold) {
+			return fmt.Errorf("copy is %s, its status cannot be changed until it is back", strings.ReplaceAll(bookCopy.Status, "_", " "))
+		}
+
+		copyDataUpdate := map[string]interface{}{
+			"updated_at": time.Now(),
+			"updated_by": username,
+		}
+		if req.Condition != "" {
+			copyDataUpdate["condition"] = req.Condition
+		}
+		if req.Location != "" {
+			copyDataUpdate["location"] = req.Location
+		}
+		if statusChanged && req.Status != utils.CopyAvailable {
+			copyDataUpdate["status"] = req.Status
+		}
+		if err := s.copyRepo.Update(tx, bookCopy, copyDataUpdate); err != nil {
+			return err
+		}
+
+		if statusChanged {
+			if req.Status == utils.CopyAvailable {
+				// a copy back from maintenance serves the hold queue first
+				if err := releaseCopy(tx, s.bookRepo, s.copyRepo, s.holdRepo, s.notificationRepo, bookCopy); err != nil {
+					return err
+				}
+			} else if err := s.bookRepo.SyncQuantity(tx, bookCopy.BookId); err != nil {
+				return err
+			}
+		}
+
+		updatedCopy, err = s.copyRepo.GetByIdForUpdate(tx, id)
+		return err
+	})
+
+	return updatedCopy, err
+}
+
+// storeCopy adds an available copy to a locked book. Copies without a barcode
+// get one made from the book's ISBN and the copy's sequence number.
+func storeCopy(tx *gorm.DB, copyRepo interfaces.BookCopy, book models.Book, req request.AddBookCopy, username string) (models.BookCopy, error) {
+	barcode := strings.TrimSpace(req.Barcode)
+	if barcode == "" {
+		count, err := copyRepo.CountByBook(tx, book.ID)
+		if err != nil {
+			return models.BookCopy{}, err
+		}
+		isbn := strings.NewReplacer("-", "", " ", "").Replace(book.ISBN)
+		barcode = fmt.Sprintf("%s-%03d", isbn, count+1)
+	}
+
+	condition := req.Condition
+	if condition == "" {
+		condition = "good"
+	}
+
+	return copyRepo.Store(tx, models.BookCopy{
+		Id:        utils.CreateUUID(),
+		BookId:    book.ID,
+		Barcode:   barcode,
+		Condition: condition,
+		Location:  req.Location,
+		Status:    utils.CopyAvailable,
+		CreatedAt: time.Now(),
+		CreatedBy: username,
+	})
+}
+
+// adjustCopies brings the number of available copies of a locked book to the
+// given quantity, adding copies or withdrawing the newest available ones.
+func adjustCopies(tx *gorm.DB, bookRepo interfaces.Book, copyRepo interfaces.BookCopy, holdRepo interfaces.Hold, notificationRepo interfaces.Notification, book models.Book, quantity int, username string) error {
+	available, err := copyRepo.CountAvailable(tx, book.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := int(available); i < quantity; i++ {
+		newCopy, err := storeCopy(tx, copyRepo, book, request.AddBookCopy{}, username)
+		if err != nil {
+			return err
+		}
+		if err := releaseCopy(tx, bookRepo, copyRepo, holdRepo, notificationRepo, newCopy); err != nil {
+			return err
+		}
+	}
+
+	if int(available) > quantity {
+		extra, err := copyRepo.FetchAvailableForUpdate(tx, book.ID, int(available)-quantity)
+		if err != nil {
+			return err
+		}
+		for _, bookCopy := range extra {
+			copyDataUpdate := map[string]interface{}{
+				"status":     utils.CopyWithdrawn,
+				"updated_at": time.Now(),
+				"updated_by": username,
+			}
+			if err := copyRepo.Update(tx, bookCopy, copyDataUpdate); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bookRepo.SyncQuantity(tx, book.ID)
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+var importColumns = []string{"title", "author", "isbn", "category", "quantity"}
+
+// errDryRun rolls back the batches of a dry run.
+var errDryRun = errors.New("dry run")
+
+// importLine is a row of an import file, with what is wrong with it already.
+// keepQuantity leaves the copies of an existing book as they are, for files
+// that say nothing about copies.
+type importLine struct {
+	row          int
+	req          request.AddBook
+	issues       []models.ImportIssue
+	keepQuantity bool
+}
+
+// ImportBooks reads books from CSV whose header names the columns title,
+// author, isbn, category and quantity in any order, checks every row with the
+// rules of POST /books, and creates or updates each book by ISBN. Rows are
+// saved in batches of IMPORT_BATCH_SIZE, one transaction each, and a row that
+// fails is rolled back alone. A dry run does all of it but commits nothing.
+func (s *BookService) ImportBooks(r io.Reader, dryRun bool, username string) (models.ImportReport, error) {
+	lines, err := readImportLines(r)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return s.importLines(lines, dryRun, username), nil
+}
+
+// importLines upserts the rows by ISBN in batches and reports on each of them.
+func (s *BookService) importLines(lines []importLine, dryRun bool, username string) models.ImportReport {
+	report := models.ImportReport{
+		DryRun: dryRun,
+		Total:  len(lines),
+		Rows:   make([]models.ImportRow, 0, len(lines)),
+	}
+
+	batchSize := utils.GetEnv("IMPORT_BATCH_SIZE", 100).(int)
+	seen := map[string]int{}
+	for start := 0; start < len(lines); start += batchSize {
+		batch := lines[start:min(start+batchSize, len(lines))]
+		report.Rows = append(report.Rows, s.importBatch(batch, seen, dryRun, username)...)
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case utils.ImportCreated:
+			report.Created++
+		case utils.ImportUpdated:
+			report.Updated++
+		case utils.ImportSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+	}
+
+	return report
+}
+
+// importBatch saves a batch of rows in one transaction, each row under its own
+// savepoint. When the batch itself cannot be committed, none of it is saved
+// and every row is reported as failed. seen holds the row that saved each
+// ISBN in earlier batches, so a later row with the same ISBN is rejected; a
+// row that failed does not count.
+func (s *BookService) importBatch(lines []importLine, seen map[string]int, dryRun bool, username string) []models.ImportRow {
+	rows := make([]models.ImportRow, len(lines))
+	added := map[string]int{}
+	var saved []models.Book
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		for i, line := range lines {
+			rows[i] = models.ImportRow{Row: line.row, ISBN: line.req.ISBN}
+
+			first, ok := seen[line.req.ISBN]
+			if !ok {
+				first, ok = added[line.req.ISBN]
+			}
+			if ok && len(line.issues) == 0 {
+				line.issues = []models.ImportIssue{{Field: "isbn", Message: fmt.Sprintf("Same ISBN as row %d", first)}}
+			}
+			if len(line.issues) > 0 {
+				rows[i].Status = utils.ImportError
+				rows[i].Errors = line.issues
+				continue
+			}
+
+			var (
+				status string
+				book   models.Book
+			)
+			err := tx.Transaction(func(rowTx *gorm.DB) error {
+				var err error
+				status, book, err = s.importBook(rowTx, line, username)
+				return err
+			})
+			if err != nil {
+				rows[i].Status = utils.ImportError
+				rows[i].Errors = []models.ImportIssue{importIssue(err)}
+				continue
+			}
+
+			added[line.req.ISBN] = line.row
+			rows[i].Status = status
+			if status != utils.ImportSkipped {
+				saved = append(saved, book)
+			}
+			if !dryRun || status != utils.ImportCreated {
+				rows[i].BookId = book.ID
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		utils.WriteLog(utils.LogLevelError, fmt.Sprintf("[Book][ImportBooks]; rows %d-%d not saved; Error: %+v", lines[0].row, lines[len(lines)-1].row, err))
+		for i := range rows {
+			if rows[i].Status != utils.ImportError {
+				rows[i].Status = utils.ImportError
+				rows[i].BookId = ""
+				rows[i].Errors = []models.ImportIssue{{Message: "Batch was not saved: " + err.Error()}}
+			}
+		}
+		return rows
+	}
+
+	for isbn, row := range added {
+		seen[isbn] = row
+	}
+	if !dryRun {
+		for _, book := range saved {
+			s.indexBook(book)
+		}
+	}
+
+	return rows
+}
+
+// importBook creates the book of a row, or updates the book with its ISBN
+// when anything differs.
+func (s *BookService) importBook(tx *gorm.DB, line importLine, username string) (string, models.Book, error) {
+	req := line.req
+	existing, err := s.bookRepo.GetByIsbnForUpdate(tx, req.ISBN)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		book, err := s.createBook(tx, req, username)
+		return utils.ImportCreated, book, err
+	}
+	if err != nil {
+		return "", models.Book{}, err
+	}
+	if line.keepQuantity {
+		req.Quantity = existing.Quantity
+	}
+
+	if existing.Title == req.Title && existing.Author == req.Author && existing.Category == req.Category && existing.Quantity == req.Quantity {
+		return utils.ImportSkipped, existing, nil
+	}
+
+	_, book, err := s.updateBook(tx, existing.ID, request.UpdateBook{
+		Title:    req.Title,
+		Author:   req.Author,
+		ISBN:     req.ISBN,
+		Category: req.Category,
+		Quantity: req.Quantity,
+	}, username)
+	return utils.ImportUpdated, book, err
+}
+
+// readImportLines parses the file and checks each row on its own. Problems
+// with the file as a whole, such as a missing column, fail the import.
+func readImportLines(r io.Reader) ([]importLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		// spreadsheets often save CSV with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the header has no %s column", name)
+		}
+	}
+
+	maxRows := utils.GetEnv("IMPORT_MAX_ROWS", 10000).(int)
+	var lines []importLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(lines) == maxRows {
+			return nil, fmt.Errorf("the file has more than %d rows", maxRows)
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			lines = append(lines, importLine{
+				row:    parseErr.StartLine,
+				issues: []models.ImportIssue{{Message: parseErr.Err.Error()}},
+			})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row, _ := reader.FieldPos(0)
+		lines = append(lines, newImportLine(row, record, columns))
+	}
+
+	return lines, nil
+}
+
+func newImportLine(row int, record []string, columns map[string]int) importLine {
+	field := func(name string) string {
+		if i := columns[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	line := importLine{
+		row: row,
+		req: request.AddBook{
+			Title:    field("title"),
+			Author:   field("author"),
+			ISBN:     field("isbn"),
+			Category: field("category"),
+		},
+	}
+
+	if quantity := field("quantity"); quantity != "" {
+		number, err := strconv.Atoi(quantity)
+		if err != nil {
+			line.issues = append(line.issues, models.ImportIssue{Field: "quantity", Message: "Should be a whole number"})
+		}
+		line.req.Quantity = number
+	}
+
+	validateImportLine(&line)
+	return line
+}
+
+// validateImportLine checks a row with the rules of request.AddBook. A field
+// that already has an issue is not reported twice. A valid ISBN is normalized,
+// so rows match the stored book whichever way the ISBN is written.
+func validateImportLine(line *importLine) {
+	if isbn, err := utils.NormalizeISBN(line.req.ISBN); err == nil {
+		line.req.ISBN = isbn
+	}
+
+	err := binding.Validator.ValidateStruct(line.req)
+	if err == nil {
+		return
+	}
+
+	reported := map[string]bool{}
+	for _, issue := range line.issues {
+		reported[issue.Field] = true
+	}
+	for _, message := range utils.ValidateError(err, reflect.TypeOf(line.req), "json") {
+		if !reported[message.Field] {
+			line.issues = append(line.issues, models.ImportIssue{Field: message.Field, Message: message.Message})
+		}
+	}
+}
+
+func importIssue(err error) models.ImportIssue {
+	// the ISBN is free among live books, so it belongs to a deleted one
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return models.ImportIssue{Field: "isbn", Message: "ISBN belongs to a deleted book"}
+	}
+	return models.ImportIssue{Message: err.Error()}
+}
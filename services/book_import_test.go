@@ -0,0 +1,329 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"errors"
+	"maps"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// catalog is the books, their author and category names and their copies
+// kept in memory by the fake repositories of an import. An author named
+// "Broken" cannot be linked, which fails its row after the book was stored.
+type catalog struct {
+	books      map[string]models.Book
+	authors    map[string]string
+	categories map[string]string
+	links      map[string][2]string
+	copies     map[string]int64
+}
+
+func newCatalog(books ...models.Book) *catalog {
+	c := &catalog{
+		books:      map[string]models.Book{},
+		authors:    map[string]string{},
+		categories: map[string]string{},
+		links:      map[string][2]string{},
+		copies:     map[string]int64{},
+	}
+	for _, book := range books {
+		c.books[book.ID] = book
+		c.copies[book.ID] = int64(book.Quantity)
+	}
+	return c
+}
+
+func (c *catalog) snapshot() (restore func()) {
+	books, authors, categories, links, copies := maps.Clone(c.books), maps.Clone(c.authors), maps.Clone(c.categories), maps.Clone(c.links), maps.Clone(c.copies)
+	return func() {
+		c.books, c.authors, c.categories, c.links, c.copies = books, authors, categories, links, copies
+	}
+}
+
+type catalogBooks struct {
+	interfaces.Book
+	*catalog
+}
+
+func (r catalogBooks) GetByIsbnForUpdate(_ *gorm.DB, isbn string) (models.Book, error) {
+	for _, book := range r.books {
+		if book.ISBN == isbn {
+			return book, nil
+		}
+	}
+	return models.Book{}, gorm.ErrRecordNotFound
+}
+
+func (r catalogBooks) GetByIdForUpdate(_ *gorm.DB, id string) (models.Book, error) {
+	book, ok := r.books[id]
+	if !ok {
+		return models.Book{}, gorm.ErrRecordNotFound
+	}
+	return book, nil
+}
+
+func (r catalogBooks) Store(_ *gorm.DB, book models.Book) error {
+	r.books[book.ID] = book
+	return nil
+}
+
+func (r catalogBooks) Update(_ *gorm.DB, m models.Book, data interface{}) (int64, error) {
+	book, ok := r.books[m.ID]
+	if !ok {
+		return 0, nil
+	}
+	book.Title = data.(models.Book).Title
+	r.books[m.ID] = book
+	return 1, nil
+}
+
+func (r catalogBooks) SyncNames(_ *gorm.DB, ids []string) error {
+	for _, id := range ids {
+		book := r.books[id]
+		book.Author, book.Category = r.links[id][0], r.links[id][1]
+		r.books[id] = book
+	}
+	return nil
+}
+
+func (r catalogBooks) SyncQuantity(_ *gorm.DB, id string) error {
+	book := r.books[id]
+	book.Quantity = int(r.copies[id])
+	r.books[id] = book
+	return nil
+}
+
+type catalogAuthors struct {
+	interfaces.Author
+	*catalog
+}
+
+func (r catalogAuthors) GetByName(_ *gorm.DB, name string) (models.Author, error) {
+	if id, ok := r.authors[name]; ok {
+		return models.Author{ID: id, Name: name}, nil
+	}
+	return models.Author{}, gorm.ErrRecordNotFound
+}
+
+func (r catalogAuthors) Store(_ *gorm.DB, author models.Author) error {
+	r.authors[author.Name] = author.ID
+	return nil
+}
+
+func (r catalogAuthors) LinkBook(_ *gorm.DB, bookId string, ids []string) error {
+	for name, id := range r.authors {
+		if id == ids[0] {
+			if name == "Broken" {
+				return errors.New("deadlock found when trying to get lock")
+			}
+			link := r.links[bookId]
+			link[0] = name
+			r.links[bookId] = link
+		}
+	}
+	return nil
+}
+
+func (r catalogAuthors) FetchByBooks(*gorm.DB, []string) (map[string][]models.Author, error) {
+	return nil, nil
+}
+
+type catalogCategories struct {
+	interfaces.Category
+	*catalog
+}
+
+func (r catalogCategories) GetByName(_ *gorm.DB, name string) (models.Category, error) {
+	if id, ok := r.categories[name]; ok {
+		return models.Category{ID: id, Name: name}, nil
+	}
+	return models.Category{}, gorm.ErrRecordNotFound
+}
+
+func (r catalogCategories) Store(_ *gorm.DB, category models.Category) error {
+	r.categories[category.Name] = category.ID
+	return nil
+}
+
+func (r catalogCategories) LinkBook(_ *gorm.DB, bookId string, ids []string) error {
+	for name, id := range r.categories {
+		if id == ids[0] {
+			link := r.links[bookId]
+			link[1] = name
+			r.links[bookId] = link
+		}
+	}
+	return nil
+}
+
+func (r catalogCategories) FetchByBooks(*gorm.DB, []string) (map[string][]models.Category, error) {
+	return nil, nil
+}
+
+type catalogCopies struct {
+	interfaces.BookCopy
+	*catalog
+}
+
+func (r catalogCopies) CountByBook(_ *gorm.DB, bookId string) (int64, error) {
+	return r.copies[bookId], nil
+}
+
+func (r catalogCopies) CountAvailable(_ *gorm.DB, bookId string) (int64, error) {
+	return r.copies[bookId], nil
+}
+
+func (r catalogCopies) Store(_ *gorm.DB, bookCopy models.BookCopy) (models.BookCopy, error) {
+	r.copies[bookCopy.BookId]++
+	return bookCopy, nil
+}
+
+type noEvents struct{ interfaces.Webhook }
+
+func (noEvents) StoreEvent(*gorm.DB, models.WebhookEvent) error { return nil }
+
+// newImportService imports into the catalog, holding the Dune of the tests
+// with two copies.
+func newImportService(t *testing.T) (*BookService, *catalog, *syncedIndex, *fakeDB) {
+	t.Helper()
+
+	books := newCatalog(models.Book{ID: "dune", Title: "Dune", Author: "Frank Herbert", ISBN: "9780000000019", Category: "Fiction", Quantity: 2})
+	books.links["dune"] = [2]string{"Frank Herbert", "Fiction"}
+	index := &syncedIndex{}
+	db, conn := newFakeDB(t, books)
+	service := NewBookService(catalogBooks{catalog: books}, catalogAuthors{catalog: books}, catalogCategories{catalog: books}, catalogCopies{catalog: books}, nil, nil, nil, noEvents{}, nil, index, db)
+
+	return service, books, index, conn
+}
+
+const importHeader = "title,author,isbn,category,quantity\n"
+
+type importResult struct {
+	status string
+	error  string
+}
+
+func importResults(report models.ImportReport) map[int]importResult {
+	results := map[int]importResult{}
+	for _, row := range report.Rows {
+		result := importResult{status: row.Status}
+		for _, issue := range row.Errors {
+			result.error += issue.Message
+		}
+		results[row.Row] = result
+	}
+	return results
+}
+
+func TestImportBooks(t *testing.T) {
+	t.Setenv("IMPORT_BATCH_SIZE", "2")
+	service, books, index, _ := newImportService(t)
+
+	report, err := service.ImportBooks(strings.NewReader(importHeader+
+		"Dune,Frank Herbert,9780000000019,Fiction,2\n"+
+		"Emma,Jane Austen,9780000000026,Fiction,1\n"+
+		"Dune Messiah,Frank Herbert,9780000000019,Fiction,2\n"+
+		"Ulysses,Broken,9780000000033,Fiction,1\n"+
+		"Ulysses,James Joyce,9780000000033,Fiction,1\n"+
+		"Emma,Jane Austen,978-0-00-000002-6,Fiction,1\n"+
+		"Dune,Frank Herbert,9780000000019,Fiction,2\n",
+	), false, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]importResult{
+		2: {status: utils.ImportSkipped},
+		3: {status: utils.ImportCreated},
+		// the same ISBN in the same batch
+		4: {status: utils.ImportError, error: "Same ISBN as row 2"},
+		// rolled back alone, with the book it stored
+		5: {status: utils.ImportError, error: "deadlock found when trying to get lock"},
+		// the failed row does not hold on to the ISBN
+		6: {status: utils.ImportCreated},
+		// the same ISBN in an earlier batch, written another way
+		7: {status: utils.ImportError, error: "Same ISBN as row 3"},
+		8: {status: utils.ImportError, error: "Same ISBN as row 2"},
+	}
+	if got := importResults(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if report.Created != 2 || report.Skipped != 1 || report.Failed != 4 {
+		t.Errorf("report = %d created, %d skipped, %d failed, want 2, 1 and 4", report.Created, report.Skipped, report.Failed)
+	}
+
+	var titles []string
+	for _, book := range books.books {
+		if book.ID != "dune" {
+			titles = append(titles, book.Title+" by "+book.Author)
+		}
+	}
+	if len(titles) != 2 || !strings.Contains(strings.Join(titles, ","), "Ulysses by James Joyce") {
+		t.Errorf("books added: %v, want Emma and Ulysses by James Joyce", titles)
+	}
+	if len(index.indexed) != 2 {
+		t.Errorf("indexed %v, want the two new books", index.indexed)
+	}
+}
+
+func TestImportBooksUpdate(t *testing.T) {
+	service, books, index, _ := newImportService(t)
+
+	report, err := service.ImportBooks(strings.NewReader(importHeader+"Dune (50th Anniversary),Frank Herbert,9780000000019,Fiction,2\n"), false, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row := report.Rows[0]; row.Status != utils.ImportUpdated || row.BookId != "dune" {
+		t.Errorf("row = %+v, want dune updated", row)
+	}
+	if title := books.books["dune"].Title; title != "Dune (50th Anniversary)" {
+		t.Errorf("title = %q after the import", title)
+	}
+	if !reflect.DeepEqual(index.indexed, []string{"dune"}) {
+		t.Errorf("indexed %v, want dune", index.indexed)
+	}
+}
+
+func TestImportBooksDryRun(t *testing.T) {
+	t.Setenv("IMPORT_BATCH_SIZE", "2")
+	service, books, index, conn := newImportService(t)
+	before := maps.Clone(books.books)
+
+	report, err := service.ImportBooks(strings.NewReader(importHeader+
+		"Dune (50th Anniversary),Frank Herbert,9780000000019,Fiction,2\n"+
+		"Emma,Jane Austen,9780000000026,Fiction,1\n"+
+		"Emma,Jane Austen,9780000000026,Fiction,1\n",
+	), true, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]importResult{
+		2: {status: utils.ImportUpdated},
+		3: {status: utils.ImportCreated},
+		// a dry run saves nothing, yet the ISBN is taken all the same
+		4: {status: utils.ImportError, error: "Same ISBN as row 3"},
+	}
+	if got := importResults(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if !report.DryRun || report.Rows[0].BookId != "dune" || report.Rows[1].BookId != "" {
+		t.Errorf("rows = %+v, want the id of dune and no id for the book not created", report.Rows)
+	}
+
+	if !reflect.DeepEqual(books.books, before) {
+		t.Errorf("books = %v after a dry run, want %v", books.books, before)
+	}
+	if len(index.indexed) != 0 {
+		t.Errorf("a dry run indexed %v", index.indexed)
+	}
+	if strings.Contains(strings.Join(conn.log, ";"), "COMMIT") {
+		t.Errorf("a dry run committed: %v", conn.log)
+	}
+}
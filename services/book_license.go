@@ -0,0 +1,166 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var errNoLicenseSeat = errors.New("all licensed copies of this e-book are on loan, you can place a hold on it instead")
+
+// SetLicense puts a digital title under a publisher license. Setting a license
+// starts it afresh, so the checkouts of a metered license count from zero.
+func (s *BookService) SetLicense(id string, req request.BookLicense, username string) (models.Book, error) {
+	var book models.Book
+
+	license, err := newLicense(req)
+	if err != nil {
+		return book, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		book, err = s.bookRepo.GetByIdForUpdate(tx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("book not found")
+			}
+			return err
+		}
+
+		bookDataUpdate := map[string]interface{}{
+			"license_type":          license.Type,
+			"license_seats":         license.Seats,
+			"license_max_checkouts": license.MaxCheckouts,
+			"license_checkouts":     0,
+			"license_expires_at":    license.ExpiresAt,
+			"updated_at":            time.Now(),
+			"updated_by":            username,
+		}
+		if _, err := s.bookRepo.Update(tx, book, bookDataUpdate); err != nil {
+			return err
+		}
+
+		book, err = s.bookRepo.GetByIdForUpdate(tx, id)
+		return err
+	})
+
+	return book, err
+}
+
+// ExpiringLicenses lists the digital titles whose license runs out within the
+// given number of days or has at most the given number of checkouts left.
+func (s *BookService) ExpiringLicenses(days, checkoutsLeft int) ([]models.LicenseReport, error) {
+	now := time.Now()
+	books, err := s.bookRepo.FetchExpiringLicenses(now.AddDate(0, 0, days), checkoutsLeft)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]models.LicenseReport, 0, len(books))
+	for _, book := range books {
+		report := models.LicenseReport{Book: book}
+
+		if report.ActiveLoans, err = s.lendingRepo.CountActiveDigitalByBook(s.DB, book.ID); err != nil {
+			return nil, err
+		}
+		if book.License.MaxCheckouts != nil {
+			remaining := max(*book.License.MaxCheckouts-book.License.Checkouts, 0)
+			report.RemainingCheckouts = &remaining
+		}
+		if book.License.ExpiresAt != nil {
+			daysLeft := max(int(math.Ceil(book.License.ExpiresAt.Sub(now).Hours()/24)), 0)
+			report.DaysLeft = &daysLeft
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// newLicense checks that a license carries the terms its type needs.
+// One-copy-one-user licenses lend each seat to one member at a time for good,
+// metered licenses run out after a number of checkouts, time-limited licenses
+// on a date, and simultaneous-use licenses lend to any number of members.
+func newLicense(req request.BookLicense) (models.License, error) {
+	license := models.License{Type: &req.Type}
+	one := 1
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return license, errors.New("expires_at must be in the future")
+	}
+
+	switch req.Type {
+	case utils.LicenseOneCopyOneUser:
+		if req.Seats == nil {
+			return license, errors.New("seats is required for a one-copy-one-user license")
+		}
+		license.Seats = req.Seats
+	case utils.LicenseMetered:
+		if req.MaxCheckouts == nil {
+			return license, errors.New("max_checkouts is required for a metered license")
+		}
+		license.Seats, license.MaxCheckouts, license.ExpiresAt = &one, req.MaxCheckouts, req.ExpiresAt
+		if req.Seats != nil {
+			license.Seats = req.Seats
+		}
+	case utils.LicenseTimeLimited:
+		if req.ExpiresAt == nil {
+			return license, errors.New("expires_at is required for a time-limited license")
+		}
+		license.Seats, license.ExpiresAt = &one, req.ExpiresAt
+		if req.Seats != nil {
+			license.Seats = req.Seats
+		}
+	case utils.LicenseSimultaneous:
+		license.ExpiresAt = req.ExpiresAt
+	}
+
+	return license, nil
+}
+
+// checkLicense refuses a digital loan once the license of a locked book has
+// expired or used up its checkouts, or when every seat is taken by a loan or
+// kept for a ready hold. A member claiming a ready hold already has a seat.
+func checkLicense(tx *gorm.DB, lendingRepo interfaces.Lending, holdRepo interfaces.Hold, book models.Book, claimHold bool) error {
+	license := book.License
+
+	if license.ExpiresAt != nil && time.Now().After(*license.ExpiresAt) {
+		return fmt.Errorf("the license for this e-book expired on %s", license.ExpiresAt.Format(time.DateOnly))
+	}
+	if license.MaxCheckouts != nil && license.Checkouts >= *license.MaxCheckouts {
+		return fmt.Errorf("the license for this e-book has used up all of its %d checkouts", *license.MaxCheckouts)
+	}
+	if claimHold || license.Seats == nil {
+		return nil
+	}
+
+	onLoan, err := lendingRepo.CountActiveDigitalByBook(tx, book.ID)
+	if err != nil {
+		return err
+	}
+	kept, err := holdRepo.CountReady(tx, book.ID)
+	if err != nil {
+		return err
+	}
+	if onLoan+kept >= int64(*license.Seats) {
+		return errNoLicenseSeat
+	}
+
+	return nil
+}
+
+// licensedDueDate keeps a digital loan from outliving the license it was lent under.
+func licensedDueDate(book models.Book, dueDate time.Time) time.Time {
+	if book.License.ExpiresAt != nil && book.License.ExpiresAt.Before(dueDate) {
+		return *book.License.ExpiresAt
+	}
+	return dueDate
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type seatLoans struct {
+	interfaces.Lending
+	onLoan int64
+}
+
+func (r seatLoans) CountActiveDigitalByBook(*gorm.DB, string) (int64, error) {
+	return r.onLoan, nil
+}
+
+type seatHolds struct {
+	interfaces.Hold
+	ready int64
+}
+
+func (r seatHolds) CountReady(*gorm.DB, string) (int64, error) {
+	return r.ready, nil
+}
+
+func TestCheckLicense(t *testing.T) {
+	two, three := 2, 3
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		license   models.License
+		onLoan    int64
+		ready     int64
+		claimHold bool
+		err       string
+	}{
+		{name: "no license"},
+		{name: "simultaneous use", license: models.License{ExpiresAt: &future}, onLoan: 100},
+		{name: "seat free", license: models.License{Seats: &two}, onLoan: 1},
+		{name: "seats on loan", license: models.License{Seats: &two}, onLoan: 2, err: errNoLicenseSeat.Error()},
+		{name: "seat kept for a ready hold", license: models.License{Seats: &two}, onLoan: 1, ready: 1, err: errNoLicenseSeat.Error()},
+		{name: "claiming the ready hold", license: models.License{Seats: &two}, onLoan: 1, ready: 1, claimHold: true},
+		{name: "checkouts left", license: models.License{Seats: &two, MaxCheckouts: &three, Checkouts: 2}},
+		{name: "checkouts used up", license: models.License{Seats: &two, MaxCheckouts: &three, Checkouts: 3}, err: "used up all of its 3 checkouts"},
+		{name: "checkouts used up claiming a hold", license: models.License{Seats: &two, MaxCheckouts: &three, Checkouts: 3}, claimHold: true, err: "used up all of its 3 checkouts"},
+		{name: "expired", license: models.License{Seats: &two, ExpiresAt: &past}, err: "expired on " + past.Format(time.DateOnly)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := models.Book{ID: "b1", License: tt.license}
+			err := checkLicense(nil, seatLoans{onLoan: tt.onLoan}, seatHolds{ready: tt.ready}, book, tt.claimHold)
+
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("checkLicense = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNewLicense(t *testing.T) {
+	one, five := 1, 5
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	license := func(licenseType string, seats, maxCheckouts *int, expiresAt *time.Time) models.License {
+		return models.License{Type: &licenseType, Seats: seats, MaxCheckouts: maxCheckouts, ExpiresAt: expiresAt}
+	}
+
+	tests := []struct {
+		name string
+		req  request.BookLicense
+		want models.License
+		err  string
+	}{
+		{name: "one copy one user", req: request.BookLicense{Type: utils.LicenseOneCopyOneUser, Seats: &five}, want: license(utils.LicenseOneCopyOneUser, &five, nil, nil)},
+		{name: "one copy one user without seats", req: request.BookLicense{Type: utils.LicenseOneCopyOneUser}, err: "seats is required"},
+		{name: "metered", req: request.BookLicense{Type: utils.LicenseMetered, MaxCheckouts: &five}, want: license(utils.LicenseMetered, &one, &five, nil)},
+		{name: "metered with seats and expiry", req: request.BookLicense{Type: utils.LicenseMetered, Seats: &five, MaxCheckouts: &five, ExpiresAt: &future}, want: license(utils.LicenseMetered, &five, &five, &future)},
+		{name: "metered without checkouts", req: request.BookLicense{Type: utils.LicenseMetered, Seats: &five}, err: "max_checkouts is required"},
+		{name: "time limited", req: request.BookLicense{Type: utils.LicenseTimeLimited, ExpiresAt: &future}, want: license(utils.LicenseTimeLimited, &one, nil, &future)},
+		{name: "time limited without expiry", req: request.BookLicense{Type: utils.LicenseTimeLimited}, err: "expires_at is required"},
+		{name: "simultaneous use", req: request.BookLicense{Type: utils.LicenseSimultaneous, Seats: &five, ExpiresAt: &future}, want: license(utils.LicenseSimultaneous, nil, nil, &future)},
+		{name: "expired", req: request.BookLicense{Type: utils.LicenseTimeLimited, ExpiresAt: &past}, err: "must be in the future"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newLicense(tt.req)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("newLicense = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newLicense = %s, want %s", describeLicense(got), describeLicense(tt.want))
+			}
+		})
+	}
+}
+
+// describeLicense spells out the terms a license points to.
+func describeLicense(license models.License) string {
+	terms := *license.Type
+	if license.Seats != nil {
+		terms += fmt.Sprintf(", %d seats", *license.Seats)
+	}
+	if license.MaxCheckouts != nil {
+		terms += fmt.Sprintf(", %d checkouts", *license.MaxCheckouts)
+	}
+	if license.ExpiresAt != nil {
+		terms += ", expires at " + license.ExpiresAt.String()
+	}
+	return terms
+}
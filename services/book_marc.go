@@ -0,0 +1,130 @@
+package services
+
+import (
+	"digital-book-lending/marc"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const marcExportBatchSize = 500
+
+// marcFields are the book fields read from MARC and where they come from.
+var marcFields = []struct {
+	field  string
+	source string
+}{
+	{"isbn", "020 $a"},
+	{"author", "100 $a"},
+	{"title", "245 $a"},
+	{"category", "650 $a"},
+}
+
+// ImportMarc creates or updates books by ISBN from MARC21 binary or MARCXML
+// records, the same way ImportBooks does. MARC says nothing about copies, so
+// a new book gets the given number of copies and an existing one keeps its
+// own. A record that cannot be read or mapped is reported with its position
+// in the file as the row.
+func (s *BookService) ImportMarc(r io.Reader, format string, copies int, dryRun bool, username string) (models.ImportReport, error) {
+	reader, err := marc.NewReader(r, format)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	maxRows := utils.GetEnv("IMPORT_MAX_ROWS", 10000).(int)
+	var lines []importLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(lines) == maxRows {
+			return models.ImportReport{}, fmt.Errorf("the file has more than %d records", maxRows)
+		}
+
+		var recordErr *marc.RecordError
+		if errors.As(err, &recordErr) {
+			lines = append(lines, importLine{
+				row:    recordErr.Index,
+				issues: []models.ImportIssue{{Message: recordErr.Error()}},
+			})
+			continue
+		}
+		if err != nil {
+			return models.ImportReport{}, err
+		}
+
+		lines = append(lines, marcImportLine(len(lines)+1, record, copies))
+	}
+
+	return s.importLines(lines, dryRun, username), nil
+}
+
+// ExportMarc writes the books matching the filter as MARC records, reading
+// them in batches by id. It returns how many records were written.
+func (s *BookService) ExportMarc(w io.Writer, format string, filter request.BookFilter) (int, error) {
+	writer, err := marc.NewWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	filter.OrderBy, filter.OrderDir = "id", "asc"
+	var (
+		after   *request.BookCursor
+		written int
+	)
+	for {
+		books, next, err := s.bookRepo.FetchAfter(marcExportBatchSize, filter, after)
+		if err != nil {
+			return written, err
+		}
+		if err := fillLinks(s.DB, s.authorRepo, s.categoryRepo, books); err != nil {
+			return written, err
+		}
+		for _, book := range books {
+			if err := writer.Write(marc.FromBook(book)); err != nil {
+				return written, fmt.Errorf("book %s: %w", book.ID, err)
+			}
+			written++
+		}
+		if next == nil {
+			break
+		}
+		after = next
+	}
+
+	return written, writer.Close()
+}
+
+func marcImportLine(row int, record marc.Record, copies int) importLine {
+	book := record.Book()
+	line := importLine{
+		row: row,
+		req: request.AddBook{
+			Title:    book.Title,
+			Author:   book.Author,
+			ISBN:     book.ISBN,
+			Category: book.Category,
+			Quantity: copies,
+		},
+		keepQuantity: true,
+	}
+
+	values := map[string]string{
+		"isbn":     book.ISBN,
+		"author":   book.Author,
+		"title":    book.Title,
+		"category": book.Category,
+	}
+	for _, field := range marcFields {
+		if values[field.field] == "" {
+			line.issues = append(line.issues, models.ImportIssue{Field: field.field, Message: "Record has no " + field.source})
+		}
+	}
+
+	validateImportLine(&line)
+	return line
+}
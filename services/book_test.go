@@ -0,0 +1,202 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils/request"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// harvestBooks lists books the way the books repository does for OAI-PMH: in
+// datestamp then id order, from the position the harvest got to.
+type harvestBooks struct {
+	interfaces.Book
+	books []models.Book
+}
+
+func (r harvestBooks) FetchChanged(limit int, harvest request.Harvest) (ret []models.Book, err error) {
+	for _, book := range r.books {
+		if harvest.From != nil && book.ChangedAt().Before(*harvest.From) {
+			continue
+		}
+		if harvest.After != nil {
+			at := book.ChangedAt()
+			if at.Before(*harvest.After) || at.Equal(*harvest.After) && book.ID <= harvest.AfterId {
+				continue
+			}
+		}
+		if len(ret) == limit {
+			break
+		}
+		ret = append(ret, book)
+	}
+	return ret, nil
+}
+
+func (r harvestBooks) CountChanged(request.Harvest) (int64, error) {
+	return int64(len(r.books)), nil
+}
+
+type noAuthors struct{ interfaces.Author }
+
+func (noAuthors) FetchByBooks(*gorm.DB, []string) (map[string][]models.Author, error) {
+	return nil, nil
+}
+
+type noCategories struct{ interfaces.Category }
+
+func (noCategories) FetchByBooks(*gorm.DB, []string) (map[string][]models.Category, error) {
+	return nil, nil
+}
+
+// changedBooks are in datestamp order: b2 and b3 changed at the same time,
+// b3 by being deleted, and b4 was never updated.
+func changedBooks() []models.Book {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 5, 1, hour, 0, 0, 0, time.UTC)
+	}
+	updated := func(hour int) *time.Time {
+		t := at(hour)
+		return &t
+	}
+
+	return []models.Book{
+		{ID: "b1", CreatedAt: at(1)},
+		{ID: "b2", CreatedAt: at(1), UpdatedAt: updated(3)},
+		{ID: "b3", CreatedAt: at(1), UpdatedAt: updated(2), DeletedAt: gorm.DeletedAt{Time: at(3), Valid: true}},
+		{ID: "b4", CreatedAt: at(4)},
+		{ID: "b5", CreatedAt: at(1), UpdatedAt: updated(5)},
+	}
+}
+
+func TestHarvestBooks(t *testing.T) {
+	books := changedBooks()
+	service := NewBookService(harvestBooks{books: books}, noAuthors{}, noCategories{}, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	var (
+		ids     []string
+		cursors []int
+	)
+	harvest := request.Harvest{MetadataPrefix: "oai_dc"}
+	for page := 0; ; page++ {
+		if page == len(books) {
+			t.Fatal("the harvest does not end")
+		}
+
+		got, totalData, next, err := service.HarvestBooks(harvest, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if totalData != int64(len(books)) {
+			t.Errorf("totalData = %d, want %d", totalData, len(books))
+		}
+		cursors = append(cursors, harvest.Cursor)
+		for _, book := range got {
+			ids = append(ids, book.ID)
+		}
+		if next == nil {
+			break
+		}
+
+		last := got[len(got)-1]
+		if !next.After.Equal(last.ChangedAt()) || next.AfterId != last.ID {
+			t.Errorf("next = %v %s, want the position of %s", next.After, next.AfterId, last.ID)
+		}
+		if harvest, err = request.DecodeHarvest(next.Token()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want := []string{"b1", "b2", "b3", "b4", "b5"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("harvested %v, want %v", ids, want)
+	}
+	if want := []int{0, 2, 4}; !reflect.DeepEqual(cursors, want) {
+		t.Errorf("cursors = %v, want %v", cursors, want)
+	}
+}
+
+func TestHarvestBooksFullLastPage(t *testing.T) {
+	books := changedBooks()[:4]
+	service := NewBookService(harvestBooks{books: books}, noAuthors{}, noCategories{}, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	got, _, next, err := service.HarvestBooks(request.Harvest{MetadataPrefix: "oai_dc"}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 || next != nil {
+		t.Errorf("HarvestBooks = %d books, next %+v, want 4 books and no resumption", len(got), next)
+	}
+}
+
+// syncedIndex is a search index that keeps the ids it was sent.
+type syncedIndex struct {
+	interfaces.SearchIndex
+	synced  time.Time
+	rebuilt bool
+	indexed []string
+	deleted []string
+}
+
+func (i *syncedIndex) Index(book models.Book) error {
+	i.indexed = append(i.indexed, book.ID)
+	return nil
+}
+
+func (i *syncedIndex) Delete(id string) error {
+	i.deleted = append(i.deleted, id)
+	return nil
+}
+
+func (i *syncedIndex) Rebuild(func() ([]models.Book, error)) (int, error) {
+	i.rebuilt, i.synced = true, time.Time{}
+	return 3, nil
+}
+
+func (i *syncedIndex) SyncedAt() (time.Time, error) { return i.synced, nil }
+
+func (i *syncedIndex) SetSyncedAt(at time.Time) error {
+	i.synced = at
+	return nil
+}
+
+func TestSyncSearchIndex(t *testing.T) {
+	t.Setenv("SEARCH_REINDEX_BATCH_SIZE", "2")
+	index := &syncedIndex{synced: time.Date(2024, 5, 1, 3, 3, 0, 0, time.UTC)}
+	service := NewBookService(harvestBooks{books: changedBooks()}, nil, nil, nil, nil, nil, nil, nil, nil, index, nil)
+
+	count, err := service.SyncSearchIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b2 and b3 changed at 3:00, before the last sync but within the overlap
+	if want := []string{"b2", "b4", "b5"}; !reflect.DeepEqual(index.indexed, want) {
+		t.Errorf("indexed %v, want %v", index.indexed, want)
+	}
+	if want := []string{"b3"}; !reflect.DeepEqual(index.deleted, want) {
+		t.Errorf("deleted %v, want %v", index.deleted, want)
+	}
+	if want := time.Date(2024, 5, 1, 5, 0, 0, 0, time.UTC); count != 4 || index.rebuilt || !index.synced.Equal(want) {
+		t.Errorf("SyncSearchIndex = %d, rebuilt %t, synced at %v, want 4 books and %v", count, index.rebuilt, index.synced, want)
+	}
+}
+
+func TestSyncSearchIndexNeverSynced(t *testing.T) {
+	index := &syncedIndex{}
+	service := NewBookService(harvestBooks{books: changedBooks()}, nil, nil, nil, nil, nil, nil, nil, nil, index, nil)
+
+	start := time.Now()
+	count, err := service.SyncSearchIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !index.rebuilt || count != 3 || index.synced.Before(start) {
+		t.Errorf("SyncSearchIndex = %d, rebuilt %t, synced at %v, want the index rebuilt and synced as of %v", count, index.rebuilt, index.synced, start)
+	}
+	if len(index.indexed) != 0 || len(index.deleted) != 0 {
+		t.Errorf("books from before the rebuild were synced again: %v %v", index.indexed, index.deleted)
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrUnknownCategory = errors.New("category_ids lists a category that does not exist")
+
+type CategoryService struct {
+	categoryRepo interfaces.Category
+	authorRepo   interfaces.Author
+	bookRepo     interfaces.Book
+	policyRepo   interfaces.LendingPolicy
+	webhookRepo  interfaces.Webhook
+	searchIndex  interfaces.SearchIndex
+	DB           *gorm.DB
+}
+
+func NewCategoryService(categoryRepo interfaces.Category, authorRepo interfaces.Author, bookRepo interfaces.Book, policyRepo interfaces.LendingPolicy, webhookRepo interfaces.Webhook, searchIndex interfaces.SearchIndex, db *gorm.DB) *CategoryService {
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+		authorRepo:   authorRepo,
+		bookRepo:     bookRepo,
+		policyRepo:   policyRepo,
+		webhookRepo:  webhookRepo,
+		searchIndex:  searchIndex,
+		DB:           db,
+	}
+}
+
+func (s *CategoryService) ListCategories(page, limit int, search string) ([]models.Category, int64, error) {
+	return s.categoryRepo.Fetch(page, limit, search)
+}
+
+func (s *CategoryService) GetCategory(id string) (models.Category, error) {
+	return s.categoryRepo.GetById(id)
+}
+
+func (s *CategoryService) CreateCategory(req request.Category, username string) (models.Category, error) {
+	category := models.Category{
+		ID:        utils.CreateUUID(),
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: time.Now(),
+		CreatedBy: username,
+	}
+
+	if err := s.categoryRepo.Store(s.DB, category); err != nil {
+		return models.Category{}, err
+	}
+
+	return category, nil
+}
+
+// UpdateCategory renames a category, along with the books in it and the
+// lending policies for it. It returns 0 rows when there is no such category.
+func (s *CategoryService) UpdateCategory(id string, req request.Category, username string) (int64, error) {
+	var (
+		rows  int64
+		books []models.Book
+	)
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		category, err := s.categoryRepo.GetByIdForUpdate(tx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		name := strings.TrimSpace(req.Name)
+		rows, err = s.categoryRepo.Update(tx, models.Category{ID: id}, map[string]interface{}{
+			"name":       name,
+			"updated_at": time.Now(),
+			"updated_by": username,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.policyRepo.RenameCategory(tx, []string{category.Name}, name); err != nil {
+			return err
+		}
+
+		bookIds, err := s.categoryRepo.FetchBookIds(tx, []string{id})
+		if err != nil {
+			return err
+		}
+		books, err = syncBookNames(tx, s.bookRepo, s.authorRepo, s.categoryRepo, s.webhookRepo, bookIds)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	indexBooks(s.searchIndex, books...)
+
+	return rows, nil
+}
+
+// DeleteCategory deletes a category no book in the catalog is in. It returns
+// 0 rows when there is no such category.
+func (s *CategoryService) DeleteCategory(id string) (int64, error) {
+	var rows int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.categoryRepo.GetByIdForUpdate(tx, id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		books, err := s.categoryRepo.CountBooks(tx, id)
+		if err != nil {
+			return err
+		}
+		if books > 0 {
+			return errors.New("the category still has books, merge it into another category instead")
+		}
+
+		rows, err = s.categoryRepo.Delete(tx, models.Category{ID: id})
+		return err
+	})
+
+	return rows, err
+}
+
+// MergeCategories folds the source categories into the category: their books
+// and lending policies move to it and the sources are deleted. A policy stays
+// where it was when its role already has one for the category. It returns the
+// category as it is now.
+func (s *CategoryService) MergeCategories(id string, req request.Merge, username string) (models.Category, error) {
+	var books []models.Book
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		category, err := s.categoryRepo.GetByIdForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(req.SourceIds, id) {
+			return errors.New("a category cannot be merged into itself")
+		}
+
+		sources, err := s.categoryRepo.FetchByIds(tx, req.SourceIds)
+		if err != nil {
+			return err
+		}
+		if len(sources) != len(req.SourceIds) {
+			return errors.New("source_ids lists a category that does not exist")
+		}
+
+		bookIds, err := s.categoryRepo.FetchBookIds(tx, req.SourceIds)
+		if err != nil {
+			return err
+		}
+		if err := s.categoryRepo.Merge(tx, id, req.SourceIds); err != nil {
+			return err
+		}
+		if _, err := s.categoryRepo.Update(tx, models.Category{ID: id}, map[string]interface{}{"updated_at": time.Now(), "updated_by": username}); err != nil {
+			return err
+		}
+
+		names := make([]string, len(sources))
+		for i, source := range sources {
+			names[i] = source.Name
+		}
+		if err := s.policyRepo.RenameCategory(tx, names, category.Name); err != nil {
+			return err
+		}
+
+		books, err = syncBookNames(tx, s.bookRepo, s.authorRepo, s.categoryRepo, s.webhookRepo, bookIds)
+		return err
+	})
+	if err != nil {
+		return models.Category{}, err
+	}
+	indexBooks(s.searchIndex, books...)
+
+	return s.categoryRepo.GetById(id)
+}
+
+// bookCategories are the categories a book is given: the ones listed by id,
+// or else the one named, added when the catalog has no category by that name.
+func bookCategories(tx *gorm.DB, categoryRepo interfaces.Category, ids []string, name, username string) ([]string, error) {
+	if len(ids) > 0 {
+		categories, err := categoryRepo.FetchByIds(tx, ids)
+		if err != nil {
+			return nil, err
+		}
+		if len(categories) != len(ids) {
+			return nil, ErrUnknownCategory
+		}
+		return ids, nil
+	}
+
+	category, err := categoryRepo.GetByName(tx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		category = models.Category{
+			ID:        utils.CreateUUID(),
+			Name:      strings.TrimSpace(name),
+			CreatedAt: time.Now(),
+			CreatedBy: username,
+		}
+		err = categoryRepo.Store(tx, category)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{category.ID}, nil
+}
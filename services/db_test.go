@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// snapshotter is the state of fake repositories that a transaction can roll
+// back: snapshot copies it and the returned func puts the copy back.
+type snapshotter interface {
+	snapshot() (restore func())
+}
+
+// fakeDB runs the transactions of a service whose repositories are fakes. It
+// keeps the statements it was sent, and snapshots the state, when there is
+// one, at every BEGIN and SAVEPOINT to put it back on rollback.
+type fakeDB struct {
+	state snapshotter
+	log   []string
+	saved []savepoint
+}
+
+type savepoint struct {
+	name    string
+	restore func()
+}
+
+func newFakeDB(t *testing.T, state snapshotter) (*gorm.DB, *fakeDB) {
+	t.Helper()
+
+	conn := &fakeDB{state: state}
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sql.OpenDB(conn), SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, conn
+}
+
+func (c *fakeDB) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *fakeDB) Driver() driver.Driver                        { return nil }
+func (c *fakeDB) Close() error                                 { return nil }
+
+func (c *fakeDB) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+func (c *fakeDB) Begin() (driver.Tx, error) {
+	c.log = append(c.log, "BEGIN")
+	c.save("")
+	return fakeTx{c}, nil
+}
+
+func (c *fakeDB) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.log = append(c.log, query)
+	switch {
+	case strings.HasPrefix(query, "SAVEPOINT "):
+		c.save(strings.TrimPrefix(query, "SAVEPOINT "))
+	case strings.HasPrefix(query, "ROLLBACK TO SAVEPOINT "):
+		c.rollbackTo(strings.TrimPrefix(query, "ROLLBACK TO SAVEPOINT "))
+	default:
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeDB) save(name string) {
+	point := savepoint{name: name, restore: func() {}}
+	if c.state != nil {
+		point.restore = c.state.snapshot()
+	}
+	c.saved = append(c.saved, point)
+}
+
+// rollbackTo puts back the state of the savepoint, or of the transaction for
+// "", and forgets the savepoints taken after it.
+func (c *fakeDB) rollbackTo(name string) {
+	for i := len(c.saved) - 1; i >= 0; i-- {
+		if c.saved[i].name == name {
+			c.saved[i].restore()
+			c.saved = c.saved[:i+1]
+			return
+		}
+	}
+}
+
+type fakeTx struct {
+	conn *fakeDB
+}
+
+func (tx fakeTx) Commit() error {
+	tx.conn.log = append(tx.conn.log, "COMMIT")
+	tx.conn.saved = nil
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.conn.log = append(tx.conn.log, "ROLLBACK")
+	tx.conn.rollbackTo("")
+	tx.conn.saved = nil
+	return nil
+}
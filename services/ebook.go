@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type EbookService struct {
+	lendingRepo interfaces.Lending
+	storage     interfaces.Storage
+}
+
+func NewEbookService(lendingRepo interfaces.Lending, storage interfaces.Storage) *EbookService {
+	return &EbookService{
+		lendingRepo: lendingRepo,
+		storage:     storage,
+	}
+}
+
+// GetAccessLink signs a link to the e-book of an active loan. The link expires
+// after EBOOK_LINK_TTL_MINUTES, or when the loan is due if that comes first.
+func (s *EbookService) GetAccessLink(lendingId, userId string) (models.EbookLink, error) {
+	record, err := s.getReadableLending(lendingId)
+	if err != nil {
+		return models.EbookLink{}, err
+	}
+	if record.UserId != userId {
+		return models.EbookLink{}, errors.New("you are not authorized to read this book")
+	}
+
+	ttl := utils.GetEnv("EBOOK_LINK_TTL_MINUTES", 60).(int)
+	expiresAt := time.Now().Add(time.Duration(ttl) * time.Minute)
+	if record.DueDate.Before(expiresAt) {
+		expiresAt = record.DueDate
+	}
+	expires := expiresAt.Unix()
+
+	return models.EbookLink{
+		LendingId: record.Id,
+		Url: fmt.Sprintf("%s/api/v1/ebooks/%s?expires=%d&signature=%s",
+			utils.GetEnv("APP_URL", "").(string), record.Id, expires, utils.SignEbookLink(record.Id, expires)),
+		ExpiresAt: time.Unix(expires, 0),
+	}, nil
+}
+
+// OpenEbook checks a signed link and opens the e-book file of the loan from
+// storage. The loan is checked again, so returning the book or letting it
+// become due revokes every link that was handed out for it.
+func (s *EbookService) OpenEbook(ctx context.Context, lendingId string, expires int64, signature string) (models.Book, io.ReadSeekCloser, error) {
+	if !utils.VerifyEbookLink(lendingId, expires, signature) {
+		return models.Book{}, nil, errors.New("invalid e-book link")
+	}
+	if time.Now().Unix() > expires {
+		return models.Book{}, nil, errors.New("e-book link has expired")
+	}
+
+	record, err := s.getReadableLending(lendingId)
+	if err != nil {
+		return models.Book{}, nil, err
+	}
+
+	obj, err := s.storage.Open(ctx, *record.Book.Ebook.Key)
+	return *record.Book, obj, err
+}
+
+func (s *EbookService) getReadableLending(lendingId string) (models.LendingRecord, error) {
+	record, err := s.lendingRepo.GetById(lendingId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return record, errors.New("lending record not found")
+		}
+		return record, err
+	}
+
+	if !record.IsDigital {
+		return record, errors.New("this loan is not an e-book loan")
+	}
+	if record.Status != utils.Borrowed || time.Now().After(record.DueDate) {
+		return record, errors.New("loan is no longer active, access to this e-book has been revoked")
+	}
+	if record.Book == nil || record.Book.Ebook.Key == nil {
+		return record, errors.New("this book has no e-book file")
+	}
+
+	return record, nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type ebookLending struct {
+	interfaces.Lending
+	record models.LendingRecord
+}
+
+func (r ebookLending) GetById(string) (models.LendingRecord, error) {
+	return r.record, nil
+}
+
+type ebookStorage struct{ interfaces.Storage }
+
+func (ebookStorage) Open(context.Context, string) (io.ReadSeekCloser, error) {
+	return nopCloser{strings.NewReader("%PDF")}, nil
+}
+
+type nopCloser struct{ io.ReadSeeker }
+
+func (nopCloser) Close() error { return nil }
+
+func newEbookService(t *testing.T, dueDate time.Time) *EbookService {
+	t.Helper()
+	t.Setenv("EBOOK_LINK_KEY", "ebook-key")
+	t.Setenv("EBOOK_LINK_TTL_MINUTES", "60")
+
+	key := "ebooks/b1/e1.pdf"
+	return NewEbookService(ebookLending{record: models.LendingRecord{
+		Id:        "l1",
+		UserId:    "u1",
+		IsDigital: true,
+		DueDate:   dueDate,
+		Status:    utils.Borrowed,
+		Book:      &models.Book{ID: "b1", Ebook: models.Asset{Key: &key}},
+	}}, ebookStorage{})
+}
+
+// linkQuery reads the expiry and signature off an e-book link.
+func linkQuery(t *testing.T, link models.EbookLink) (int64, string) {
+	t.Helper()
+
+	u, err := url.Parse(link.Url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expires, u.Query().Get("signature")
+}
+
+func TestEbookLink(t *testing.T) {
+	service := newEbookService(t, time.Now().Add(24*time.Hour))
+
+	link, err := service.GetAccessLink("l1", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(link.ExpiresAt); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("link expires in %v, want EBOOK_LINK_TTL_MINUTES", d)
+	}
+	expires, signature := linkQuery(t, link)
+
+	book, obj, err := service.OpenEbook(context.Background(), "l1", expires, signature)
+	if err != nil {
+		t.Fatalf("OpenEbook: %v", err)
+	}
+	obj.Close()
+	if book.ID != "b1" {
+		t.Errorf("OpenEbook book = %s, want b1", book.ID)
+	}
+
+	if _, _, err := service.OpenEbook(context.Background(), "l1", expires+3600, signature); err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("OpenEbook with a later expiry = %v, want an invalid link", err)
+	}
+	if _, _, err := service.OpenEbook(context.Background(), "l2", expires, signature); err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("OpenEbook of another loan = %v, want an invalid link", err)
+	}
+}
+
+func TestEbookLinkExpiry(t *testing.T) {
+	dueDate := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	service := newEbookService(t, dueDate)
+
+	link, err := service.GetAccessLink("l1", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !link.ExpiresAt.Equal(dueDate) {
+		t.Errorf("link expires at %v, want the due date %v", link.ExpiresAt, dueDate)
+	}
+
+	expired := time.Now().Add(-time.Second).Unix()
+	_, _, err = service.OpenEbook(context.Background(), "l1", expired, utils.SignEbookLink("l1", expired))
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("OpenEbook of an expired link = %v, want it expired", err)
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FineService struct {
+	fineRepo interfaces.Fine
+	userRepo interfaces.Users
+	DB       *gorm.DB
+}
+
+func NewFineService(fineRepo interfaces.Fine, userRepo interfaces.Users, db *gorm.DB) *FineService {
+	return &FineService{
+		fineRepo: fineRepo,
+		userRepo: userRepo,
+		DB:       db,
+	}
+}
+
+func (s *FineService) GetUserFines(userId string) (models.FineSummary, error) {
+	if _, err := s.userRepo.GetById(userId); err != nil {
+		return models.FineSummary{}, err
+	}
+
+	balance, err := s.fineRepo.GetBalance(s.DB, userId)
+	if err != nil {
+		return models.FineSummary{}, err
+	}
+
+	ledger, err := s.fineRepo.FetchByUser(userId)
+	if err != nil {
+		return models.FineSummary{}, err
+	}
+
+	return models.FineSummary{UserId: userId, Balance: balance, Ledger: ledger}, nil
+}
+
+func (s *FineService) RecordPayment(userId string, req request.FinePayment, username string) (models.Fine, error) {
+	return s.settle(userId, utils.FinePayment, req.Amount, req.Note, username)
+}
+
+func (s *FineService) WaiveFine(userId string, req request.FineWaiver, username string) (models.Fine, error) {
+	return s.settle(userId, utils.FineWaiver, req.Amount, req.Reason, username)
+}
+
+func (s *FineService) settle(userId, fineType string, amount models.Money, reason, username string) (models.Fine, error) {
+	var newFine models.Fine
+
+	if _, err := s.userRepo.GetById(userId); err != nil {
+		return models.Fine{}, err
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		balance, err := s.fineRepo.GetBalanceForUpdate(tx, userId)
+		if err != nil {
+			return err
+		}
+		if amount > balance {
+			return fmt.Errorf("amount %s exceeds the outstanding balance of %s", amount, balance)
+		}
+
+		fine := models.Fine{
+			Id:        utils.CreateUUID(),
+			UserId:    userId,
+			Type:      fineType,
+			Amount:    amount,
+			Reason:    reason,
+			CreatedAt: time.Now(),
+			CreatedBy: username,
+		}
+
+		newFine, err = s.fineRepo.Store(tx, fine)
+		return err
+	})
+
+	return newFine, err
+}
+
+// checkFineBalance refuses new loans once the member's outstanding fines pass
+// FINE_BLOCK_THRESHOLD.
+func checkFineBalance(tx *gorm.DB, fineRepo interfaces.Fine, userId string) error {
+	balance, err := fineRepo.GetBalance(tx, userId)
+	if err != nil {
+		return err
+	}
+
+	threshold := models.Money(utils.GetEnv("FINE_BLOCK_THRESHOLD", 50000).(int) * 100)
+	if balance > threshold {
+		return fmt.Errorf("outstanding fines of %s exceed the limit of %s, please settle them before borrowing", balance, threshold)
+	}
+
+	return nil
+}
+
+// chargeOverdueFine records a charge in the fines ledger when a loan comes back
+// after its due date.
+func chargeOverdueFine(tx *gorm.DB, fineRepo interfaces.Fine, record models.LendingRecord, category string, returnedAt time.Time) error {
+	days := daysOverdue(record.DueDate, returnedAt)
+	if days < 1 {
+		return nil
+	}
+
+	fine := models.Fine{
+		Id:          utils.CreateUUID(),
+		UserId:      record.UserId,
+		LendingId:   &record.Id,
+		Type:        utils.FineCharge,
+		Amount:      models.Money(days) * fineRate(category),
+		DaysOverdue: days,
+		Reason:      fmt.Sprintf("returned %d day(s) late", days),
+		CreatedAt:   returnedAt,
+		CreatedBy:   "system",
+	}
+
+	_, err := fineRepo.Store(tx, fine)
+	return err
+}
+
+func daysOverdue(dueDate, returnedAt time.Time) int {
+	if !returnedAt.After(dueDate) {
+		return 0
+	}
+
+	return int(math.Ceil(returnedAt.Sub(dueDate).Hours() / 24))
+}
+
+// fineRate returns the per-day fine for a book category. FINE_RATE_CATEGORIES
+// overrides FINE_RATE_PER_DAY per category, e.g. "reference:5000,children:500".
+func fineRate(category string) models.Money {
+	rate := models.Money(utils.GetEnv("FINE_RATE_PER_DAY", 1000).(int) * 100)
+
+	for _, pair := range strings.Split(utils.GetEnv("FINE_RATE_CATEGORIES", "").(string), ",") {
+		name, value, ok := strings.Cut(pair, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(category)) {
+			continue
+		}
+		if categoryRate, err := models.ParseMoney(value); err == nil {
+			return categoryRate
+		}
+	}
+
+	return rate
+}
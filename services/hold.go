@@ -0,0 +1,285 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type HoldService struct {
+	holdRepo         interfaces.Hold
+	bookRepo         interfaces.Book
+	copyRepo         interfaces.BookCopy
+	lendingRepo      interfaces.Lending
+	notificationRepo interfaces.Notification
+	DB               *gorm.DB
+}
+
+func NewHoldService(holdRepo interfaces.Hold, bookRepo interfaces.Book, copyRepo interfaces.BookCopy, lendingRepo interfaces.Lending, notificationRepo interfaces.Notification, db *gorm.DB) *HoldService {
+	return &HoldService{
+		holdRepo:         holdRepo,
+		bookRepo:         bookRepo,
+		copyRepo:         copyRepo,
+		lendingRepo:      lendingRepo,
+		notificationRepo: notificationRepo,
+		DB:               db,
+	}
+}
+
+func (s *HoldService) PlaceHold(bookId, userId string) (models.Hold, error) {
+	var newHold models.Hold
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		book, err := s.bookRepo.GetByIdForUpdate(tx, bookId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("book not found")
+			}
+			return err
+		}
+
+		if _, err := expireLapsedHolds(tx, s.bookRepo, s.copyRepo, s.holdRepo, s.notificationRepo, bookId); err != nil {
+			return err
+		}
+
+		if book.License.Type != nil {
+			// an expired or used-up license will not free a seat again
+			err := checkLicense(tx, s.lendingRepo, s.holdRepo, book, false)
+			if err == nil {
+				return errors.New("book is available, borrow it instead of placing a hold")
+			}
+			if !errors.Is(err, errNoLicenseSeat) {
+				return err
+			}
+		} else {
+			available, err := s.copyRepo.CountAvailable(tx, bookId)
+			if err != nil {
+				return err
+			}
+			if available > 0 {
+				return errors.New("book is available, borrow it instead of placing a hold")
+			}
+		}
+
+		_, err = s.lendingRepo.GetActiveByUserAndBook(tx, userId, bookId)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("you have already borrowed this book")
+		}
+
+		_, err = s.holdRepo.GetActiveByUserAndBook(tx, userId, bookId)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("you already have a hold on this book")
+		}
+
+		hold := models.Hold{
+			Id:        utils.CreateUUID(),
+			UserId:    userId,
+			BookId:    bookId,
+			Status:    utils.HoldWaiting,
+			CreatedAt: time.Now(),
+		}
+
+		newHold, err = s.holdRepo.Store(tx, hold)
+		if err != nil {
+			return err
+		}
+
+		newHold.Position, err = s.holdRepo.GetQueuePosition(tx, newHold)
+		return err
+	})
+
+	return newHold, err
+}
+
+func (s *HoldService) ListHolds(userId string) ([]models.Hold, error) {
+	holds, err := s.holdRepo.FetchByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range holds {
+		if holds[i].Status != utils.HoldWaiting {
+			continue
+		}
+		if holds[i].Position, err = s.holdRepo.GetQueuePosition(s.DB, holds[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return holds, nil
+}
+
+// CancelHold locks the book before the hold, in the same order as borrowing
+// and the hold queue, so they cannot deadlock.
+func (s *HoldService) CancelHold(holdId, userId string) error {
+	hold, err := s.holdRepo.GetById(holdId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("hold not found")
+		}
+		return err
+	}
+	if hold.UserId != userId {
+		return errors.New("you are not authorized to cancel this hold")
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.bookRepo.GetByIdForUpdate(tx, hold.BookId); err != nil {
+			return err
+		}
+		hold, err := s.holdRepo.GetByIdForUpdate(tx, holdId)
+		if err != nil {
+			return err
+		}
+		if hold.Status != utils.HoldWaiting && hold.Status != utils.HoldReady {
+			return errors.New("hold is no longer active")
+		}
+
+		if err := s.holdRepo.Update(tx, hold, map[string]interface{}{"status": utils.HoldCancelled}); err != nil {
+			return err
+		}
+
+		if hold.Status == utils.HoldReady {
+			return releaseHold(tx, s.bookRepo, s.copyRepo, s.holdRepo, s.notificationRepo, hold)
+		}
+
+		return nil
+	})
+}
+
+// ExpireLapsedHolds expires every ready hold whose pickup window has passed and
+// hands the copy that was set aside to the next member in the queue. A hold
+// that cannot be expired is logged and skipped so it does not hold up the
+// others; the errors are joined together.
+func (s *HoldService) ExpireLapsedHolds() (int, error) {
+	lapsed, err := s.holdRepo.FetchLapsed(s.DB, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		expired int
+		errs    []error
+	)
+	for _, hold := range lapsed {
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			if _, err := s.bookRepo.GetByIdForUpdate(tx, hold.BookId); err != nil {
+				return err
+			}
+			count, err := expireLapsedHolds(tx, s.bookRepo, s.copyRepo, s.holdRepo, s.notificationRepo, hold.BookId)
+			expired += count
+			return err
+		})
+		if err != nil {
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("[Hold][ExpireLapsedHolds][%s]; expireLapsedHolds; Error: %+v", hold.Id, err))
+			errs = append(errs, fmt.Errorf("hold %s: %w", hold.Id, err))
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// releaseCopy gives a copy of a locked book back: it is set aside for the first
+// member waiting in the hold queue, or put back on the shelf when nobody is waiting.
+func releaseCopy(tx *gorm.DB, bookRepo interfaces.Book, copyRepo interfaces.BookCopy, holdRepo interfaces.Hold, notificationRepo interfaces.Notification, bookCopy models.BookCopy) error {
+	next, err := holdRepo.GetNextWaiting(tx, bookCopy.BookId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err == nil {
+		if err := copyRepo.Update(tx, bookCopy, map[string]interface{}{"status": utils.CopyOnHold}); err != nil {
+			return err
+		}
+		if err := markHoldReady(tx, holdRepo, notificationRepo, next, &bookCopy.Id); err != nil {
+			return err
+		}
+	} else {
+		if err := copyRepo.Update(tx, bookCopy, map[string]interface{}{"status": utils.CopyAvailable}); err != nil {
+			return err
+		}
+	}
+
+	return bookRepo.SyncQuantity(tx, bookCopy.BookId)
+}
+
+// releaseSeat gives a licensed seat of a digital book back to the first member
+// waiting in the hold queue. Nothing is set aside, the hold just becomes ready.
+func releaseSeat(tx *gorm.DB, holdRepo interfaces.Hold, notificationRepo interfaces.Notification, bookId string) error {
+	next, err := holdRepo.GetNextWaiting(tx, bookId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return markHoldReady(tx, holdRepo, notificationRepo, next, nil)
+}
+
+// releaseHold gives back what a ready hold was keeping: a copy, or a licensed
+// seat for digital books.
+func releaseHold(tx *gorm.DB, bookRepo interfaces.Book, copyRepo interfaces.BookCopy, holdRepo interfaces.Hold, notificationRepo interfaces.Notification, hold models.Hold) error {
+	if hold.CopyId == nil {
+		return releaseSeat(tx, holdRepo, notificationRepo, hold.BookId)
+	}
+
+	bookCopy, err := lockCopy(tx, copyRepo, hold.CopyId)
+	if err != nil {
+		return err
+	}
+	return releaseCopy(tx, bookRepo, copyRepo, holdRepo, notificationRepo, bookCopy)
+}
+
+// markHoldReady starts the pickup window of a hold and lets the member know.
+func markHoldReady(tx *gorm.DB, holdRepo interfaces.Hold, notificationRepo interfaces.Notification, hold models.Hold, copyId *string) error {
+	now := time.Now()
+	pickupDays := utils.GetEnv("HOLD_PICKUP_DAYS", 3).(int)
+	expiresAt := now.AddDate(0, 0, pickupDays)
+	holdDataUpdate := map[string]interface{}{
+		"status":     utils.HoldReady,
+		"copy_id":    copyId,
+		"ready_at":   now,
+		"expires_at": expiresAt,
+	}
+	if err := holdRepo.Update(tx, hold, holdDataUpdate); err != nil {
+		return err
+	}
+
+	_, err := queueNotification(tx, notificationRepo, utils.NotifyHoldReady, hold.UserId, hold.BookId, hold.Id, expiresAt)
+	return err
+}
+
+// lockCopy locks the copy a lending record or hold points at.
+func lockCopy(tx *gorm.DB, copyRepo interfaces.BookCopy, copyId *string) (models.BookCopy, error) {
+	if copyId == nil {
+		return models.BookCopy{}, errors.New("no copy is attached to this record")
+	}
+
+	return copyRepo.GetByIdForUpdate(tx, *copyId)
+}
+
+// expireLapsedHolds expires the ready holds of a locked book whose pickup window
+// has passed and releases what was kept for each of them.
+func expireLapsedHolds(tx *gorm.DB, bookRepo interfaces.Book, copyRepo interfaces.BookCopy, holdRepo interfaces.Hold, notificationRepo interfaces.Notification, bookId string) (int, error) {
+	lapsed, err := holdRepo.FetchLapsedByBook(tx, bookId, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, hold := range lapsed {
+		if err := holdRepo.Update(tx, hold, map[string]interface{}{"status": utils.HoldExpired}); err != nil {
+			return 0, err
+		}
+		if err := releaseHold(tx, bookRepo, copyRepo, holdRepo, notificationRepo, hold); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(lapsed), nil
+}
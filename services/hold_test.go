@@ -0,0 +1,143 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockOrder records the reads and locks of holds and books in order.
+type lockOrder struct {
+	calls []string
+}
+
+type orderedHolds struct {
+	interfaces.Hold
+	order *lockOrder
+	hold  models.Hold
+}
+
+func (r orderedHolds) GetById(string) (models.Hold, error) {
+	r.order.calls = append(r.order.calls, "read hold")
+	return r.hold, nil
+}
+
+func (r orderedHolds) GetByIdForUpdate(*gorm.DB, string) (models.Hold, error) {
+	r.order.calls = append(r.order.calls, "lock hold")
+	return r.hold, nil
+}
+
+func (r orderedHolds) Update(*gorm.DB, models.Hold, interface{}) error {
+	r.order.calls = append(r.order.calls, "update hold")
+	return nil
+}
+
+type orderedBooks struct {
+	interfaces.Book
+	order *lockOrder
+}
+
+func (r orderedBooks) GetByIdForUpdate(*gorm.DB, string) (models.Book, error) {
+	r.order.calls = append(r.order.calls, "lock book")
+	return models.Book{ID: "b1"}, nil
+}
+
+func TestCancelHoldLocksTheBookFirst(t *testing.T) {
+	tests := []struct {
+		name   string
+		userId string
+		status string
+		calls  []string
+		err    string
+	}{
+		{name: "waiting", userId: "u1", status: utils.HoldWaiting, calls: []string{"read hold", "lock book", "lock hold", "update hold"}},
+		{name: "someone else's", userId: "u2", status: utils.HoldWaiting, calls: []string{"read hold"}, err: "not authorized"},
+		{name: "cancelled", userId: "u1", status: utils.HoldCancelled, calls: []string{"read hold", "lock book", "lock hold"}, err: "no longer active"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &lockOrder{}
+			db, _ := newFakeDB(t, nil)
+			hold := models.Hold{Id: "h1", UserId: "u1", BookId: "b1", Status: tt.status}
+			service := NewHoldService(orderedHolds{order: order, hold: hold}, orderedBooks{order: order}, nil, nil, nil, db)
+
+			err := service.CancelHold("h1", tt.userId)
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("CancelHold = %v, want %q", err, tt.err)
+			}
+			if !reflect.DeepEqual(order.calls, tt.calls) {
+				t.Errorf("calls = %v, want %v", order.calls, tt.calls)
+			}
+		})
+	}
+}
+
+// lapsedHolds are ready holds of e-books whose pickup window has passed, with
+// nobody waiting after them.
+type lapsedHolds struct {
+	interfaces.Hold
+	holds   []models.Hold
+	expired *[]string
+}
+
+func (r lapsedHolds) FetchLapsed(*gorm.DB, time.Time) ([]models.Hold, error) {
+	return r.holds, nil
+}
+
+func (r lapsedHolds) FetchLapsedByBook(_ *gorm.DB, bookId string, _ time.Time) (ret []models.Hold, err error) {
+	for _, hold := range r.holds {
+		if hold.BookId == bookId {
+			ret = append(ret, hold)
+		}
+	}
+	return ret, nil
+}
+
+func (r lapsedHolds) Update(_ *gorm.DB, hold models.Hold, _ interface{}) error {
+	*r.expired = append(*r.expired, hold.Id)
+	return nil
+}
+
+func (r lapsedHolds) GetNextWaiting(*gorm.DB, string) (models.Hold, error) {
+	return models.Hold{}, gorm.ErrRecordNotFound
+}
+
+// brokenBook fails to lock one book.
+type brokenBook struct {
+	interfaces.Book
+	id string
+}
+
+func (r brokenBook) GetByIdForUpdate(_ *gorm.DB, id string) (models.Book, error) {
+	if id == r.id {
+		return models.Book{}, errors.New("lock wait timeout exceeded")
+	}
+	return models.Book{ID: id}, nil
+}
+
+func TestExpireLapsedHoldsKeepsGoing(t *testing.T) {
+	var expired []string
+	holds := lapsedHolds{expired: &expired, holds: []models.Hold{
+		{Id: "h1", BookId: "b1", Status: utils.HoldReady},
+		{Id: "h2", BookId: "b2", Status: utils.HoldReady},
+		{Id: "h3", BookId: "b3", Status: utils.HoldReady},
+	}}
+	db, _ := newFakeDB(t, nil)
+	service := NewHoldService(holds, brokenBook{id: "b2"}, nil, nil, nil, db)
+
+	count, err := service.ExpireLapsedHolds()
+	if count != 2 || !reflect.DeepEqual(expired, []string{"h1", "h3"}) {
+		t.Errorf("ExpireLapsedHolds expired %d: %v, want h1 and h3", count, expired)
+	}
+	if err == nil || !strings.Contains(err.Error(), "hold h2: lock wait timeout exceeded") {
+		t.Errorf("ExpireLapsedHolds error = %v, want the error of h2", err)
+	}
+}
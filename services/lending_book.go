@@ -1,33 +1,59 @@
 package services
 
 import (
+	"database/sql"
 	"digital-book-lending/interfaces"
 	"digital-book-lending/models"
 	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
 	"errors"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// errNotBorrowed is returned for a loan that is not active, for instance
+// because another request returned it first.
+var errNotBorrowed = errors.New("active lending record not found or already returned")
+
 type LendingService struct {
-	lendingRepo interfaces.Lending
-	bookRepo    interfaces.Book
-	DB          *gorm.DB
+	lendingRepo      interfaces.Lending
+	bookRepo         interfaces.Book
+	copyRepo         interfaces.BookCopy
+	holdRepo         interfaces.Hold
+	fineRepo         interfaces.Fine
+	userRepo         interfaces.Users
+	policyRepo       interfaces.LendingPolicy
+	notificationRepo interfaces.Notification
+	webhookRepo      interfaces.Webhook
+	DB               *gorm.DB
 }
 
-func NewLendingService(lendingRepo interfaces.Lending, bookRepo interfaces.Book, db *gorm.DB) *LendingService {
+func NewLendingService(lendingRepo interfaces.Lending, bookRepo interfaces.Book, copyRepo interfaces.BookCopy, holdRepo interfaces.Hold, fineRepo interfaces.Fine, userRepo interfaces.Users, policyRepo interfaces.LendingPolicy, notificationRepo interfaces.Notification, webhookRepo interfaces.Webhook, db *gorm.DB) *LendingService {
 	return &LendingService{
-		lendingRepo: lendingRepo,
-		bookRepo:    bookRepo,
-		DB:          db,
+		lendingRepo:      lendingRepo,
+		bookRepo:         bookRepo,
+		copyRepo:         copyRepo,
+		holdRepo:         holdRepo,
+		fineRepo:         fineRepo,
+		userRepo:         userRepo,
+		policyRepo:       policyRepo,
+		notificationRepo: notificationRepo,
+		webhookRepo:      webhookRepo,
+		DB:               db,
 	}
 }
 
 func (s *LendingService) BorrowBook(bookId, userId string) (models.LendingRecord, error) {
 	var newLendingRecord models.LendingRecord
 
-	err := s.DB.Transaction(func(tx *gorm.DB) error {
+	user, err := s.getUser(userId)
+	if err != nil {
+		return newLendingRecord, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
 		book, err := s.bookRepo.GetByIdForUpdate(tx, bookId)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -36,8 +62,36 @@ func (s *LendingService) BorrowBook(bookId, userId string) (models.LendingRecord
 			return err
 		}
 
-		if book.Quantity < 1 {
-			return errors.New("book is out of stock")
+		if _, err := expireLapsedHolds(tx, s.bookRepo, s.copyRepo, s.holdRepo, s.notificationRepo, bookId); err != nil {
+			return err
+		}
+
+		// a ready hold means a copy, or a licensed seat of an e-book, has
+		// already been set aside for this member
+		hold, err := s.holdRepo.GetActiveByUserAndBook(tx, userId, bookId)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		hasHold := err == nil
+		claimHold := hasHold && hold.Status == utils.HoldReady
+
+		// titles under a publisher license are lent as e-books
+		isDigital := book.License.Type != nil
+
+		var bookCopy models.BookCopy
+		switch {
+		case isDigital:
+			err = checkLicense(tx, s.lendingRepo, s.holdRepo, book, claimHold)
+		case claimHold:
+			bookCopy, err = lockCopy(tx, s.copyRepo, hold.CopyId)
+		default:
+			bookCopy, err = s.copyRepo.GetAvailableForUpdate(tx, bookId)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("book is out of stock, you can place a hold on it instead")
+			}
+		}
+		if err != nil {
+			return err
 		}
 
 		_, err = s.lendingRepo.GetActiveByUserAndBook(tx, userId, bookId)
@@ -45,34 +99,58 @@ func (s *LendingService) BorrowBook(bookId, userId string) (models.LendingRecord
 			return errors.New("you have already borrowed this book")
 		}
 
-		sevenDaysAgo := time.Now().AddDate(0, 0, -7)
-		recentBorrows, err := s.lendingRepo.CountBorrowsByUser(tx, userId, sevenDaysAgo)
-		if err != nil {
+		if err := checkFineBalance(tx, s.fineRepo, userId); err != nil {
 			return err
 		}
-		if recentBorrows >= 5 {
-			return errors.New("borrowing limit exceeded: you have borrowed 5 books in the last 7 days")
-		}
 
-		bookDataUpdate := map[string]interface{}{"quantity": book.Quantity - 1}
-		if _, err := s.bookRepo.Update(tx, book, bookDataUpdate); err != nil {
+		terms, err := getLendingTerms(tx, s.policyRepo, user.Role, book.Category)
+		if err != nil {
+			return err
+		}
+		if err := checkLendingLimits(tx, s.lendingRepo, userId, terms); err != nil {
 			return err
 		}
 
+		borrowDate := time.Now()
+
 		record := models.LendingRecord{
 			Id:         utils.CreateUUID(),
 			UserId:     userId,
 			BookId:     bookId,
-			BorrowDate: time.Now(),
+			IsDigital:  isDigital,
+			BorrowDate: borrowDate,
+			DueDate:    borrowDate.AddDate(0, 0, terms.loanPeriodDays),
 			Status:     utils.Borrowed,
 		}
 
+		if isDigital {
+			record.DueDate = licensedDueDate(book, record.DueDate)
+			bookDataUpdate := map[string]interface{}{"license_checkouts": gorm.Expr("license_checkouts + 1")}
+			if _, err := s.bookRepo.Update(tx, book, bookDataUpdate); err != nil {
+				return err
+			}
+		} else {
+			record.CopyId = &bookCopy.Id
+			if err := s.copyRepo.Update(tx, bookCopy, map[string]interface{}{"status": utils.CopyOnLoan}); err != nil {
+				return err
+			}
+			if err := s.bookRepo.SyncQuantity(tx, bookId); err != nil {
+				return err
+			}
+		}
+
 		newLendingRecord, err = s.lendingRepo.Store(tx, record)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		if hasHold {
+			if err := s.holdRepo.Update(tx, hold, map[string]interface{}{"status": utils.HoldFulfilled}); err != nil {
+				return err
+			}
+		}
+
+		return queueWebhookEvent(tx, s.webhookRepo, utils.EventLoanBorrowed, newLendingRecord)
 	})
 
 	return newLendingRecord, err
@@ -83,7 +161,7 @@ func (s *LendingService) ReturnBook(lendingId, userId string) error {
 		record, err := s.lendingRepo.GetBorrowedById(tx, lendingId)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("active lending record not found or already returned")
+				return errNotBorrowed
 			}
 			return err
 		}
@@ -95,21 +173,188 @@ func (s *LendingService) ReturnBook(lendingId, userId string) error {
 		if err != nil {
 			return err
 		}
-		bookDataUpdate := map[string]interface{}{"quantity": book.Quantity + 1}
-		if _, err := s.bookRepo.Update(tx, book, bookDataUpdate); err != nil {
-			return err
+
+		returnDate := time.Now()
+		if record.IsDigital {
+			// access ends with the loan, so an e-book cannot be kept late and is never fined
+			if err := releaseSeat(tx, s.holdRepo, s.notificationRepo, record.BookId); err != nil {
+				return err
+			}
+		} else {
+			bookCopy, err := lockCopy(tx, s.copyRepo, record.CopyId)
+			if err != nil {
+				return err
+			}
+			if err := releaseCopy(tx, s.bookRepo, s.copyRepo, s.holdRepo, s.notificationRepo, bookCopy); err != nil {
+				return err
+			}
+			if err := chargeOverdueFine(tx, s.fineRepo, record, book.Category, returnDate); err != nil {
+				return err
+			}
 		}
 
 		lendingDataUpdate := map[string]interface{}{
 			"status":      utils.Returned,
-			"return_date": time.Now(),
+			"return_date": returnDate,
 		}
 		if err := s.lendingRepo.Update(tx, record, lendingDataUpdate); err != nil {
 			return err
 		}
 
-		return nil
+		record.Status = utils.Returned
+		record.ReturnDate = sql.NullTime{Time: returnDate, Valid: true}
+		return queueWebhookEvent(tx, s.webhookRepo, utils.EventLoanReturned, record)
 	})
 
 	return err
 }
+
+func (s *LendingService) RenewBook(lendingId, userId string) (models.LendingRecord, error) {
+	var renewedRecord models.LendingRecord
+
+	user, err := s.getUser(userId)
+	if err != nil {
+		return renewedRecord, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := s.lendingRepo.GetBorrowedById(tx, lendingId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNotBorrowed
+			}
+			return err
+		}
+		if record.UserId != userId {
+			return errors.New("you are not authorized to renew this book")
+		}
+
+		book, err := s.bookRepo.GetByIdForUpdate(tx, record.BookId)
+		if err != nil {
+			return err
+		}
+		terms, err := getLendingTerms(tx, s.policyRepo, user.Role, book.Category)
+		if err != nil {
+			return err
+		}
+
+		if record.RenewalCount >= terms.maxRenewals {
+			return fmt.Errorf("renewal limit reached: this loan has already been renewed %d times", record.RenewalCount)
+		}
+
+		waitingHolds, err := s.holdRepo.CountWaiting(tx, record.BookId)
+		if err != nil {
+			return err
+		}
+		if waitingHolds > 0 {
+			return errors.New("another member has a hold on this book, it cannot be renewed")
+		}
+
+		now := time.Now()
+		gracePeriod := utils.GetEnv("RENEWAL_GRACE_DAYS", 3).(int)
+		if now.After(record.DueDate.AddDate(0, 0, gracePeriod)) {
+			return fmt.Errorf("loan is overdue by more than %d days and can no longer be renewed", gracePeriod)
+		}
+
+		dueFrom := record.DueDate
+		if now.After(dueFrom) {
+			dueFrom = now
+		}
+
+		dueDate := dueFrom.AddDate(0, 0, terms.loanPeriodDays)
+		if record.IsDigital {
+			dueDate = licensedDueDate(book, dueDate)
+			if !dueDate.After(record.DueDate) {
+				return errors.New("the license for this e-book runs out before the loan could be extended")
+			}
+		}
+
+		record.DueDate = dueDate
+		record.RenewalCount++
+		record.Status = utils.Borrowed
+
+		lendingDataUpdate := map[string]interface{}{
+			"due_date":      record.DueDate,
+			"renewal_count": record.RenewalCount,
+			"status":        record.Status,
+		}
+		if err := s.lendingRepo.Update(tx, record, lendingDataUpdate); err != nil {
+			return err
+		}
+
+		renewedRecord = record
+		return nil
+	})
+
+	return renewedRecord, err
+}
+
+// Checkout borrows a book on behalf of a member at the front desk.
+func (s *LendingService) Checkout(bookId, userId string) (models.LendingRecord, error) {
+	return s.BorrowBook(bookId, userId)
+}
+
+// Checkin returns a book on behalf of the member who borrowed it.
+func (s *LendingService) Checkin(lendingId, userId string) error {
+	if _, err := s.getUser(userId); err != nil {
+		return err
+	}
+
+	return s.ReturnBook(lendingId, userId)
+}
+
+func (s *LendingService) getUser(userId string) (models.Users, error) {
+	user, err := s.userRepo.GetById(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, errors.New("user not found")
+		}
+		return user, err
+	}
+
+	return user, nil
+}
+
+func (s *LendingService) ListLendings(page, limit int, filter request.LendingFilter) ([]models.LendingRecord, int64, error) {
+	return s.lendingRepo.Fetch(page, limit, filter)
+}
+
+// ReturnExpiredDigitalLoans returns every e-book loan whose due date has
+// passed, the same way the borrower would have. A loan the borrower returned
+// in the meantime is skipped. A loan that cannot be returned is logged and
+// skipped so it does not hold up the others; the errors are joined together.
+func (s *LendingService) ReturnExpiredDigitalLoans() (int, error) {
+	expired, err := s.lendingRepo.FetchExpiredDigital(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		returned int
+		errs     []error
+	)
+	for _, record := range expired {
+		err := s.ReturnBook(record.Id, record.UserId)
+		if errors.Is(err, errNotBorrowed) {
+			continue
+		}
+		if err != nil {
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("[Lending][ReturnExpiredDigitalLoans][%s]; ReturnBook; Error: %+v", record.Id, err))
+			errs = append(errs, fmt.Errorf("lending %s: %w", record.Id, err))
+			continue
+		}
+		returned++
+	}
+
+	return returned, errors.Join(errs...)
+}
+
+// FlagOverdue marks every active physical loan whose due date has passed as
+// overdue. E-book loans are returned instead, see ReturnExpiredDigitalLoans.
+func (s *LendingService) FlagOverdue() (int64, error) {
+	return s.lendingRepo.MarkOverdue(s.DB, time.Now())
+}
+
+func (s *LendingService) ListOverdue() ([]models.LendingRecord, error) {
+	return s.lendingRepo.FetchOverdue()
+}
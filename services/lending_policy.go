@@ -0,0 +1,151 @@
+package services
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LendingPolicyService struct {
+	policyRepo interfaces.LendingPolicy
+}
+
+func NewLendingPolicyService(policyRepo interfaces.LendingPolicy) *LendingPolicyService {
+	return &LendingPolicyService{policyRepo: policyRepo}
+}
+
+func (s *LendingPolicyService) CreatePolicy(req request.LendingPolicy, username string) (models.LendingPolicy, error) {
+	policy := models.LendingPolicy{
+		Id:                 utils.CreateUUID(),
+		Name:               req.Name,
+		Role:               strings.TrimSpace(req.Role),
+		Category:           strings.TrimSpace(req.Category),
+		MaxConcurrentLoans: req.MaxConcurrentLoans,
+		WindowDays:         req.WindowDays,
+		WindowMaxBorrows:   req.WindowMaxBorrows,
+		LoanPeriodDays:     req.LoanPeriodDays,
+		MaxRenewals:        req.MaxRenewals,
+		CreatedAt:          time.Now(),
+		CreatedBy:          username,
+	}
+
+	if err := s.policyRepo.Store(policy); err != nil {
+		return models.LendingPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+func (s *LendingPolicyService) UpdatePolicy(id string, req request.LendingPolicy, username string) (int64, error) {
+	// every field is written so a limit can be lifted by sending null
+	data := map[string]interface{}{
+		"name":                 req.Name,
+		"role":                 strings.TrimSpace(req.Role),
+		"category":             strings.TrimSpace(req.Category),
+		"max_concurrent_loans": req.MaxConcurrentLoans,
+		"window_days":          req.WindowDays,
+		"window_max_borrows":   req.WindowMaxBorrows,
+		"loan_period_days":     req.LoanPeriodDays,
+		"max_renewals":         req.MaxRenewals,
+		"updated_at":           time.Now(),
+		"updated_by":           username,
+	}
+
+	return s.policyRepo.Update(models.LendingPolicy{Id: id}, data)
+}
+
+func (s *LendingPolicyService) DeletePolicy(id string) (int64, error) {
+	return s.policyRepo.Delete(models.LendingPolicy{Id: id})
+}
+
+func (s *LendingPolicyService) ListPolicies() ([]models.LendingPolicy, error) {
+	return s.policyRepo.Fetch()
+}
+
+// lendingTerms are the rules that apply to a single loan, resolved from the
+// lending policies matching the borrower's role and the book's category.
+type lendingTerms struct {
+	limits         []models.LendingPolicy
+	loanPeriodDays int
+	maxRenewals    int
+}
+
+// resolveLendingTerms picks, for the general scope and for the book's category
+// scope, the policy of the borrower's role over the policy for every role. The
+// limits of both are enforced, and the loan period and renewals come from the
+// most specific one. Without any policy the LOAN_PERIOD_DAYS and MAX_RENEWALS
+// environment settings apply.
+func resolveLendingTerms(policies []models.LendingPolicy, role string) lendingTerms {
+	var general, category *models.LendingPolicy
+	for i := range policies {
+		scope := &general
+		if policies[i].Category != "" {
+			scope = &category
+		}
+		if *scope == nil || policies[i].Role == role {
+			*scope = &policies[i]
+		}
+	}
+
+	terms := lendingTerms{
+		loanPeriodDays: utils.GetEnv("LOAN_PERIOD_DAYS", 14).(int),
+		maxRenewals:    utils.GetEnv("MAX_RENEWALS", 2).(int),
+	}
+	for _, policy := range []*models.LendingPolicy{general, category} {
+		if policy == nil {
+			continue
+		}
+		terms.limits = append(terms.limits, *policy)
+		terms.loanPeriodDays = policy.LoanPeriodDays
+		terms.maxRenewals = policy.MaxRenewals
+	}
+
+	return terms
+}
+
+func getLendingTerms(tx *gorm.DB, policyRepo interfaces.LendingPolicy, role, category string) (lendingTerms, error) {
+	policies, err := policyRepo.FetchApplicable(tx, role, category)
+	if err != nil {
+		return lendingTerms{}, err
+	}
+
+	return resolveLendingTerms(policies, role), nil
+}
+
+func checkLendingLimits(tx *gorm.DB, lendingRepo interfaces.Lending, userId string, terms lendingTerms) error {
+	for _, policy := range terms.limits {
+		books := "books"
+		if policy.Category != "" {
+			books = fmt.Sprintf("%s books", policy.Category)
+		}
+
+		if policy.MaxConcurrentLoans != nil {
+			active, err := lendingRepo.CountActiveByUser(tx, userId, policy.Category)
+			if err != nil {
+				return err
+			}
+			if active >= int64(*policy.MaxConcurrentLoans) {
+				return fmt.Errorf("borrowing limit exceeded: you already have %d %s on loan", active, books)
+			}
+		}
+
+		if policy.WindowDays != nil && policy.WindowMaxBorrows != nil {
+			since := time.Now().AddDate(0, 0, -*policy.WindowDays)
+			recentBorrows, err := lendingRepo.CountBorrowsByUser(tx, userId, policy.Category, since)
+			if err != nil {
+				return err
+			}
+			if recentBorrows >= int64(*policy.WindowMaxBorrows) {
+				return fmt.Errorf("borrowing limit exceeded: you have borrowed %d %s in the last %d days", recentBorrows, books, *policy.WindowDays)
+			}
+		}
+	}
+
+	return nil
+}
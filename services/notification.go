@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/notifier"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationService struct {
+	notificationRepo interfaces.Notification
+	lendingRepo      interfaces.Lending
+	holdRepo         interfaces.Hold
+	bookRepo         interfaces.Book
+	userRepo         interfaces.Users
+	notifier         interfaces.Notifier
+	DB               *gorm.DB
+}
+
+func NewNotificationService(notificationRepo interfaces.Notification, lendingRepo interfaces.Lending, holdRepo interfaces.Hold, bookRepo interfaces.Book, userRepo interfaces.Users, notifier interfaces.Notifier, db *gorm.DB) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		lendingRepo:      lendingRepo,
+		holdRepo:         holdRepo,
+		bookRepo:         bookRepo,
+		userRepo:         userRepo,
+		notifier:         notifier,
+		DB:               db,
+	}
+}
+
+func (s *NotificationService) GetPreferences(userId string) (models.NotificationPreference, error) {
+	preference, err := s.notificationRepo.GetPreference(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultPreference(userId), nil
+	}
+
+	return preference, err
+}
+
+func (s *NotificationService) UpdatePreferences(userId string, req request.NotificationPreference) (models.NotificationPreference, error) {
+	now := time.Now()
+	preference := models.NotificationPreference{
+		UserId:    userId,
+		Locale:    req.Locale,
+		DueSoon:   *req.DueSoon,
+		Overdue:   *req.Overdue,
+		HoldReady: *req.HoldReady,
+		UpdatedAt: &now,
+	}
+
+	if err := s.notificationRepo.SavePreference(preference); err != nil {
+		return models.NotificationPreference{}, err
+	}
+
+	return preference, nil
+}
+
+// QueueLoanReminders queues a reminder for every loan due within
+// DUE_REMINDER_DAYS and a notice for every overdue loan. Each one is queued
+// once per due date, so a renewed loan gets reminded again.
+func (s *NotificationService) QueueLoanReminders() (int64, error) {
+	now := time.Now()
+	reminderDays := utils.GetEnv("DUE_REMINDER_DAYS", 2).(int)
+
+	dueSoon, err := s.lendingRepo.FetchDueBetween(now, now.AddDate(0, 0, reminderDays))
+	if err != nil {
+		return 0, err
+	}
+	overdue, err := s.lendingRepo.FetchOverdue()
+	if err != nil {
+		return 0, err
+	}
+
+	var queued int64
+	for _, record := range dueSoon {
+		count, err := queueNotification(s.DB, s.notificationRepo, utils.NotifyDueSoon, record.UserId, record.BookId, record.Id, record.DueDate)
+		if err != nil {
+			return queued, err
+		}
+		queued += count
+	}
+	for _, record := range overdue {
+		count, err := queueNotification(s.DB, s.notificationRepo, utils.NotifyOverdue, record.UserId, record.BookId, record.Id, record.DueDate)
+		if err != nil {
+			return queued, err
+		}
+		queued += count
+	}
+
+	return queued, nil
+}
+
+// SendPending sends the oldest notifications in the outbox. A failed send is
+// retried on the next run until NOTIFICATION_MAX_ATTEMPTS is reached.
+func (s *NotificationService) SendPending() (int64, error) {
+	batchSize := utils.GetEnv("NOTIFICATION_BATCH_SIZE", 50).(int)
+	maxAttempts := utils.GetEnv("NOTIFICATION_MAX_ATTEMPTS", 5).(int)
+
+	pending, err := s.notificationRepo.FetchPending(batchSize, maxAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int64
+	for _, notification := range pending {
+		status, err := s.deliver(notification)
+		now := time.Now()
+		notificationDataUpdate := map[string]interface{}{"updated_at": now}
+
+		switch {
+		case err != nil:
+			attempts := notification.Attempts + 1
+			notificationDataUpdate["attempts"] = attempts
+			notificationDataUpdate["last_error"] = truncate(err.Error(), 255)
+			if attempts >= maxAttempts {
+				notificationDataUpdate["status"] = utils.NotificationFailed
+			}
+			utils.WriteLog(utils.LogLevelError, fmt.Sprintf("[Notification][SendPending][%s]; attempt %d; Error: %+v", notification.Id, attempts, err))
+		case status == utils.NotificationSent:
+			notificationDataUpdate["status"] = status
+			notificationDataUpdate["sent_at"] = now
+			sent++
+		default:
+			notificationDataUpdate["status"] = status
+		}
+
+		if err := s.notificationRepo.Update(notification, notificationDataUpdate); err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// deliver renders and sends a notification. Notifications the member opted out
+// of, or that no longer apply because the loan was returned or renewed or the
+// hold was picked up, are skipped.
+func (s *NotificationService) deliver(notification models.Notification) (string, error) {
+	preference, err := s.GetPreferences(notification.UserId)
+	if err != nil {
+		return "", err
+	}
+	if !wantsNotification(preference, notification.Type) {
+		return utils.NotificationSkipped, nil
+	}
+
+	bookTitle, current, err := s.checkCurrent(notification)
+	if err != nil {
+		return "", err
+	}
+	if !current {
+		return utils.NotificationSkipped, nil
+	}
+
+	user, err := s.userRepo.GetById(notification.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.NotificationSkipped, nil
+		}
+		return "", err
+	}
+
+	subject, body, err := notifier.Render(preference.Locale, notification.Type, notifier.TemplateData{
+		Name:      user.Name,
+		BookTitle: bookTitle,
+		DueAt:     notification.DueAt,
+		Library:   utils.GetEnv("LIBRARY_NAME", "Digital Book Lending").(string),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	timeout := time.Duration(utils.GetEnv("NOTIFICATION_SEND_TIMEOUT_SECONDS", 30).(int)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := s.notifier.Send(ctx, models.Message{To: user.Email, Subject: subject, Body: body}); err != nil {
+		return "", err
+	}
+
+	return utils.NotificationSent, nil
+}
+
+// checkCurrent reports whether the loan or hold a notification is about is
+// still in the state it was queued for, and the title of its book.
+func (s *NotificationService) checkCurrent(notification models.Notification) (string, bool, error) {
+	if notification.Type == utils.NotifyHoldReady {
+		hold, err := s.holdRepo.GetById(notification.ReferenceId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		if hold.Status != utils.HoldReady {
+			return "", false, nil
+		}
+
+		book, err := s.bookRepo.GetById(hold.BookId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+		return book.Title, true, nil
+	}
+
+	record, err := s.lendingRepo.GetById(notification.ReferenceId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if record.Status == utils.Returned || record.DueDate.Unix() != notification.DueAt.Unix() {
+		return "", false, nil
+	}
+
+	return record.Book.Title, true, nil
+}
+
+// queueNotification writes a notification to the outbox in the caller's
+// transaction. It returns 0 when the same notification was already queued.
+func queueNotification(tx *gorm.DB, notificationRepo interfaces.Notification, notificationType, userId, bookId, referenceId string, dueAt time.Time) (int64, error) {
+	return notificationRepo.Enqueue(tx, models.Notification{
+		Id:          utils.CreateUUID(),
+		UserId:      userId,
+		BookId:      bookId,
+		Type:        notificationType,
+		ReferenceId: referenceId,
+		DueAt:       dueAt,
+		DedupKey:    fmt.Sprintf("%s:%s:%d", notificationType, referenceId, dueAt.Unix()),
+		Status:      utils.NotificationPending,
+		CreatedAt:   time.Now(),
+	})
+}
+
+func defaultPreference(userId string) models.NotificationPreference {
+	return models.NotificationPreference{
+		UserId:    userId,
+		Locale:    utils.GetEnv("NOTIFICATION_DEFAULT_LOCALE", utils.LocaleEnglish).(string),
+		DueSoon:   true,
+		Overdue:   true,
+		HoldReady: true,
+	}
+}
+
+func wantsNotification(preference models.NotificationPreference, notificationType string) bool {
+	switch notificationType {
+	case utils.NotifyDueSoon:
+		return preference.DueSoon
+	case utils.NotifyOverdue:
+		return preference.Overdue
+	case utils.NotifyHoldReady:
+		return preference.HoldReady
+	default:
+		return false
+	}
+}
+
+func truncate(s string, max int) string {
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max])
+	}
+	return s
+}
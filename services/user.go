@@ -75,3 +75,10 @@ func (s *UserService) LogoutUser(token string) error {
 
 	return nil
 }
+
+// PurgeBlacklist removes the logged-out tokens that have expired by now, since
+// an expired token is refused without looking at the blacklist.
+func (s *UserService) PurgeBlacklist() (int64, error) {
+	tokenLifetime := time.Hour * time.Duration(utils.GetEnv("JWT_EXP", 24).(int))
+	return s.blacklistRepo.DeleteCreatedBefore(time.Now().Add(-tokenLifetime))
+}
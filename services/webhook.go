@@ -0,0 +1,334 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"digital-book-lending/interfaces"
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"digital-book-lending/utils/request"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookService struct {
+	webhookRepo interfaces.Webhook
+	client      *http.Client
+	DB          *gorm.DB
+}
+
+func NewWebhookService(webhookRepo interfaces.Webhook, db *gorm.DB) *WebhookService {
+	timeout := time.Duration(utils.GetEnv("WEBHOOK_TIMEOUT_SECONDS", 10).(int)) * time.Second
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client:      &http.Client{Timeout: timeout},
+		DB:          db,
+	}
+}
+
+func (s *WebhookService) CreateSubscription(req request.WebhookSubscription, username string) (models.WebhookSubscription, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	subscription := models.WebhookSubscription{
+		Id:          utils.CreateUUID(),
+		Url:         req.Url,
+		Description: req.Description,
+		Events:      req.Events,
+		Secret:      secret,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		CreatedAt:   time.Now(),
+		CreatedBy:   username,
+	}
+
+	if err := s.webhookRepo.StoreSubscription(subscription); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+func (s *WebhookService) UpdateSubscription(id string, req request.WebhookSubscription, username string) (int64, error) {
+	data := map[string]interface{}{
+		"url":         req.Url,
+		"description": req.Description,
+		"events":      utils.JsonEncode(req.Events),
+		"updated_at":  time.Now(),
+		"updated_by":  username,
+	}
+	if req.IsActive != nil {
+		data["is_active"] = *req.IsActive
+	}
+
+	return s.webhookRepo.UpdateSubscription(models.WebhookSubscription{Id: id}, data)
+}
+
+func (s *WebhookService) DeleteSubscription(id string) (int64, error) {
+	return s.webhookRepo.DeleteSubscription(models.WebhookSubscription{Id: id})
+}
+
+// ListSubscriptions lists every subscription without its secret.
+func (s *WebhookService) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	subscriptions, err := s.webhookRepo.FetchSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	return subscriptions, nil
+}
+
+func (s *WebhookService) ListDeliveries(subscriptionId string, page, limit int, status string) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.webhookRepo.GetSubscriptionById(subscriptionId); err != nil {
+		return nil, 0, err
+	}
+
+	return s.webhookRepo.FetchDeliveries(page, limit, subscriptionId, status)
+}
+
+func (s *WebhookService) GetDelivery(id string) (models.WebhookDelivery, error) {
+	return s.webhookRepo.GetDeliveryById(id)
+}
+
+// ReplayDelivery sends a failed delivery again on the next run of the delivery
+// job, with a fresh set of attempts. The body is the one originally sent.
+func (s *WebhookService) ReplayDelivery(id string) (models.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDeliveryById(id)
+	if err != nil {
+		return delivery, err
+	}
+	if delivery.Status != utils.WebhookFailed {
+		return delivery, errors.New("only failed deliveries can be replayed")
+	}
+
+	deliveryDataUpdate := map[string]interface{}{
+		"status":          utils.WebhookPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	}
+	if _, err := s.webhookRepo.UpdateDelivery(delivery, deliveryDataUpdate); err != nil {
+		return delivery, err
+	}
+
+	return s.webhookRepo.GetDeliveryById(id)
+}
+
+// DeliverEvents hands new events in the outbox to the subscriptions listening
+// for them, then sends every delivery that is due.
+func (s *WebhookService) DeliverEvents() (int64, error) {
+	if err := s.dispatchEvents(); err != nil {
+		return 0, err
+	}
+
+	batchSize := utils.GetEnv("WEBHOOK_BATCH_SIZE", 50).(int)
+	due, err := s.webhookRepo.FetchDueDeliveries(time.Now(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int64
+	for _, delivery := range due {
+		ok, err := s.deliver(delivery)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// dispatchEvents creates a delivery of each new event for every active
+// subscription listening for it. Subscriptions created later do not get
+// earlier events.
+func (s *WebhookService) dispatchEvents() error {
+	batchSize := utils.GetEnv("WEBHOOK_BATCH_SIZE", 50).(int)
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		events, err := s.webhookRepo.FetchUndispatchedEvents(tx, batchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		subscriptions, err := s.webhookRepo.FetchActiveSubscriptions(tx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var deliveries []models.WebhookDelivery
+		eventIds := make([]string, 0, len(events))
+		for _, event := range events {
+			eventIds = append(eventIds, event.Id)
+			for _, subscription := range subscriptions {
+				if !slices.Contains(subscription.Events, event.Type) {
+					continue
+				}
+				deliveries = append(deliveries, models.WebhookDelivery{
+					Id:             utils.CreateUUID(),
+					SubscriptionId: subscription.Id,
+					EventId:        event.Id,
+					Status:         utils.WebhookPending,
+					NextAttemptAt:  now,
+					CreatedAt:      now,
+				})
+			}
+		}
+
+		if len(deliveries) > 0 {
+			if err := s.webhookRepo.StoreDeliveries(tx, deliveries); err != nil {
+				return err
+			}
+		}
+
+		return s.webhookRepo.MarkEventsDispatched(tx, eventIds, now)
+	})
+}
+
+// deliver makes one attempt at a delivery, logs it and schedules the next
+// attempt with exponential backoff when it fails.
+func (s *WebhookService) deliver(delivery models.WebhookDelivery) (bool, error) {
+	attempt := models.WebhookDeliveryAttempt{
+		Id:         utils.CreateUUID(),
+		DeliveryId: delivery.Id,
+		CreatedAt:  time.Now(),
+	}
+
+	var sendErr error
+	switch {
+	case delivery.Subscription == nil || delivery.Event == nil:
+		sendErr = errors.New("subscription or event no longer exists")
+	case !delivery.Subscription.IsActive:
+		sendErr = errors.New("subscription is disabled")
+	default:
+		statusCode, body, err := s.post(*delivery.Subscription, *delivery.Event, delivery.Id)
+		attempt.DurationMs = time.Since(attempt.CreatedAt).Milliseconds()
+		if statusCode != 0 {
+			attempt.StatusCode = &statusCode
+			attempt.ResponseBody = &body
+		}
+		sendErr = err
+	}
+
+	if sendErr != nil {
+		message := truncate(sendErr.Error(), 255)
+		attempt.Error = &message
+	}
+	if err := s.webhookRepo.StoreAttempt(attempt); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	deliveryDataUpdate := map[string]interface{}{"attempts": attempts}
+
+	maxAttempts := utils.GetEnv("WEBHOOK_MAX_ATTEMPTS", 8).(int)
+	switch {
+	case sendErr == nil:
+		deliveryDataUpdate["status"] = utils.WebhookDelivered
+		deliveryDataUpdate["delivered_at"] = now
+	case attempts >= maxAttempts:
+		deliveryDataUpdate["status"] = utils.WebhookFailed
+	default:
+		deliveryDataUpdate["next_attempt_at"] = now.Add(webhookBackoff(attempts))
+	}
+
+	if _, err := s.webhookRepo.UpdateDelivery(delivery, deliveryDataUpdate); err != nil {
+		return false, err
+	}
+
+	return sendErr == nil, nil
+}
+
+// post sends the event to the subscriber. Any 2xx response counts as delivered.
+func (s *WebhookService) post(subscription models.WebhookSubscription, event models.WebhookEvent, deliveryId string) (int, string, error) {
+	body := []byte(event.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, subscription.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", utils.GetEnv("APP_NAME", "digital-book-lending").(string)+"-webhooks")
+	req.Header.Set("X-Webhook-Id", event.Id)
+	req.Header.Set("X-Webhook-Delivery", deliveryId)
+	req.Header.Set("X-Webhook-Event", event.Type)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", "sha256="+utils.SignWebhook(subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("subscriber answered %s", resp.Status)
+	}
+
+	return resp.StatusCode, string(respBody), nil
+}
+
+// webhookBackoff doubles the wait after every failed attempt, starting at
+// WEBHOOK_RETRY_BASE_SECONDS and capped at WEBHOOK_RETRY_MAX_SECONDS.
+func webhookBackoff(attempts int) time.Duration {
+	base := time.Duration(utils.GetEnv("WEBHOOK_RETRY_BASE_SECONDS", 30).(int)) * time.Second
+	ceiling := time.Duration(utils.GetEnv("WEBHOOK_RETRY_MAX_SECONDS", 21600).(int)) * time.Second
+
+	wait := base
+	for i := 1; i < attempts && wait < ceiling; i++ {
+		wait *= 2
+	}
+
+	return min(wait, ceiling)
+}
+
+// queueWebhookEvent writes an event to the outbox in the caller's transaction,
+// so partners only hear about changes that were committed.
+func queueWebhookEvent(tx *gorm.DB, webhookRepo interfaces.Webhook, eventType string, data interface{}) error {
+	event := models.WebhookEvent{
+		Id:        utils.CreateUUID(),
+		Type:      eventType,
+		CreatedAt: time.Now(),
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":         event.Id,
+		"type":       event.Type,
+		"created_at": event.CreatedAt,
+		"data":       data,
+	})
+	if err != nil {
+		return err
+	}
+	event.Payload = string(payload)
+
+	return webhookRepo.StoreEvent(tx, event)
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
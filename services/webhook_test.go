@@ -0,0 +1,44 @@
+package services
+
+import (
+	"digital-book-lending/models"
+	"digital-book-lending/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWebhookSignatureHeader(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	service := NewWebhookService(nil, nil)
+	subscription := models.WebhookSubscription{Url: server.URL, Secret: "secret"}
+	event := models.WebhookEvent{Id: "e1", Type: "book.created", Payload: `{"id":"b1"}`}
+
+	if _, _, err := service.post(subscription, event, "d1"); err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != event.Payload {
+		t.Fatalf("body = %s, want the event payload", body)
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get("X-Webhook-Timestamp"), 10, 64)
+	if err != nil || time.Since(time.Unix(timestamp, 0)) > time.Minute {
+		t.Fatalf("X-Webhook-Timestamp = %q, want the unix time it was sent", header.Get("X-Webhook-Timestamp"))
+	}
+	if got, want := header.Get("X-Webhook-Signature"), "sha256="+utils.SignWebhook("secret", timestamp, body); got != want {
+		t.Errorf("X-Webhook-Signature = %s, want %s", got, want)
+	}
+	if header.Get("X-Webhook-Id") != "e1" || header.Get("X-Webhook-Event") != "book.created" || header.Get("X-Webhook-Delivery") != "d1" {
+		t.Errorf("headers = %v", header)
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"digital-book-lending/interfaces"
+	"errors"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config points at an S3-compatible service: AWS S3, or a local stand-in
+// such as MinIO, which usually needs PathStyle and no SSL.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PathStyle bool
+}
+
+type s3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Storage(conf S3Config) (interfaces.Storage, error) {
+	if conf.Endpoint == "" || conf.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required for the s3 storage driver")
+	}
+
+	lookup := minio.BucketLookupAuto
+	if conf.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(conf.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(conf.AccessKey, conf.SecretKey, ""),
+		Secure:       conf.UseSSL,
+		Region:       conf.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, conf.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, conf.Bucket, minio.MakeBucketOptions{Region: conf.Region}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &s3Storage{client: client, bucket: conf.Bucket}, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *s3Storage) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject is lazy, Stat surfaces a missing object before anything is served
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"digital-book-lending/interfaces"
+	"digital-book-lending/utils"
+	"fmt"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// NewStorage builds the storage backend picked by STORAGE_DRIVER.
+func NewStorage() (interfaces.Storage, error) {
+	switch driver := utils.GetEnv("STORAGE_DRIVER", DriverLocal).(string); driver {
+	case DriverLocal:
+		return NewLocalStorage(utils.GetEnv("STORAGE_DIR", "data/storage").(string))
+	case DriverS3:
+		return NewS3Storage(S3Config{
+			Endpoint:  utils.GetEnv("S3_ENDPOINT", "").(string),
+			Region:    utils.GetEnv("S3_REGION", "").(string),
+			Bucket:    utils.GetEnv("S3_BUCKET", "").(string),
+			AccessKey: utils.GetEnv("S3_ACCESS_KEY", "").(string),
+			SecretKey: utils.GetEnv("S3_SECRET_KEY", "").(string),
+			UseSSL:    utils.GetEnv("S3_USE_SSL", true).(bool),
+			PathStyle: utils.GetEnv("S3_PATH_STYLE", false).(bool),
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", driver)
+	}
+}
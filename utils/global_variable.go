@@ -34,6 +34,66 @@ const (
 
 	Borrowed = "borrowed"
 	Returned = "returned"
+	Overdue  = "overdue"
+
+	// LendingActive filters lending records that are borrowed or overdue
+	LendingActive = "active"
+
+	HoldWaiting   = "waiting"
+	HoldReady     = "ready"
+	HoldFulfilled = "fulfilled"
+	HoldCancelled = "cancelled"
+	HoldExpired   = "expired"
+
+	CopyAvailable   = "available"
+	CopyOnLoan      = "on_loan"
+	CopyOnHold      = "on_hold"
+	CopyMaintenance = "maintenance"
+	CopyLost        = "lost"
+	CopyWithdrawn   = "withdrawn"
+
+	LicenseOneCopyOneUser = "one_copy_one_user"
+	LicenseMetered        = "metered"
+	LicenseTimeLimited    = "time_limited"
+	LicenseSimultaneous   = "simultaneous_use"
+
+	EbookEpub = "epub"
+	EbookPdf  = "pdf"
+
+	FineCharge  = "charge"
+	FinePayment = "payment"
+	FineWaiver  = "waiver"
+
+	NotifyDueSoon   = "due_soon"
+	NotifyOverdue   = "overdue"
+	NotifyHoldReady = "hold_ready"
+
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationSkipped = "skipped"
+	NotificationFailed  = "failed"
+
+	LocaleEnglish    = "en"
+	LocaleIndonesian = "id"
+
+	EventBookCreated  = "book.created"
+	EventBookUpdated  = "book.updated"
+	EventBookDeleted  = "book.deleted"
+	EventLoanBorrowed = "loan.borrowed"
+	EventLoanReturned = "loan.returned"
+
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportError   = "error"
+
+	SearchNatural = "natural"
+	SearchBoolean = "boolean"
+	SearchLike    = "like"
+
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
 )
 
 const (
@@ -212,10 +212,22 @@ func mapValidateMessage(fe validator.FieldError) string {
 	switch fe.Tag() {
 	case "required":
 		return "This field is required"
+	case "required_with":
+		return "This field is required with " + fe.Param()
 	case "email":
 		return "Invalid email"
+	case "uuid":
+		return "Should be a valid UUID"
 	case "alphanum":
 		return "Should be alphanumeric"
+	case "url":
+		return "Should be a valid URL"
+	case "isbn":
+		return "Should be a valid ISBN-10 or ISBN-13"
+	case "unique":
+		return "Should not contain duplicates"
+	case "oneof":
+		return "Should be one of: " + fe.Param()
 	case "min":
 		return "Minimum " + fe.Param()
 	case "max":
@@ -224,6 +236,8 @@ func mapValidateMessage(fe validator.FieldError) string {
 		return "Should be less than " + fe.Param()
 	case "gte":
 		return "Should be greater than " + fe.Param()
+	case "gt":
+		return "Should be greater than " + fe.Param()
 	case "ltefield":
 		return "Should be less than " + fe.Param()
 	case "gtefield":
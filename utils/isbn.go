@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrInvalidISBN = errors.New("invalid ISBN")
+
+// NormalizeISBN checks the checksum of an ISBN-10 or ISBN-13, with or without
+// hyphens and spaces, and returns it as ISBN-13 digits, so "0-306-40615-2" and
+// "978-0-306-40615-7" are both "9780306406157".
+func NormalizeISBN(isbn string) (string, error) {
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i, c := range digits {
+			switch {
+			case c >= '0' && c <= '9':
+				sum += (10 - i) * int(c-'0')
+			case c == 'X' && i == 9:
+				sum += 10
+			default:
+				return "", ErrInvalidISBN
+			}
+		}
+		if sum%11 != 0 {
+			return "", ErrInvalidISBN
+		}
+
+		isbn13 := "978" + digits[:9]
+		return isbn13 + string(rune('0'+isbn13CheckDigit(isbn13))), nil
+	case 13:
+		if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+			return "", ErrInvalidISBN
+		}
+		for _, c := range digits {
+			if c < '0' || c > '9' {
+				return "", ErrInvalidISBN
+			}
+		}
+		if isbn13CheckDigit(digits[:12]) != int(digits[12]-'0') {
+			return "", ErrInvalidISBN
+		}
+
+		return digits, nil
+	}
+
+	return "", ErrInvalidISBN
+}
+
+// isbn13CheckDigit computes the last digit of an ISBN-13 from the first 12.
+func isbn13CheckDigit(digits string) int {
+	sum := 0
+	for i, c := range digits {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(c-'0')
+	}
+
+	return (10 - sum%10) % 10
+}
+
+// RegisterValidations adds the custom binding tags. "isbn" replaces the
+// validator's own tag, which rejects the hyphens ISBNs are usually written with.
+func RegisterValidations() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		_ = v.RegisterValidation("isbn", func(fl validator.FieldLevel) bool {
+			_, err := NormalizeISBN(fl.Field().String())
+			return err == nil
+		})
+	}
+}
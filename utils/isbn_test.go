@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "ISBN-13", in: "9780306406157", want: "9780306406157"},
+		{name: "ISBN-13 with hyphens", in: "978-0-306-40615-7", want: "9780306406157"},
+		{name: "ISBN-13 with spaces", in: "978 0 306 40615 7", want: "9780306406157"},
+		{name: "ISBN-13 with 979 prefix", in: "979-10-90636-07-1", want: "9791090636071"},
+		{name: "ISBN-10 to ISBN-13", in: "0306406152", want: "9780306406157"},
+		{name: "ISBN-10 with hyphens", in: "0-306-40615-2", want: "9780306406157"},
+		{name: "ISBN-10 with spaces and hyphens", in: "1 84356-028 3", want: "9781843560289"},
+		{name: "ISBN-10 with X check digit", in: "0-8044-2957-X", want: "9780804429573"},
+		{name: "ISBN-10 with lowercase x check digit", in: "080442957x", want: "9780804429573"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeISBN(tt.in)
+			if err != nil {
+				t.Fatalf("NormalizeISBN(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeISBN(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeISBNInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{name: "empty", in: ""},
+		{name: "too short", in: "030640615"},
+		{name: "too long", in: "97803064061570"},
+		{name: "ISBN-10 with wrong check digit", in: "0306406153"},
+		{name: "ISBN-10 with X before the end", in: "0X06406152"},
+		{name: "ISBN-10 with a letter", in: "03064A6152"},
+		{name: "ISBN-13 with wrong check digit", in: "9780306406158"},
+		{name: "ISBN-13 with X check digit", in: "978030640615X"},
+		{name: "ISBN-13 without 978 or 979 prefix", in: "9771234567003"},
+		{name: "other separators", in: "0.306.40615.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeISBN(tt.in)
+			if !errors.Is(err, ErrInvalidISBN) {
+				t.Errorf("NormalizeISBN(%q) = %q, %v, want ErrInvalidISBN", tt.in, got, err)
+			}
+		})
+	}
+}
+
+func TestISBNValidation(t *testing.T) {
+	RegisterValidations()
+
+	type book struct {
+		ISBN string `binding:"required,isbn"`
+	}
+	tests := map[string]bool{
+		"978-0-306-40615-7": true,
+		"0-306-40615-2":     true,
+		"0-8044-2957-X":     true,
+		"978-0-306-40615-8": false,
+		"0306406153":        false,
+	}
+
+	for isbn, valid := range tests {
+		err := binding.Validator.ValidateStruct(book{ISBN: isbn})
+		if (err == nil) != valid {
+			t.Errorf("isbn %q: error = %v, want valid %v", isbn, err, valid)
+		}
+	}
+}
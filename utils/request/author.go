@@ -0,0 +1,10 @@
+package request
+
+type Author struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// Merge lists the authors or categories to fold into another one.
+type Merge struct {
+	SourceIds []string `json:"source_ids" binding:"required,min=1,unique,dive,uuid"`
+}
@@ -1,17 +1,78 @@
 package request
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// AddBook names one author and one category, found by name or added, or lists
+// existing ones by id in AuthorIds and CategoryIds, which win when both are
+// given. The first category is the book's main one.
 type AddBook struct {
-	Title    string `json:"title" binding:"required"`
-	Author   string `json:"author" binding:"required"`
-	ISBN     string `json:"isbn" binding:"required"`
-	Category string `json:"category" binding:"required"`
-	Quantity int    `json:"quantity" binding:"required,gte=0"`
+	Title       string   `json:"title" binding:"required"`
+	Author      string   `json:"author" binding:"required_without=AuthorIds,max=100"`
+	AuthorIds   []string `json:"author_ids" binding:"omitempty,min=1,max=10,unique,dive,uuid"`
+	ISBN        string   `json:"isbn" binding:"required,isbn"`
+	Category    string   `json:"category" binding:"required_without=CategoryIds,max=100"`
+	CategoryIds []string `json:"category_ids" binding:"omitempty,min=1,max=10,unique,dive,uuid"`
+	Quantity    int      `json:"quantity" binding:"required,gte=0"`
 }
 
+// UpdateBook replaces the authors or categories of a book when it names or
+// lists any, the same way as AddBook.
 type UpdateBook struct {
-	Title    string `json:"title"`
-	Author   string `json:"author"`
-	ISBN     string `json:"isbn"`
-	Category string `json:"category"`
-	Quantity int    `json:"quantity" binding:"omitempty,gte=0"`
+	Title       string   `json:"title"`
+	Author      string   `json:"author" binding:"max=100"`
+	AuthorIds   []string `json:"author_ids" binding:"omitempty,min=1,max=10,unique,dive,uuid"`
+	ISBN        string   `json:"isbn" binding:"omitempty,isbn"`
+	Category    string   `json:"category" binding:"max=100"`
+	CategoryIds []string `json:"category_ids" binding:"omitempty,min=1,max=10,unique,dive,uuid"`
+	Quantity    int      `json:"quantity" binding:"omitempty,gte=0"`
+}
+
+// BookFilter narrows the catalog. Categories and Authors match any of their
+// names, CategoryIds and AuthorIds any of their ids, InStock keeps books that
+// can be borrowed right now. SearchMode is natural, boolean or like.
+type BookFilter struct {
+	Search      string
+	SearchMode  string
+	Categories  []string
+	CategoryIds []string
+	Authors     []string
+	AuthorIds   []string
+	InStock     bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinQuantity *int
+	MaxQuantity *int
+	OrderBy     string
+	OrderDir    string
+}
+
+// BookCursor is the position after the last book of a page in cursor
+// pagination: the value of the order column, nil when it is NULL, and the id
+// that breaks ties. It is handed to clients as an opaque token.
+type BookCursor struct {
+	OrderBy  string  `json:"o"`
+	OrderDir string  `json:"d"`
+	Value    *string `json:"v"`
+	Id       string  `json:"i"`
+}
+
+func (c BookCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeBookCursor(token string) (BookCursor, error) {
+	var cursor BookCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.Id == "" {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	return cursor, nil
 }
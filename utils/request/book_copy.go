@@ -0,0 +1,13 @@
+package request
+
+type AddBookCopy struct {
+	Barcode   string `json:"barcode"`
+	Condition string `json:"condition" binding:"omitempty,oneof=new good fair poor damaged"`
+	Location  string `json:"location"`
+}
+
+type UpdateBookCopy struct {
+	Condition string `json:"condition" binding:"omitempty,oneof=new good fair poor damaged"`
+	Location  string `json:"location"`
+	Status    string `json:"status" binding:"omitempty,oneof=available maintenance lost withdrawn"`
+}
@@ -0,0 +1,60 @@
+package request
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestBookCursorRoundTrip(t *testing.T) {
+	value, empty := "2024-05-01T10:00:00.123456Z", ""
+	tests := map[string]BookCursor{
+		"value":        {OrderBy: "updated_at", OrderDir: "desc", Value: &value, Id: "b1"},
+		"NULL value":   {OrderBy: "updated_at", OrderDir: "desc", Value: nil, Id: "b2"},
+		"empty string": {OrderBy: "title", OrderDir: "asc", Value: &empty, Id: "b3"},
+	}
+
+	for name, cursor := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := DecodeBookCursor(cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodeBookCursor: %v", err)
+			}
+			if !reflect.DeepEqual(got, cursor) {
+				t.Errorf("DecodeBookCursor = %+v, want %+v", got, cursor)
+			}
+		})
+	}
+}
+
+func TestBookCursorTokenIsURLSafe(t *testing.T) {
+	value := "??>>~~"
+	token := BookCursor{OrderBy: "title", OrderDir: "asc", Value: &value, Id: "b1"}.Encode()
+
+	for _, c := range token {
+		if c == '+' || c == '/' || c == '=' {
+			t.Fatalf("token %q has a character that needs escaping in a query string", token)
+		}
+	}
+}
+
+func TestDecodeBookCursorInvalid(t *testing.T) {
+	valid := BookCursor{OrderBy: "title", OrderDir: "asc", Id: "b1"}.Encode()
+	tests := map[string]string{
+		"empty":                "",
+		"not base64":           "!!!",
+		"padded base64":        base64.URLEncoding.EncodeToString([]byte(`{"o":"title","d":"asc","i":"b1"}`)),
+		"not JSON":             base64.RawURLEncoding.EncodeToString([]byte("title,asc,b1")),
+		"JSON of another type": base64.RawURLEncoding.EncodeToString([]byte(`["title","asc","b1"]`)),
+		"without id":           base64.RawURLEncoding.EncodeToString([]byte(`{"o":"title","d":"asc","v":"Go"}`)),
+		"cut short":            valid[:len(valid)-4],
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if cursor, err := DecodeBookCursor(token); err == nil {
+				t.Errorf("DecodeBookCursor(%q) = %+v, want an error", token, cursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+package request
+
+type Category struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
@@ -0,0 +1,13 @@
+package request
+
+import "digital-book-lending/models"
+
+type FinePayment struct {
+	Amount models.Money `json:"amount" binding:"required,gt=0" swaggertype:"number"`
+	Note   string       `json:"note"`
+}
+
+type FineWaiver struct {
+	Amount models.Money `json:"amount" binding:"required,gt=0" swaggertype:"number"`
+	Reason string       `json:"reason" binding:"required"`
+}
@@ -0,0 +1,24 @@
+package request
+
+import "time"
+
+type LendingFilter struct {
+	UserId   string
+	BookId   string
+	BookIds  []string
+	Status   string
+	Overdue  *bool
+	FromDate *time.Time
+	ToDate   *time.Time
+	OrderBy  string
+	OrderDir string
+}
+
+type Checkout struct {
+	BookId string `json:"book_id" binding:"required,uuid"`
+	UserId string `json:"user_id" binding:"required,uuid"`
+}
+
+type Checkin struct {
+	UserId string `json:"user_id" binding:"required,uuid"`
+}
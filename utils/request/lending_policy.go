@@ -0,0 +1,12 @@
+package request
+
+type LendingPolicy struct {
+	Name               string `json:"name" binding:"required"`
+	Role               string `json:"role"`
+	Category           string `json:"category"`
+	MaxConcurrentLoans *int   `json:"max_concurrent_loans" binding:"omitempty,gte=1"`
+	WindowDays         *int   `json:"window_days" binding:"required_with=WindowMaxBorrows,omitempty,gte=1"`
+	WindowMaxBorrows   *int   `json:"window_max_borrows" binding:"required_with=WindowDays,omitempty,gte=1"`
+	LoanPeriodDays     int    `json:"loan_period_days" binding:"required,gte=1"`
+	MaxRenewals        int    `json:"max_renewals" binding:"gte=0"`
+}
@@ -0,0 +1,10 @@
+package request
+
+import "time"
+
+type BookLicense struct {
+	Type         string     `json:"type" binding:"required,oneof=one_copy_one_user metered time_limited simultaneous_use"`
+	Seats        *int       `json:"seats" binding:"omitempty,gte=1"`
+	MaxCheckouts *int       `json:"max_checkouts" binding:"omitempty,gte=1"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
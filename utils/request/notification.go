@@ -0,0 +1,8 @@
+package request
+
+type NotificationPreference struct {
+	Locale    string `json:"locale" binding:"required,oneof=en id"`
+	DueSoon   *bool  `json:"due_soon" binding:"required"`
+	Overdue   *bool  `json:"overdue" binding:"required"`
+	HoldReady *bool  `json:"hold_ready" binding:"required"`
+}
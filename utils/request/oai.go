@@ -0,0 +1,37 @@
+package request
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Harvest is an OAI-PMH list request: the metadata format and the range of
+// datestamps asked for, both ends included. After and AfterId are the position
+// past the last record already sent and Cursor counts those records, so the
+// resumption token carries the whole harvest and the server keeps no state.
+type Harvest struct {
+	MetadataPrefix string     `json:"m"`
+	From           *time.Time `json:"f,omitempty"`
+	Until          *time.Time `json:"u,omitempty"`
+	After          *time.Time `json:"a,omitempty"`
+	AfterId        string     `json:"i,omitempty"`
+	Cursor         int        `json:"c,omitempty"`
+}
+
+func (h Harvest) Token() string {
+	data, _ := json.Marshal(h)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeHarvest(token string) (Harvest, error) {
+	var harvest Harvest
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, &harvest) != nil || harvest.MetadataPrefix == "" || harvest.After == nil || harvest.AfterId == "" {
+		return harvest, errors.New("invalid resumption token")
+	}
+
+	return harvest, nil
+}
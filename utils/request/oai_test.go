@@ -0,0 +1,53 @@
+package request
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestHarvestTokenRoundTrip(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.FixedZone("WIB", 7*60*60))
+	harvest := Harvest{MetadataPrefix: "oai_dc", From: &from, After: &after, AfterId: "b1", Cursor: 50}
+
+	got, err := DecodeHarvest(harvest.Token())
+	if err != nil {
+		t.Fatalf("DecodeHarvest: %v", err)
+	}
+	if got.MetadataPrefix != "oai_dc" || got.AfterId != "b1" || got.Cursor != 50 || got.Until != nil {
+		t.Errorf("DecodeHarvest = %+v, want %+v", got, harvest)
+	}
+	if got.From == nil || !got.From.Equal(from) {
+		t.Errorf("From = %v, want %v", got.From, from)
+	}
+	if got.After == nil || !got.After.Equal(after) {
+		t.Errorf("After = %v, want %v to the nanosecond", got.After, after)
+	}
+}
+
+func TestDecodeHarvestInvalid(t *testing.T) {
+	after := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	token := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	tests := map[string]string{
+		"empty":            "",
+		"not base64":       "oai_dc:b1",
+		"not JSON":         token("oai_dc"),
+		"bad time":         token(`{"m":"oai_dc","a":"yesterday","i":"b1"}`),
+		"without prefix":   Harvest{After: &after, AfterId: "b1"}.Token(),
+		"without position": Harvest{MetadataPrefix: "oai_dc", Cursor: 50}.Token(),
+		"without id":       Harvest{MetadataPrefix: "oai_dc", After: &after}.Token(),
+		"without after":    Harvest{MetadataPrefix: "oai_dc", AfterId: "b1"}.Token(),
+		"cut short":        Harvest{MetadataPrefix: "oai_dc", After: &after, AfterId: "b1"}.Token()[:20],
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if harvest, err := DecodeHarvest(token); err == nil {
+				t.Errorf("DecodeHarvest(%q) = %+v, want an error", token, harvest)
+			}
+		})
+	}
+}
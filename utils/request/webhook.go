@@ -0,0 +1,8 @@
+package request
+
+type WebhookSubscription struct {
+	Url         string   `json:"url" binding:"required,url,max=2048"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1,unique,dive,oneof=book.created book.updated book.deleted loan.borrowed loan.returned"`
+	IsActive    *bool    `json:"is_active"`
+}
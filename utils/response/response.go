@@ -17,6 +17,9 @@ type Error Api
 // Pagination is an alias for PaginatedResponse for swag documentation.
 type Pagination PaginatedResponse
 
+// CursorPagination is an alias for CursorPaginatedResponse for swag documentation.
+type CursorPagination CursorPaginatedResponse
+
 type Errors struct {
 	Code    int    `json:"code,omitempty"`
 	Message string `json:"message,omitempty"`
@@ -44,9 +47,24 @@ type PaginatedResponse struct {
 	PrevPage    bool        `json:"prev_page"`
 	Limit       int         `json:"limit"`
 	Data        interface{} `json:"data"`
+	Facets      interface{} `json:"facets,omitempty"`
 	Error       interface{} `json:"error,omitempty"`
 }
 
+// CursorPaginatedResponse is a page of a cursor-paginated list. NextCursor is
+// nil on the last page.
+type CursorPaginatedResponse struct {
+	LogID      string      `json:"log_id"`
+	Code       int         `json:"code"`
+	Status     string      `json:"status"`
+	Message    string      `json:"message"`
+	Limit      int         `json:"limit"`
+	NextCursor *string     `json:"next_cursor"`
+	Data       interface{} `json:"data"`
+	Facets     interface{} `json:"facets,omitempty"`
+	Error      interface{} `json:"error,omitempty"`
+}
+
 func Response(code int, msg string, logId uuid.UUID, data interface{}) *Api {
 	res := new(Api)
 	res.Id = logId
@@ -99,3 +117,24 @@ func PaginationResponse(code, total, page, perPage int, logId uuid.UUID, data in
 
 	return res
 }
+
+func CursorPaginationResponse(code, count, perPage int, nextCursor string, logId uuid.UUID, data interface{}) *CursorPaginatedResponse {
+	res := new(CursorPaginatedResponse)
+
+	message := utils.MsgSuccess
+	if count == 0 {
+		message = utils.MsgNotFound
+	}
+
+	res.LogID = logId.String()
+	res.Code = code
+	res.Status = http.StatusText(code)
+	res.Message = message
+	res.Limit = perPage
+	res.Data = data
+	if nextCursor != "" {
+		res.NextCursor = &nextCursor
+	}
+
+	return res
+}
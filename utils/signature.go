@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// SignEbookLink signs the e-book link of a lending record that is valid until
+// the given unix time.
+func SignEbookLink(lendingId string, expires int64) string {
+	mac := hmac.New(sha256.New, ebookLinkKey())
+	mac.Write([]byte(fmt.Sprintf("%s:%d", lendingId, expires)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyEbookLink(lendingId string, expires int64, signature string) bool {
+	expected, err := hex.DecodeString(SignEbookLink(lendingId, expires))
+	if err != nil {
+		return false
+	}
+	given, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(expected, given)
+}
+
+func ebookLinkKey() []byte {
+	if key := GetEnv("EBOOK_LINK_KEY", "").(string); key != "" {
+		return []byte(key)
+	}
+	return []byte(GetEnv("JWT_KEY", "").(string))
+}
+
+// SignWebhook signs a webhook request body sent at the given unix time with
+// the secret of the subscription. Receivers compute the same over
+// "<timestamp>.<body>" and compare.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestEbookLinkSignature(t *testing.T) {
+	t.Setenv("EBOOK_LINK_KEY", "ebook-key")
+	expires := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Unix()
+	signature := SignEbookLink("l1", expires)
+	changed := "0"
+	if signature[0] == '0' {
+		changed = "1"
+	}
+
+	if !VerifyEbookLink("l1", expires, signature) {
+		t.Fatal("VerifyEbookLink rejected its own signature")
+	}
+
+	tests := []struct {
+		name      string
+		lendingId string
+		expires   int64
+		signature string
+	}{
+		{name: "other loan", lendingId: "l2", expires: expires, signature: signature},
+		{name: "later expiry", lendingId: "l1", expires: expires + 3600, signature: signature},
+		{name: "changed signature", lendingId: "l1", expires: expires, signature: changed + signature[1:]},
+		{name: "cut signature", lendingId: "l1", expires: expires, signature: signature[:32]},
+		{name: "not hex", lendingId: "l1", expires: expires, signature: "zz" + signature[2:]},
+		{name: "empty signature", lendingId: "l1", expires: expires},
+	}
+
+	for _, tt := range tests {
+		if VerifyEbookLink(tt.lendingId, tt.expires, tt.signature) {
+			t.Errorf("%s: VerifyEbookLink accepted the link", tt.name)
+		}
+	}
+}
+
+func TestEbookLinkKey(t *testing.T) {
+	expires := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Unix()
+
+	t.Setenv("EBOOK_LINK_KEY", "")
+	t.Setenv("JWT_KEY", "jwt-key")
+	signature := SignEbookLink("l1", expires)
+
+	t.Setenv("EBOOK_LINK_KEY", "ebook-key")
+	if VerifyEbookLink("l1", expires, signature) {
+		t.Error("a link signed with JWT_KEY is valid once EBOOK_LINK_KEY is set")
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"type":"book.created","data":{"id":"b1"}}`)
+
+	// the signature a receiver computes over "<timestamp>.<body>"
+	want := hex.EncodeToString(hmacOf("secret", "1714557600."+string(body)))
+
+	if got := SignWebhook("secret", 1714557600, body); got != want {
+		t.Fatalf("SignWebhook = %s, want %s", got, want)
+	}
+
+	tests := map[string]string{
+		"other secret":    SignWebhook("other", 1714557600, body),
+		"other timestamp": SignWebhook("secret", 1714557601, body),
+		"other body":      SignWebhook("secret", 1714557600, []byte(`{"type":"book.created","data":{"id":"b2"}}`)),
+		"no separator":    hex.EncodeToString(hmacOf("secret", "1714557600"+string(body))),
+	}
+	for name, got := range tests {
+		if got == want {
+			t.Errorf("%s: signature did not change", name)
+		}
+	}
+}
+
+func hmacOf(secret, message string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}